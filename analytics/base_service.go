@@ -0,0 +1,21 @@
+package analytics
+
+import (
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type Service struct {
+	or domain.OrderRepository
+	pr domain.ProductRepository
+	ur domain.UserRepository
+	jr domain.JwtRepository
+}
+
+func InitService(
+	or domain.OrderRepository,
+	pr domain.ProductRepository,
+	ur domain.UserRepository,
+	jr domain.JwtRepository,
+) domain.AnalyticsService {
+	return &Service{or: or, pr: pr, ur: ur, jr: jr}
+}
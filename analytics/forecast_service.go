@@ -0,0 +1,122 @@
+package analytics
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultWindowDays = 28
+	defaultCoverDays  = 14
+	maxDays           = 365
+)
+
+// Forecast estimates daily sales velocity of each product of the seller as
+// the average of the window before AsOf, then ranks them by days left.
+// Products which don't sell come last.
+func (s *Service) Forecast(ctx context.Context, opts domain.ForecastOptions) ([]domain.Forecast, error) {
+	const op string = "analytics.service.Forecast"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	switch u.Role {
+	case domain.SELLER:
+		opts.SellerId = u.Id
+	case domain.ADMIN:
+		if opts.SellerId == 0 {
+			return nil, errors.Wrap(domain.ErrInvalidParams, "seller id is required")
+		}
+	default:
+		return nil, domain.ErrPermissionDenied
+	}
+
+	if opts.WindowDays <= 0 {
+		opts.WindowDays = defaultWindowDays
+	}
+	if opts.CoverDays <= 0 {
+		opts.CoverDays = defaultCoverDays
+	}
+	if opts.WindowDays > maxDays || opts.CoverDays > maxDays {
+		return nil, domain.ErrInvalidParams
+	}
+	if opts.AsOf.IsZero() {
+		opts.AsOf = time.Now()
+	}
+	today := startOfDay(opts.AsOf)
+
+	ps, err := s.pr.ListBySeller(ctx, opts.SellerId)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	// window ends with today, which is usually not over yet
+	// so it's left out to not underestimate the velocity
+	sales, err := s.or.DailySales(ctx, opts.SellerId, today.AddDate(0, 0, -opts.WindowDays), today)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return forecast(ps, sales, today, opts.WindowDays, opts.CoverDays), nil
+}
+
+func forecast(ps []domain.Product, sales []domain.DailySales, today time.Time, window, cover int) []domain.Forecast {
+	sold := make(map[uint]uint)
+	for _, s := range sales {
+		sold[s.ProductId] += s.Units
+	}
+
+	fs := make([]domain.Forecast, len(ps))
+	for i, p := range ps {
+		f := domain.Forecast{
+			ProductId: p.Id,
+			Name:      p.Name,
+			Stock:     p.Count,
+			Velocity:  float64(sold[p.Id]) / float64(window),
+		}
+		if f.Velocity > 0 || p.Count == 0 {
+			daysLeft := 0.0
+			if f.Velocity > 0 {
+				daysLeft = float64(p.Count) / f.Velocity
+			}
+			stockout := today.AddDate(0, 0, int(math.Floor(daysLeft)))
+			f.DaysLeft = &daysLeft
+			f.StockoutDate = &stockout
+		}
+		if need := math.Ceil(f.Velocity * float64(cover)); need > float64(p.Count) {
+			f.Restock = uint(need) - p.Count
+		}
+		fs[i] = f
+	}
+
+	sort.SliceStable(fs, func(i, j int) bool {
+		a, b := fs[i].DaysLeft, fs[j].DaysLeft
+		switch {
+		case a == nil && b == nil:
+			return fs[i].ProductId < fs[j].ProductId
+		case a == nil || b == nil:
+			return b == nil
+		case *a != *b:
+			return *a < *b
+		default:
+			return fs[i].ProductId < fs[j].ProductId
+		}
+	})
+
+	return fs
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,134 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/analytics"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Forecast(t *testing.T) {
+	type want struct {
+		productId uint
+		velocity  float64
+		daysLeft  float64
+		stockout  string
+		restock   uint
+	}
+	type wants struct {
+		err       error
+		forecasts []want
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		opts    domain.ForecastOptions
+		wants   wants
+	}
+
+	or := new(mocks.OrderRepository)
+	pr := new(mocks.ProductRepository)
+
+	sellerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 2, Role: domain.SELLER,
+	})
+	asOf := time.Date(2021, time.November, 15, 17, 30, 0, 0, time.UTC)
+	today := time.Date(2021, time.November, 15, 0, 0, 0, 0, time.UTC)
+
+	// synthetic history of the last 10 days:
+	// cake sells 2 a day, soda 1 a day, tea sold 5 once, gum never
+	history := func() []domain.DailySales {
+		sales := make([]domain.DailySales, 0)
+		for d := 1; d <= 10; d++ {
+			day := today.AddDate(0, 0, -d)
+			sales = append(sales,
+				domain.DailySales{ProductId: 1, Day: day, Units: 2},
+				domain.DailySales{ProductId: 2, Day: day, Units: 1},
+			)
+		}
+		return append(sales, domain.DailySales{ProductId: 3, Day: today.AddDate(0, 0, -3), Units: 5})
+	}
+	catalog := []domain.Product{
+		{Id: 1, Name: "Cake", Count: 5, SellerId: 2},
+		{Id: 2, Name: "Soda", Count: 30, SellerId: 2},
+		{Id: 3, Name: "Tea", Count: 0, SellerId: 2},
+		{Id: 4, Name: "Gum", Count: 10, SellerId: 2},
+	}
+
+	testCases := []testCase{
+		{
+			name: "should rank products by days left and suggest restock",
+			prepare: func() {
+				pr.On("ListBySeller", mock.Anything, uint(2)).Return(catalog, nil).Once()
+				or.On("DailySales", mock.Anything, uint(2), today.AddDate(0, 0, -10), today).
+					Return(history(), nil).Once()
+			},
+			ctx:  sellerContext,
+			opts: domain.ForecastOptions{WindowDays: 10, CoverDays: 7, AsOf: asOf},
+			wants: wants{
+				forecasts: []want{
+					{productId: 3, velocity: 0.5, daysLeft: 0, stockout: "2021-11-15", restock: 4},
+					{productId: 1, velocity: 2, daysLeft: 2.5, stockout: "2021-11-17", restock: 9},
+					{productId: 2, velocity: 1, daysLeft: 30, stockout: "2021-12-15", restock: 0},
+					{productId: 4},
+				},
+			},
+		},
+		{
+			name:    "should fail when admin doesn't choose a seller",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 1, Role: domain.ADMIN,
+			}),
+			wants: wants{err: domain.ErrInvalidParams},
+		},
+		{
+			name:    "should fail when buyer asks for forecast",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 5, Role: domain.BUYER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := analytics.InitService(or, pr, nil, nil)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		fs, err := svc.Forecast(tc.ctx, tc.opts)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, fs, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		if !assert.Len(t, fs, len(tc.wants.forecasts), tc.name) {
+			continue
+		}
+		for i, w := range tc.wants.forecasts {
+			f := fs[i]
+			assert.Equal(t, w.productId, f.ProductId, tc.name)
+			assert.InDelta(t, w.velocity, f.Velocity, 1e-9, tc.name)
+			assert.Equal(t, w.restock, f.Restock, tc.name)
+			if w.stockout == "" {
+				assert.Nil(t, f.DaysLeft, tc.name)
+				assert.Nil(t, f.StockoutDate, tc.name)
+				continue
+			}
+			assert.InDelta(t, w.daysLeft, *f.DaysLeft, 1e-9, tc.name)
+			assert.Equal(t, w.stockout, f.StockoutDate.Format("2006-01-02"), tc.name)
+		}
+	}
+	or.AssertExpectations(t)
+	pr.AssertExpectations(t)
+}
@@ -0,0 +1,61 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func (s *Service) Overview(ctx context.Context) (*domain.Overview, error) {
+	const op string = "analytics.service.Overview"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if u.Role != domain.ADMIN {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	now := time.Now()
+	o := &domain.Overview{GeneratedAt: now}
+
+	o.Users, err = s.ur.CountByRole(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	o.BuyerCredit, err = s.ur.SumDeposits(ctx, domain.BUYER)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	stock, err := s.pr.StockStats(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	o.Stock = *stock
+
+	today := startOfDay(now)
+	sales, err := s.or.SalesTotals(ctx, today, today.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	o.SalesToday = *sales
+
+	o.ActiveSessions, err = s.jr.ActiveCount(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return o, nil
+}
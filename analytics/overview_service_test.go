@@ -0,0 +1,89 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apm-dev/vending-machine/analytics"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Overview(t *testing.T) {
+	type wants struct {
+		err      error
+		overview *domain.Overview
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wants   wants
+	}
+
+	or := new(mocks.OrderRepository)
+	pr := new(mocks.ProductRepository)
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+
+	users := map[domain.Role]uint{domain.ADMIN: 1, domain.SELLER: 2, domain.BUYER: 7}
+	stock := &domain.StockStats{Units: 40, Value: 250, OutOfStock: 3}
+	sales := &domain.SalesTotals{Orders: 4, Units: 9, Revenue: 65}
+
+	testCases := []testCase{
+		{
+			name: "should gather figures from aggregate queries",
+			prepare: func() {
+				ur.On("CountByRole", mock.Anything).Return(users, nil).Once()
+				ur.On("SumDeposits", mock.Anything, domain.BUYER).Return(uint(120), nil).Once()
+				pr.On("StockStats", mock.Anything).Return(stock, nil).Once()
+				or.On("SalesTotals", mock.Anything, mock.Anything, mock.Anything).Return(sales, nil).Once()
+				jr.On("ActiveCount", mock.Anything).Return(uint(5), nil).Once()
+			},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 1, Role: domain.ADMIN,
+			}),
+			wants: wants{
+				overview: &domain.Overview{
+					Users:          users,
+					BuyerCredit:    120,
+					Stock:          *stock,
+					SalesToday:     *sales,
+					ActiveSessions: 5,
+				},
+			},
+		},
+		{
+			name:    "should fail when seller asks for overview",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 2, Role: domain.SELLER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := analytics.InitService(or, pr, ur, jr)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		o, err := svc.Overview(tc.ctx)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, o, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		o.GeneratedAt = tc.wants.overview.GeneratedAt
+		assert.Equal(t, tc.wants.overview, o, tc.name)
+	}
+	or.AssertExpectations(t)
+	pr.AssertExpectations(t)
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+}
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+type AnalyticsHandler struct {
+	as domain.AnalyticsService
+}
+
+// InitAnalyticsHandler
+// auth echo group which uses auth middleware
+func InitAnalyticsHandler(auth *echo.Group, as domain.AnalyticsService) *AnalyticsHandler {
+	h := &AnalyticsHandler{as: as}
+
+	ag := auth.Group("/analytics")
+	ag.GET("/forecast", h.Forecast)
+	ag.GET("/sales", h.Sales)
+	ag.GET("/sales/top", h.TopSellers)
+
+	auth.GET("/admin/overview", h.Overview)
+
+	return h
+}
+
+func (h *AnalyticsHandler) Overview(c echo.Context) error {
+	o, err := h.as.Overview(c.Request().Context())
+	return checkErrorThenResponse(c, err, o)
+}
+
+func checkErrorThenResponse(c echo.Context, err error, content interface{}) error {
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", content,
+	))
+}
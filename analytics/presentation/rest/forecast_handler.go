@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/apm-dev/vending-machine/analytics/presentation/rest/requests"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+func (h *AnalyticsHandler) Forecast(c echo.Context) error {
+	req := new(requests.Forecast)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	fs, err := h.as.Forecast(c.Request().Context(), domain.ForecastOptions{
+		SellerId:   req.SellerId,
+		WindowDays: req.Window,
+		CoverDays:  req.Cover,
+	})
+
+	return checkErrorThenResponse(c, err, fs)
+}
@@ -0,0 +1,29 @@
+package requests
+
+type Forecast struct {
+	// admins choose the seller, sellers always get their own
+	SellerId uint `query:"seller_id"`
+	// days of sales history to estimate velocity from
+	Window int `query:"window" validate:"gte=0,lte=365"`
+	// days the suggested restock should last for
+	Cover int `query:"cover" validate:"gte=0,lte=365"`
+}
+
+type Sales struct {
+	// admins may choose a seller, all sellers by default
+	SellerId uint `query:"seller_id"`
+	// inclusive date range as YYYY-MM-DD, last 30 days by default
+	From   string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To     string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Bucket string `query:"bucket" validate:"omitempty,oneof=day week hour"`
+	Format string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+type TopSellers struct {
+	SellerId uint   `query:"seller_id"`
+	From     string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To       string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	By       string `query:"by" validate:"omitempty,oneof=revenue units"`
+	Limit    int    `query:"limit" validate:"gte=0,lte=100"`
+	Format   string `query:"format" validate:"omitempty,oneof=json csv"`
+}
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apm-dev/vending-machine/analytics/presentation/rest/requests"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+const (
+	dateLayout       = "2006-01-02"
+	defaultRangeDays = 30
+	maxRangeDays     = 366
+)
+
+func (h *AnalyticsHandler) Sales(c echo.Context) error {
+	req := new(requests.Sales)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	q, err := salesQuery(req.SellerId, req.From, req.To, req.Bucket)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	report, err := h.as.Sales(c.Request().Context(), q)
+	if err != nil || req.Format != "csv" {
+		return checkErrorThenResponse(c, err, report)
+	}
+
+	records := [][]string{{"period", "product_id", "seller_id", "name", "units", "revenue"}}
+	for _, row := range report.Breakdown {
+		records = append(records, append([]string{row.Period}, productSalesRecord(row.ProductSales)...))
+	}
+	return csvResponse(c, "sales.csv", records)
+}
+
+func (h *AnalyticsHandler) TopSellers(c echo.Context) error {
+	req := new(requests.TopSellers)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	q, err := salesQuery(req.SellerId, req.From, req.To, "")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	ps, err := h.as.TopSellers(c.Request().Context(), q, req.By == "units", req.Limit)
+	if err != nil || req.Format != "csv" {
+		return checkErrorThenResponse(c, err, ps)
+	}
+
+	records := [][]string{{"rank", "product_id", "seller_id", "name", "units", "revenue"}}
+	for i, p := range ps {
+		records = append(records, append([]string{strconv.Itoa(i + 1)}, productSalesRecord(p)...))
+	}
+	return csvResponse(c, "top-sellers.csv", records)
+}
+
+// salesQuery converts inclusive date range of the request to [from, to),
+// days start in the local time zone which is the time zone of db sessions
+func salesQuery(sellerId uint, from, to, bucket string) (domain.SalesQuery, error) {
+	q := domain.SalesQuery{SellerId: sellerId, Bucket: bucket}
+	if q.Bucket == "" {
+		q.Bucket = domain.BucketDay
+	}
+
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if to != "" {
+		t, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return q, err
+		}
+		end = t
+	}
+	start := end.AddDate(0, 0, -defaultRangeDays+1)
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return q, err
+		}
+		start = t
+	}
+
+	q.From = start
+	q.To = end.AddDate(0, 0, 1)
+	if !q.From.Before(q.To) || q.To.Sub(q.From) > maxRangeDays*24*time.Hour {
+		return q, domain.ErrInvalidParams
+	}
+
+	return q, nil
+}
+
+func productSalesRecord(p domain.ProductSales) []string {
+	return []string{
+		strconv.FormatUint(uint64(p.ProductId), 10),
+		strconv.FormatUint(uint64(p.SellerId), 10),
+		p.Name,
+		strconv.FormatUint(uint64(p.Units), 10),
+		strconv.FormatUint(uint64(p.Revenue), 10),
+	}
+}
+
+func csvResponse(c echo.Context, filename string, records [][]string) error {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err := w.WriteAll(records); err != nil {
+		return checkErrorThenResponse(c, err, nil)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return c.Blob(http.StatusOK, "text/csv", buf.Bytes())
+}
@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"context"
+	"sort"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Sales aggregates persisted orders, so it reports what was actually
+// sold at the price of the sale, net of refunds
+func (s *Service) Sales(ctx context.Context, q domain.SalesQuery) (*domain.SalesReport, error) {
+	rows, err := s.sales(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.SalesReport{
+		From:      q.From,
+		To:        q.To,
+		Bucket:    q.Bucket,
+		Products:  rankProducts(rows, false),
+		Breakdown: rows,
+	}
+	for _, p := range report.Products {
+		report.Units += p.Units
+		report.Revenue += p.Revenue
+	}
+
+	return report, nil
+}
+
+func (s *Service) TopSellers(ctx context.Context, q domain.SalesQuery, byUnits bool, limit int) ([]domain.ProductSales, error) {
+	// totals don't depend on the bucket
+	q.Bucket = domain.BucketWeek
+	rows, err := s.sales(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := rankProducts(rows, byUnits)
+	if limit > 0 && len(ps) > limit {
+		ps = ps[:limit]
+	}
+
+	return ps, nil
+}
+
+// sales scopes the query to the caller and loads its rows
+func (s *Service) sales(ctx context.Context, q *domain.SalesQuery) ([]domain.SalesRow, error) {
+	const op string = "analytics.service.sales"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	switch u.Role {
+	case domain.SELLER:
+		q.SellerId = u.Id
+	case domain.ADMIN:
+	default:
+		return nil, domain.ErrPermissionDenied
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.or.Sales(ctx, *q)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return rows, nil
+}
+
+// rankProducts sums rows of each product and sorts them descending
+func rankProducts(rows []domain.SalesRow, byUnits bool) []domain.ProductSales {
+	index := make(map[uint]int)
+	ps := make([]domain.ProductSales, 0)
+	for _, row := range rows {
+		i, ok := index[row.ProductId]
+		if !ok {
+			i = len(ps)
+			index[row.ProductId] = i
+			ps = append(ps, domain.ProductSales{
+				ProductId: row.ProductId,
+				SellerId:  row.SellerId,
+				Name:      row.Name,
+			})
+		}
+		ps[i].Units += row.Units
+		ps[i].Revenue += row.Revenue
+	}
+
+	sort.SliceStable(ps, func(i, j int) bool {
+		a, b := ps[i].Revenue, ps[j].Revenue
+		if byUnits {
+			a, b = ps[i].Units, ps[j].Units
+		}
+		if a != b {
+			return a > b
+		}
+		return ps[i].ProductId < ps[j].ProductId
+	})
+
+	return ps
+}
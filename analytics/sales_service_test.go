@@ -0,0 +1,117 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/analytics"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Sales(t *testing.T) {
+	type wants struct {
+		err      error
+		units    uint
+		revenue  uint
+		products []uint
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		query   domain.SalesQuery
+		wants   wants
+	}
+
+	or := new(mocks.OrderRepository)
+	pr := new(mocks.ProductRepository)
+
+	from := time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.November, 8, 0, 0, 0, 0, time.UTC)
+	row := func(period string, pid, units, revenue uint) domain.SalesRow {
+		return domain.SalesRow{Period: period, ProductSales: domain.ProductSales{
+			ProductId: pid, SellerId: 2, Units: units, Revenue: revenue,
+		}}
+	}
+	rows := []domain.SalesRow{
+		row("2021-11-01", 1, 2, 20),
+		row("2021-11-01", 2, 6, 30),
+		row("2021-11-02", 1, 1, 10),
+		row("2021-11-03", 3, 1, 5),
+	}
+
+	testCases := []testCase{
+		{
+			name: "should scope seller to own products and rank them by revenue",
+			prepare: func() {
+				or.On("Sales", mock.Anything, domain.SalesQuery{
+					SellerId: 2, From: from, To: to, Bucket: domain.BucketDay,
+				}).Return(rows, nil).Once()
+			},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 2, Role: domain.SELLER,
+			}),
+			query: domain.SalesQuery{SellerId: 9, From: from, To: to, Bucket: domain.BucketDay},
+			wants: wants{units: 10, revenue: 65, products: []uint{1, 2, 3}},
+		},
+		{
+			name: "should report all sellers to admin",
+			prepare: func() {
+				or.On("Sales", mock.Anything, domain.SalesQuery{
+					From: from, To: to, Bucket: domain.BucketHourOfDay,
+				}).Return(rows[1:], nil).Once()
+			},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 1, Role: domain.ADMIN,
+			}),
+			query: domain.SalesQuery{From: from, To: to, Bucket: domain.BucketHourOfDay},
+			wants: wants{units: 8, revenue: 45, products: []uint{2, 1, 3}},
+		},
+		{
+			name:    "should fail on unknown bucket",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 1, Role: domain.ADMIN,
+			}),
+			query: domain.SalesQuery{From: from, To: to, Bucket: "month"},
+			wants: wants{err: domain.ErrInvalidParams},
+		},
+		{
+			name:    "should fail when buyer asks for sales",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 5, Role: domain.BUYER,
+			}),
+			query: domain.SalesQuery{From: from, To: to, Bucket: domain.BucketDay},
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := analytics.InitService(or, pr, nil, nil)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		report, err := svc.Sales(tc.ctx, tc.query)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, report, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.wants.units, report.Units, tc.name)
+		assert.Equal(t, tc.wants.revenue, report.Revenue, tc.name)
+		ids := make([]uint, len(report.Products))
+		for i, p := range report.Products {
+			ids[i] = p.ProductId
+		}
+		assert.Equal(t, tc.wants.products, ids, tc.name)
+	}
+	or.AssertExpectations(t)
+}
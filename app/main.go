@@ -1,16 +1,35 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/apm-dev/vending-machine/analytics"
+	analyticsRest "github.com/apm-dev/vending-machine/analytics/presentation/rest"
+	"github.com/apm-dev/vending-machine/cart"
+	cartPgsql "github.com/apm-dev/vending-machine/cart/data/pgsql"
+	cartRest "github.com/apm-dev/vending-machine/cart/presentation/rest"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/order"
+	orderPgsql "github.com/apm-dev/vending-machine/order/data/pgsql"
+	orderRest "github.com/apm-dev/vending-machine/order/presentation/rest"
 	"github.com/apm-dev/vending-machine/pkg/httputil"
 	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/apm-dev/vending-machine/pkg/password"
+	"github.com/apm-dev/vending-machine/pkg/ratelimit"
 	"github.com/apm-dev/vending-machine/product"
+	productDispenser "github.com/apm-dev/vending-machine/product/data/dispenser"
 	productPgsql "github.com/apm-dev/vending-machine/product/data/pgsql"
 	productRest "github.com/apm-dev/vending-machine/product/presentation/rest"
+	"github.com/apm-dev/vending-machine/recommendation"
+	recommendationRest "github.com/apm-dev/vending-machine/recommendation/presentation/rest"
 	"github.com/apm-dev/vending-machine/user"
 	userPgsql "github.com/apm-dev/vending-machine/user/data/pgsql"
 	userRest "github.com/apm-dev/vending-machine/user/presentation/rest"
@@ -44,8 +63,13 @@ func main() {
 	dbUser := viper.GetString(`database.user`)
 	dbPass := viper.GetString(`database.pass`)
 	dbName := viper.GetString(`database.name`)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Tehran",
-		dbHost, dbUser, dbPass, dbName, dbPort,
+	dbTimeZone := viper.GetString(`database.timezone`)
+	// days of reports are truncated by the db session, so the app works in the same zone
+	loc, err := time.LoadLocation(dbTimeZone)
+	fatalOnError(err)
+	time.Local = loc
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=%s",
+		dbHost, dbUser, dbPass, dbName, dbPort, loc,
 	)
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -63,23 +87,144 @@ func main() {
 	err = db.AutoMigrate(
 		&userPgsql.User{},
 		&userPgsql.JWT{},
+		&userPgsql.RefreshToken{},
+		&userPgsql.LoginFailure{},
+		&userPgsql.Lockout{},
+		&userPgsql.TwoFactor{},
+		&userPgsql.BackupCode{},
+		&userPgsql.LoginChallenge{},
+		&userPgsql.TwoFactorRole{},
 		&productPgsql.Product{},
+		&productPgsql.ProductPrice{},
+		&productPgsql.ProductTranslation{},
+		&productPgsql.Reservation{},
+		&productPgsql.ReservationItem{},
+		&productPgsql.QuotaRule{},
+		&orderPgsql.Order{},
+		&orderPgsql.OrderItem{},
+		&orderPgsql.Refund{},
+		&orderPgsql.RefundItem{},
+		&cartPgsql.CartItem{},
 	)
 	fatalOnError(err)
+	// tokens are persisted as keyed hashes, so the key is required
+	tokenHashKey := viper.GetString("jwt.hash_key")
+	if tokenHashKey == "" {
+		log.Fatal("jwt.hash_key is required")
+	}
+	err = userPgsql.HashStoredTokens(db, tokenHashKey)
+	fatalOnError(err)
+	// TOTP secrets are encrypted by a key derived from it
+	err = userPgsql.EncryptTwoFactorSecrets(db, tokenHashKey)
+	fatalOnError(err)
 
 	ur := userPgsql.InitUserRepository(db)
-	jr := userPgsql.InitJwtRepository(db)
-	jwt := user.NewJWTManager(
-		viper.GetString("jwt.secret"),
+	jr := userPgsql.InitJwtRepository(db, tokenHashKey)
+	la := userPgsql.InitLoginAttemptRepository(db)
+	tf := userPgsql.InitTwoFactorRepository(db, tokenHashKey)
+	jwt, err := user.NewJWTManager(
+		jwtKeys(),
+		viper.GetString("jwt.signing_key"),
 		time.Duration(viper.GetInt("jwt.duration"))*time.Second,
+		time.Duration(viper.GetInt("jwt.refresh_duration"))*time.Second,
 	)
+	fatalOnError(err)
 	pr := productPgsql.InitProductRepository(db)
+	rr := productPgsql.InitReservationRepository(db)
+	qr := productPgsql.InitQuotaRepository(db)
+	or := orderPgsql.InitOrderRepository(db)
+	cr := cartPgsql.InitCartRepository(db)
+	// hardware
+	failingProducts := make([]uint, 0)
+	for _, pid := range viper.GetIntSlice("dispenser.failing_products") {
+		failingProducts = append(failingProducts, uint(pid))
+	}
+	dispenser := productDispenser.InitSimulatedDispenser(
+		viper.GetFloat64("dispenser.failure_rate"),
+		failingProducts,
+	)
 
 	depositTimeout := time.Duration(viper.GetInt("deposit.timeout")) * time.Second
+	reservationTTL := time.Duration(viper.GetInt("reservation.ttl")) * time.Second
+
+	loginPolicy := domain.LoginPolicy{
+		MaxFailures:   uint(viper.GetInt("login.max_failures")),
+		MaxIPFailures: uint(viper.GetInt("login.max_ip_failures")),
+		Delay:         time.Duration(viper.GetInt("login.delay")) * time.Second,
+		Lockout:       time.Duration(viper.GetInt("login.lockout")) * time.Second,
+		Window:        time.Duration(viper.GetInt("login.window")) * time.Second,
+	}
+
+	twoFactorPolicy := domain.TwoFactorPolicy{
+		Issuer:       viper.GetString("totp.issuer"),
+		ChallengeTTL: time.Duration(viper.GetInt("totp.challenge_ttl")) * time.Second,
+		MaxAttempts:  uint(viper.GetInt("totp.max_attempts")),
+		Skew:         uint(viper.GetInt("totp.skew")),
+		BackupCodes:  uint(viper.GetInt("totp.backup_codes")),
+	}
+
+	// hashes of old algorithm or cost are upgraded on login
+	hasher, err := password.NewHasher(
+		viper.GetString("password.algorithm"),
+		viper.GetInt("password.bcrypt_cost"),
+		password.Argon2Params{
+			Time:    uint32(viper.GetUint("password.argon2.time")),
+			Memory:  uint32(viper.GetUint("password.argon2.memory")),
+			Threads: uint8(viper.GetUint("password.argon2.threads")),
+			KeyLen:  uint32(viper.GetUint("password.argon2.key_length")),
+			SaltLen: uint32(viper.GetUint("password.argon2.salt_length")),
+		},
+	)
+	fatalOnError(err)
+	domain.SetPasswordHasher(hasher)
 
 	// services (usecase)
-	us := user.InitService(ur, jr, jwt, depositTimeout)
-	ps := product.InitService(pr, ur)
+	us := user.InitService(ur, jr, la, tf, jwt, loginPolicy, passwordPolicy(), twoFactorPolicy, depositTimeout)
+	ps := product.InitService(pr, ur, rr, or, qr, dispenser, reservationTTL, viper.GetString("i18n.default_language"))
+	ors := order.InitService(or, ur, pr)
+	rcs := recommendation.InitService(or, pr)
+	as := analytics.InitService(or, pr, ur, jr)
+	cs := cart.InitService(cr, pr, ps)
+
+	// the first admin comes from config, once there is an admin it's ignored
+	if uname := viper.GetString("admin.username"); uname != "" {
+		err = us.BootstrapAdmin(context.Background(), uname, viper.GetString("admin.password"))
+		fatalOnError(err)
+	}
+
+	// background workers and the server stop on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var workers sync.WaitGroup
+
+	// recommendation model is rebuilt from orders periodically,
+	// admins can rebuild it on demand too
+	if interval := time.Duration(viper.GetInt("recommendation.rebuild_interval")) * time.Second; interval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				// errors are logged by the service
+				_ = rcs.Refresh(ctx)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
+	// expired tokens, login challenges and failures are purged periodically
+	if interval := time.Duration(viper.GetInt("jwt.purge_interval")) * time.Second; interval > 0 {
+		janitor := user.InitJanitor(jr, tf, la, loginPolicy, interval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			janitor.Run(ctx)
+		}()
+	}
 
 	// presentation (delivery/controller)
 	e := echo.New()
@@ -89,18 +234,112 @@ func main() {
 	// echo validator
 	e.Validator = httputil.InitCustomValidator()
 	// echo middlewares
-	authMiddleware := middlewares.InitUserMiddleware(us)
+	// forwarded client ips are honoured only from trusted proxies
+	trustedProxies, err := httputil.ParseTrustedProxies(viper.GetStringSlice("server.trusted_proxies"))
+	fatalOnError(err)
+	authMiddleware := middlewares.InitUserMiddleware(us, trustedProxies)
+	// client of the request is recorded on sessions
+	e.Use(authMiddleware.ClientInfo)
+	// limits are counted by user, the subject of token is known without db
+	e.Use(authMiddleware.JwtSubject)
+	// limited requests are refused before identifying the user hits db
+	e.Use(ratelimit.Middleware(ratelimit.NewMemoryStore(), rateLimitRules(), trustedProxies))
+	// identify callers of public routes too (e.g. dietary profile on listing)
+	e.Use(authMiddleware.JwtIdentify)
 	ag := e.Group("", authMiddleware.JwtAuth)
 
 	// rest(http) handlers
 	userRest.InitUserHandler(e, ag, us)
 	productRest.InitProductHandler(e, ag, ps)
+	orderRest.InitOrderHandler(ag, ors)
+	recommendationRest.InitRecommendationHandler(ag, rcs)
+	analyticsRest.InitAnalyticsHandler(ag, as)
+	cartRest.InitCartHandler(ag, cs)
 
 	e.GET("/", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, e.Routes())
 	})
 
-	log.Fatal(e.Start(viper.GetString("server.address")))
+	go func() {
+		err := e.Start(viper.GetString("server.address"))
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Println(err)
+	}
+	workers.Wait()
+}
+
+// jwtKeys loads keys of access tokens, the HS256 secret has empty kid
+// so it keeps verifying tokens which were signed before keys were configured
+func jwtKeys() []*user.JWTKey {
+	keys := make([]*user.JWTKey, 0)
+	if secret := viper.GetString("jwt.secret"); secret != "" {
+		keys = append(keys, user.NewHMACKey("", secret))
+	}
+
+	var files []struct {
+		Kid  string
+		File string
+	}
+	fatalOnError(viper.UnmarshalKey("jwt.keys", &files))
+	for _, f := range files {
+		key, err := user.LoadJWTKey(f.Kid, f.File)
+		fatalOnError(err)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// passwordPolicy loads password rules, banned passwords are read from a local file
+func passwordPolicy() domain.PasswordPolicy {
+	policy := domain.PasswordPolicy{
+		MinLength:  viper.GetUint("password.min_length"),
+		MaxLength:  viper.GetUint("password.max_length"),
+		MinClasses: viper.GetUint("password.min_classes"),
+	}
+	// longer passwords are cut by bcrypt, so they're rejected instead
+	if viper.GetString("password.algorithm") == password.Bcrypt {
+		policy.MaxBytes = password.BcryptMaxBytes
+	}
+	if path := viper.GetString("password.banned_file"); path != "" {
+		banned, err := password.ReadList(path)
+		fatalOnError(err)
+		policy.Banned = banned
+	}
+	return policy
+}
+
+// rateLimitRules loads rate limits of route groups, first matching rule applies
+func rateLimitRules() []ratelimit.Rule {
+	var configs []struct {
+		Name     string
+		Prefixes []string
+		Requests uint
+		// seconds
+		Window int
+	}
+	fatalOnError(viper.UnmarshalKey("ratelimit.rules", &configs))
+
+	rules := make([]ratelimit.Rule, 0, len(configs))
+	for _, r := range configs {
+		rules = append(rules, ratelimit.Rule{
+			Name:     r.Name,
+			Prefixes: r.Prefixes,
+			Limit: ratelimit.Limit{
+				Requests: r.Requests,
+				Window:   time.Duration(r.Window) * time.Second,
+			},
+		})
+	}
+	return rules
 }
 
 func fatalOnError(err error) {
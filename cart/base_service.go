@@ -0,0 +1,165 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+type Service struct {
+	cr domain.CartRepository
+	pr domain.ProductRepository
+	ps domain.ProductService
+	// serializes read-modify-write of carts
+	cl sync.Mutex
+}
+
+func InitService(
+	cr domain.CartRepository,
+	pr domain.ProductRepository,
+	ps domain.ProductService,
+) domain.CartService {
+	return &Service{cr: cr, pr: pr, ps: ps}
+}
+
+func (s *Service) Get(ctx context.Context) (*domain.Cart, error) {
+	const op string = "cart.service.Get"
+
+	u, err := buyerFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.cr.Items(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return s.view(ctx, u.Id, items)
+}
+
+func (s *Service) AddItem(ctx context.Context, productId, count uint) (*domain.Cart, error) {
+	return s.change(ctx, productId, func(current uint) uint {
+		return current + count
+	})
+}
+
+func (s *Service) SetItem(ctx context.Context, productId, count uint) (*domain.Cart, error) {
+	return s.change(ctx, productId, func(uint) uint {
+		return count
+	})
+}
+
+func (s *Service) RemoveItem(ctx context.Context, productId uint) (*domain.Cart, error) {
+	return s.change(ctx, productId, func(uint) uint {
+		return 0
+	})
+}
+
+func (s *Service) Clear(ctx context.Context) error {
+	const op string = "cart.service.Clear"
+
+	u, err := buyerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = s.cr.Clear(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+// change applies new count of the product to the cart of buyer
+func (s *Service) change(ctx context.Context, productId uint, count func(current uint) uint) (*domain.Cart, error) {
+	const op string = "cart.service.change"
+
+	u, err := buyerFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cl.Lock()
+	defer s.cl.Unlock()
+
+	items, err := s.cr.Items(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	n := count(items[productId])
+	// products can be removed even if they are gone meanwhile
+	if n > 0 {
+		_, err := s.pr.FindById(ctx, productId)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, errors.Wrapf(domain.ErrProductNotFound, "product %d", productId)
+		}
+	}
+
+	err = s.cr.SetItem(ctx, u.Id, productId, n)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if n == 0 {
+		delete(items, productId)
+	} else {
+		items[productId] = n
+	}
+
+	return s.view(ctx, u.Id, items)
+}
+
+// view decorates cart items with live prices and stock warnings
+func (s *Service) view(ctx context.Context, userId uint, items map[uint]uint) (*domain.Cart, error) {
+	ps, err := s.ps.List(ctx, domain.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	products := make(map[uint]domain.Product, len(ps))
+	for _, p := range ps {
+		products[p.Id] = p
+	}
+
+	c := &domain.Cart{UserId: userId, Items: make([]domain.CartLine, 0, len(items))}
+	for _, pid := range sortedIds(items) {
+		line := domain.CartLine{ProductId: pid, Count: items[pid]}
+		p, ok := products[pid]
+		if !ok {
+			line.Warnings = append(line.Warnings, "product is no longer available")
+			c.Items = append(c.Items, line)
+			continue
+		}
+
+		line.Name = p.Name
+		line.Price = p.Price
+		line.Subtotal = line.Count * p.Price
+		line.Available = p.Count
+		switch {
+		case p.Count == 0:
+			line.Warnings = append(line.Warnings, "out of stock")
+		case p.Count < line.Count:
+			line.Warnings = append(line.Warnings, fmt.Sprintf("only %d available", p.Count))
+		}
+		if len(p.Conflicts) > 0 {
+			line.Warnings = append(line.Warnings,
+				"conflicts with dietary profile: "+strings.Join(p.Conflicts, ", "))
+		}
+
+		c.Total += line.Subtotal
+		c.Items = append(c.Items, line)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,48 @@
+package cart
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Checkout runs the purchase of the stored cart, items which were
+// dispensed leave the cart while skipped ones stay in it
+func (s *Service) Checkout(ctx context.Context, opts domain.BuyOptions) (*domain.Bill, error) {
+	const op string = "cart.service.Checkout"
+
+	u, err := buyerFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cl.Lock()
+	defer s.cl.Unlock()
+
+	items, err := s.cr.Items(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if len(items) == 0 {
+		return nil, errors.Wrap(domain.ErrInvalidParams, "cart is empty")
+	}
+
+	bill, err := s.ps.Buy(ctx, items, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// purchase is done, failing to update the cart must not hide the bill
+	for _, item := range bill.Items {
+		remaining := items[item.ProductId] - item.Dispensed
+		err = s.cr.SetItem(ctx, u.Id, item.ProductId, remaining)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		}
+	}
+
+	return bill, nil
+}
@@ -0,0 +1,167 @@
+package cart_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/apm-dev/vending-machine/cart"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Checkout(t *testing.T) {
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wants   wants
+	}
+
+	cr := new(mocks.CartRepository)
+	ps := new(mocks.ProductService)
+
+	buyerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 5, Role: domain.BUYER,
+	})
+
+	testCases := []testCase{
+		{
+			name: "should buy stored cart and keep only items which were not dispensed",
+			prepare: func() {
+				items := map[uint]uint{1: 2, 2: 3}
+				cr.On("Items", mock.Anything, uint(5)).Return(items, nil).Once()
+				ps.On("Buy", mock.Anything, items, domain.BuyOptions{Partial: true}).
+					Return(&domain.Bill{
+						Items: []domain.Item{
+							{ProductId: 1, Count: 2, Dispensed: 2},
+							{ProductId: 2, Count: 3, Dispensed: 1},
+						},
+					}, nil).Once()
+				cr.On("SetItem", mock.Anything, uint(5), uint(1), uint(0)).Return(nil).Once()
+				cr.On("SetItem", mock.Anything, uint(5), uint(2), uint(2)).Return(nil).Once()
+			},
+			ctx:   buyerContext,
+			wants: wants{err: nil},
+		},
+		{
+			name: "should keep cart when purchase fails",
+			prepare: func() {
+				items := map[uint]uint{1: 9}
+				cr.On("Items", mock.Anything, uint(5)).Return(items, nil).Once()
+				ps.On("Buy", mock.Anything, items, domain.BuyOptions{Partial: true}).
+					Return(nil, domain.ErrInsufficientBalance).Once()
+			},
+			ctx:   buyerContext,
+			wants: wants{err: domain.ErrInsufficientBalance},
+		},
+		{
+			name: "should fail when cart is empty",
+			prepare: func() {
+				cr.On("Items", mock.Anything, uint(5)).Return(map[uint]uint{}, nil).Once()
+			},
+			ctx:   buyerContext,
+			wants: wants{err: domain.ErrInvalidParams},
+		},
+		{
+			name:    "should fail when seller checks out",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 2, Role: domain.SELLER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := cart.InitService(cr, nil, ps)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		bill, err := svc.Checkout(tc.ctx, domain.BuyOptions{Partial: true})
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, bill, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+			assert.NotNil(t, bill, tc.name)
+		}
+	}
+	cr.AssertExpectations(t)
+	ps.AssertExpectations(t)
+}
+
+func Test_Service_Get(t *testing.T) {
+	cr := new(mocks.CartRepository)
+	ps := new(mocks.ProductService)
+
+	buyerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 5, Role: domain.BUYER,
+	})
+
+	cr.On("Items", mock.Anything, uint(5)).Return(map[uint]uint{1: 2, 2: 3, 3: 1}, nil).Once()
+	ps.On("List", mock.Anything, domain.ListOptions{}).Return([]domain.Product{
+		{Id: 1, Name: "Cake", Count: 5, Price: 10},
+		{Id: 2, Name: "Soda", Count: 1, Price: 5, Conflicts: []string{"contains milk"}},
+	}, nil).Once()
+
+	svc := cart.InitService(cr, nil, ps)
+
+	c, err := svc.Get(buyerContext)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 35, c.Total)
+	assert.Len(t, c.Items, 3)
+	assert.Empty(t, c.Items[0].Warnings)
+	assert.Equal(t, []string{"only 1 available", "conflicts with dietary profile: contains milk"}, c.Items[1].Warnings)
+	assert.Equal(t, []string{"product is no longer available"}, c.Items[2].Warnings)
+	cr.AssertExpectations(t)
+	ps.AssertExpectations(t)
+}
+
+func Test_Service_AddItem(t *testing.T) {
+	cr := new(mocks.CartRepository)
+	pr := new(mocks.ProductRepository)
+	ps := new(mocks.ProductService)
+
+	buyerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 5, Role: domain.BUYER,
+	})
+
+	svc := cart.InitService(cr, pr, ps)
+
+	t.Run("should add count to the item", func(t *testing.T) {
+		cr.On("Items", mock.Anything, uint(5)).Return(map[uint]uint{1: 2}, nil).Once()
+		pr.On("FindById", mock.Anything, uint(1)).Return(&domain.Product{Id: 1}, nil).Once()
+		cr.On("SetItem", mock.Anything, uint(5), uint(1), uint(5)).Return(nil).Once()
+		ps.On("List", mock.Anything, domain.ListOptions{}).Return([]domain.Product{
+			{Id: 1, Name: "Cake", Count: 5, Price: 10},
+		}, nil).Once()
+
+		c, err := svc.AddItem(buyerContext, 1, 3)
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, 50, c.Total)
+	})
+
+	t.Run("should fail when product doesn't exist", func(t *testing.T) {
+		cr.On("Items", mock.Anything, uint(5)).Return(map[uint]uint{}, nil).Once()
+		pr.On("FindById", mock.Anything, uint(9)).Return(nil, errors.New("record not found")).Once()
+
+		c, err := svc.AddItem(buyerContext, 9, 1)
+
+		assert.ErrorIs(t, err, domain.ErrProductNotFound)
+		assert.Nil(t, c)
+	})
+
+	cr.AssertExpectations(t)
+	pr.AssertExpectations(t)
+	ps.AssertExpectations(t)
+}
@@ -0,0 +1,12 @@
+package pgsql
+
+type CartItem struct {
+	ID        uint `gorm:"primarykey"`
+	UserID    uint `gorm:"uniqueIndex:idx_cart_user_product;column:user_id"`
+	ProductID uint `gorm:"uniqueIndex:idx_cart_user_product;column:product_id"`
+	Count     uint `gorm:"column:count"`
+}
+
+func (i *CartItem) TableName() string {
+	return "cart_items"
+}
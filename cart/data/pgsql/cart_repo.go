@@ -0,0 +1,74 @@
+package pgsql
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartRepository struct {
+	db *gorm.DB
+}
+
+func InitCartRepository(db *gorm.DB) domain.CartRepository {
+	return &CartRepository{
+		db: db,
+	}
+}
+
+func (r *CartRepository) Items(ctx context.Context, userId uint) (map[uint]uint, error) {
+	const op string = "cart.data.pgsql.cart_repo.Items"
+
+	var dbis []CartItem
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Find(&dbis).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	items := make(map[uint]uint, len(dbis))
+	for _, dbi := range dbis {
+		items[dbi.ProductID] = dbi.Count
+	}
+
+	return items, nil
+}
+
+func (r *CartRepository) SetItem(ctx context.Context, userId, productId, count uint) error {
+	const op string = "cart.data.pgsql.cart_repo.SetItem"
+
+	var err error
+	if count == 0 {
+		err = r.db.WithContext(ctx).
+			Where("user_id = ? AND product_id = ?", userId, productId).
+			Delete(&CartItem{}).Error
+	} else {
+		err = r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"count"}),
+		}).Create(&CartItem{UserID: userId, ProductID: productId, Count: count}).Error
+	}
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *CartRepository) Clear(ctx context.Context, userId uint) error {
+	const op string = "cart.data.pgsql.cart_repo.Clear"
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Delete(&CartItem{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
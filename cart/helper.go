@@ -0,0 +1,37 @@
+package cart
+
+import (
+	"context"
+	"sort"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// buyerFromContext returns the caller, only buyers have carts
+func buyerFromContext(ctx context.Context) (*domain.User, error) {
+	const op string = "cart.helper.buyerFromContext"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if u.Role != domain.BUYER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	return u, nil
+}
+
+func sortedIds(items map[uint]uint) []uint {
+	ids := make([]uint, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
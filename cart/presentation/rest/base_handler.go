@@ -0,0 +1,121 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/cart/presentation/rest/requests"
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+type CartHandler struct {
+	cs domain.CartService
+}
+
+// InitCartHandler
+// auth echo group which uses auth middleware
+func InitCartHandler(auth *echo.Group, cs domain.CartService) *CartHandler {
+	h := &CartHandler{cs: cs}
+
+	cg := auth.Group("/cart")
+	cg.GET("/", h.Get)
+	cg.DELETE("/", h.Clear)
+	cg.POST("/items", h.AddItem)
+	cg.PUT("/items/:product_id", h.SetItem)
+	cg.DELETE("/items/:product_id", h.RemoveItem)
+	cg.POST("/checkout", h.Checkout)
+
+	return h
+}
+
+func (h *CartHandler) Get(c echo.Context) error {
+	cart, err := h.cs.Get(c.Request().Context())
+	return checkErrorThenResponse(c, err, cart)
+}
+
+func (h *CartHandler) Clear(c echo.Context) error {
+	err := h.cs.Clear(c.Request().Context())
+	return checkErrorThenResponse(c, err, nil)
+}
+
+func (h *CartHandler) AddItem(c echo.Context) error {
+	req := new(requests.AddItem)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	cart, err := h.cs.AddItem(c.Request().Context(), req.ProductId, req.Count)
+
+	return checkErrorThenResponse(c, err, cart)
+}
+
+func (h *CartHandler) SetItem(c echo.Context) error {
+	req := new(requests.SetItem)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	pid, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	cart, err := h.cs.SetItem(c.Request().Context(), uint(pid), req.Count)
+
+	return checkErrorThenResponse(c, err, cart)
+}
+
+func (h *CartHandler) RemoveItem(c echo.Context) error {
+	pid, err := strconv.Atoi(c.Param("product_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	cart, err := h.cs.RemoveItem(c.Request().Context(), uint(pid))
+
+	return checkErrorThenResponse(c, err, cart)
+}
+
+func (h *CartHandler) Checkout(c echo.Context) error {
+	req := new(requests.Checkout)
+	// body is optional, defaults buy the whole cart
+	if c.Request().ContentLength > 0 {
+		err := httputil.BindAndValidate(c, req)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+				http.StatusBadRequest, err.Error(), nil,
+			))
+		}
+	}
+
+	bill, err := h.cs.Checkout(c.Request().Context(), domain.BuyOptions{
+		StrictDiet: req.StrictDiet,
+		Partial:    req.Partial,
+	})
+
+	return checkErrorThenResponse(c, err, bill)
+}
+
+func checkErrorThenResponse(c echo.Context, err error, content interface{}) error {
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", content,
+	))
+}
@@ -0,0 +1,18 @@
+package requests
+
+type AddItem struct {
+	ProductId uint `json:"product_id" validate:"required"`
+	Count     uint `json:"count" validate:"required,gt=0"`
+}
+
+type SetItem struct {
+	// zero removes the product from the cart
+	Count uint `json:"count"`
+}
+
+type Checkout struct {
+	// refuse products conflicting with dietary profile
+	StrictDiet bool `json:"strict_diet"`
+	// buy whatever can be fulfilled
+	Partial bool `json:"partial"`
+}
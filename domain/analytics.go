@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// DailySales is units of a product sold in a day, net of refunds
+type DailySales struct {
+	ProductId uint      `json:"product_id"`
+	Day       time.Time `json:"day"`
+	Units     uint      `json:"units"`
+}
+
+// Forecast is the expected stock-out of a product based on its sales velocity
+type Forecast struct {
+	ProductId uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Stock     uint   `json:"stock"`
+	// Velocity is average units sold per day in the window
+	Velocity float64 `json:"velocity"`
+	// DaysLeft and StockoutDate are nil when product doesn't sell
+	DaysLeft     *float64   `json:"days_left"`
+	StockoutDate *time.Time `json:"stockout_date"`
+	// Restock is units to add so stock lasts for the cover days
+	Restock uint `json:"restock"`
+}
+
+type ForecastOptions struct {
+	// SellerId is whose products to forecast, admins must set it
+	// and it's always the caller for sellers
+	SellerId uint
+	// WindowDays of sales history to estimate velocity from
+	WindowDays int
+	// CoverDays the suggested restock should last for
+	CoverDays int
+	// AsOf is the day of forecast, now when zero
+	AsOf time.Time
+}
+
+type AnalyticsService interface {
+	// Forecast returns products of the seller ranked by urgency of restock
+	Forecast(ctx context.Context, opts ForecastOptions) ([]Forecast, error)
+	// Sales reports sales of the seller, admins may leave seller id
+	// zero to report all sellers
+	Sales(ctx context.Context, q SalesQuery) (*SalesReport, error)
+	// TopSellers ranks products by revenue, or by units when byUnits is set
+	TopSellers(ctx context.Context, q SalesQuery, byUnits bool, limit int) ([]ProductSales, error)
+	// Overview returns operational figures of the machine (ADMIN only)
+	Overview(ctx context.Context) (*Overview, error)
+}
+
+// buckets of sales reports
+const (
+	BucketDay       = "day"
+	BucketWeek      = "week"
+	BucketHourOfDay = "hour"
+)
+
+// SalesQuery selects orders of a seller (or all sellers when zero)
+// in [From, To) grouped by the bucket
+type SalesQuery struct {
+	SellerId uint
+	From     time.Time
+	To       time.Time
+	Bucket   string
+}
+
+func (q SalesQuery) Validate() error {
+	switch q.Bucket {
+	case BucketDay, BucketWeek, BucketHourOfDay:
+	default:
+		return ErrInvalidParams
+	}
+	if !q.From.Before(q.To) {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// ProductSales is units and revenue of a product, net of refunds
+type ProductSales struct {
+	ProductId uint   `json:"product_id"`
+	SellerId  uint   `json:"seller_id"`
+	Name      string `json:"name"`
+	Units     uint   `json:"units"`
+	Revenue   uint   `json:"revenue"`
+}
+
+// SalesRow is sales of a product in a period, period is the first day
+// of it for day and week buckets and the hour "00".."23" for hour of day
+type SalesRow struct {
+	Period string `json:"period"`
+	ProductSales
+}
+
+type SalesReport struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Bucket  string    `json:"bucket"`
+	Units   uint      `json:"units"`
+	Revenue uint      `json:"revenue"`
+	// Products are totals of the range ranked by revenue
+	Products  []ProductSales `json:"products"`
+	Breakdown []SalesRow     `json:"breakdown"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "context"
+
+// Cart is the stored cart of a buyer with live prices and stock
+type Cart struct {
+	UserId uint       `json:"user_id"`
+	Items  []CartLine `json:"items"`
+	// Total is price of the lines at current prices
+	Total uint `json:"total"`
+}
+
+type CartLine struct {
+	ProductId uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Count     uint   `json:"count"`
+	Price     uint   `json:"price"`
+	Subtotal  uint   `json:"subtotal"`
+	// Available is stock the buyer can currently buy
+	Available uint `json:"available"`
+	// Warnings tell why the line may not be bought as it is
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type CartService interface {
+	Get(ctx context.Context) (*Cart, error)
+	// AddItem increases count of the product in the cart
+	AddItem(ctx context.Context, productId, count uint) (*Cart, error)
+	// SetItem changes count of the product, zero removes it
+	SetItem(ctx context.Context, productId, count uint) (*Cart, error)
+	RemoveItem(ctx context.Context, productId uint) (*Cart, error)
+	Clear(ctx context.Context) error
+	// Checkout buys the stored cart and removes bought items from it
+	Checkout(ctx context.Context, opts BuyOptions) (*Bill, error)
+}
+
+type CartRepository interface {
+	// Items returns map of product id => count of the cart of user
+	Items(ctx context.Context, userId uint) (map[uint]uint, error)
+	// SetItem stores count of the product, zero deletes it
+	SetItem(ctx context.Context, userId, productId, count uint) error
+	Clear(ctx context.Context, userId uint) error
+}
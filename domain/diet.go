@@ -0,0 +1,133 @@
+package domain
+
+import "fmt"
+
+const (
+	Gluten    Allergen = "gluten"
+	Crustacea Allergen = "crustacea"
+	Egg       Allergen = "egg"
+	Fish      Allergen = "fish"
+	Peanut    Allergen = "peanut"
+	Soy       Allergen = "soy"
+	Milk      Allergen = "milk"
+	TreeNut   Allergen = "tree_nut"
+	Celery    Allergen = "celery"
+	Mustard   Allergen = "mustard"
+	Sesame    Allergen = "sesame"
+	Sulphite  Allergen = "sulphite"
+	Lupin     Allergen = "lupin"
+	Mollusc   Allergen = "mollusc"
+
+	Vegan      DietLabel = "vegan"
+	Vegetarian DietLabel = "vegetarian"
+	Halal      DietLabel = "halal"
+	Kosher     DietLabel = "kosher"
+)
+
+type (
+	// Allergen is one of the 14 major allergens which must be labelled
+	Allergen string
+	// DietLabel is a claim about the product which dietary profiles can require
+	DietLabel string
+)
+
+func (a Allergen) IsValid() bool {
+	switch a {
+	case Gluten, Crustacea, Egg, Fish, Peanut, Soy, Milk,
+		TreeNut, Celery, Mustard, Sesame, Sulphite, Lupin, Mollusc:
+		return true
+	}
+	return false
+}
+
+func (l DietLabel) IsValid() bool {
+	switch l {
+	case Vegan, Vegetarian, Halal, Kosher:
+		return true
+	}
+	return false
+}
+
+// Nutrition holds nutrition facts of one serving
+type Nutrition struct {
+	ServingSize  uint    `json:"serving_size_g"`
+	Energy       uint    `json:"energy_kcal"`
+	Fat          float64 `json:"fat_g"`
+	SaturatedFat float64 `json:"saturated_fat_g"`
+	Carbohydrate float64 `json:"carbohydrate_g"`
+	Sugars       float64 `json:"sugars_g"`
+	Protein      float64 `json:"protein_g"`
+	Salt         float64 `json:"salt_g"`
+}
+
+// DietInfo is the allergen and nutrition labelling of a product
+// which is maintained by its seller
+type DietInfo struct {
+	Nutrition Nutrition   `json:"nutrition"`
+	Allergens []Allergen  `json:"allergens"`
+	Labels    []DietLabel `json:"labels"`
+}
+
+func (d DietInfo) Validate() error {
+	for _, a := range d.Allergens {
+		if !a.IsValid() {
+			return ErrInvalidParams
+		}
+	}
+	for _, l := range d.Labels {
+		if !l.IsValid() {
+			return ErrInvalidParams
+		}
+	}
+	return nil
+}
+
+func (d DietInfo) Contains(a Allergen) bool {
+	for _, pa := range d.Allergens {
+		if pa == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (d DietInfo) HasLabel(l DietLabel) bool {
+	for _, pl := range d.Labels {
+		if pl == l {
+			return true
+		}
+	}
+	return false
+}
+
+// DietaryProfile is saved by buyers, e.g. nut-free profile avoids
+// peanut and tree_nut, vegan profile requires vegan label
+type DietaryProfile struct {
+	Avoid   []Allergen  `json:"avoid"`
+	Require []DietLabel `json:"require"`
+}
+
+func (d DietaryProfile) Validate() error {
+	return DietInfo{Allergens: d.Avoid, Labels: d.Require}.Validate()
+}
+
+func (d DietaryProfile) IsEmpty() bool {
+	return len(d.Avoid) == 0 && len(d.Require) == 0
+}
+
+// Conflicts returns human readable reasons why the product
+// does not fit this profile, empty result means it fits
+func (d DietaryProfile) Conflicts(p *Product) []string {
+	conflicts := make([]string, 0)
+	for _, a := range d.Avoid {
+		if p.Contains(a) {
+			conflicts = append(conflicts, fmt.Sprintf("contains %s", a))
+		}
+	}
+	for _, l := range d.Require {
+		if !p.HasLabel(l) {
+			conflicts = append(conflicts, fmt.Sprintf("not %s", l))
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "context"
+
+// Dispenser is the vending hardware which drops products out of the machine
+type Dispenser interface {
+	// Dispense drops one item of the product,
+	// ErrDispenseFailed means nothing has dropped
+	Dispense(ctx context.Context, productId uint) error
+}
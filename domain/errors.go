@@ -13,9 +13,25 @@ var (
 	ErrUnauthorized      = errors.New("unauthorized user")
 	ErrWrongCredentials  = errors.New("wrong credentials")
 	ErrPermissionDenied  = errors.New("permission denied")
+	ErrRestoreConflict   = errors.New("restore conflicts with existing data")
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrUserDisabled      = errors.New("user is disabled")
+	ErrTooManyAttempts   = errors.New("too many failed login attempts")
+	ErrWeakPassword      = errors.New("password is too weak")
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required")
+	ErrTwoFactorNotFound = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorEnabled  = errors.New("two-factor authentication is already enabled")
 
 	ErrInvalidCoin                = errors.New("invalid coin, use 5, 10, 20, 50, 100 cent coins")
 	ErrProductNotFound            = errors.New("product not found")
+	ErrDuplicateSku               = errors.New("sku already exists")
+	ErrQuotaExceeded              = errors.New("purchase quota exceeded")
 	ErrInsufficientBalance        = errors.New("insufficient balance")
 	ErrInsufficientProductsAmount = errors.New("insufficient products amount")
+	ErrDietaryConflict            = errors.New("product conflicts with dietary profile")
+	ErrReservationNotFound        = errors.New("reservation not found")
+	ErrDispenseFailed             = errors.New("failed to dispense product")
+
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrNothingToRefund = errors.New("nothing to refund")
 )
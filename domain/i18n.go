@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Translation is product name and description in one language
+type Translation struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (t Translation) Validate() error {
+	if !ValidProductName(t.Name) || utf8.RuneCountInString(t.Description) > MaxDescriptionLen {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// language tags like "en", "fa" or "pt-br"
+var langTagRegex = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// NormalizeLang lower-cases language tag and reports whether it's valid
+func NormalizeLang(tag string) (string, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	return tag, langTagRegex.MatchString(tag)
+}
+
+// MatchLang picks the first preferred language which is available,
+// a region tag like "de-at" falls back to its base language "de"
+func MatchLang(preferred []string, available func(lang string) bool) (string, bool) {
+	for _, lang := range preferred {
+		if available(lang) {
+			return lang, true
+		}
+		if i := strings.Index(lang, "-"); i > 0 && available(lang[:i]) {
+			return lang[:i], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// LoginFailures counts failed logins of a key, keys are
+// usernames (even unknown ones) and client ips
+type LoginFailures struct {
+	Key         string
+	Count       uint
+	LastAt      time.Time
+	LockedUntil *time.Time
+}
+
+// Lockout is the record of a key which was locked after too many failed logins
+type Lockout struct {
+	Id          uint       `json:"id"`
+	Key         string     `json:"key"`
+	IP          string     `json:"ip"`
+	Failures    uint       `json:"failures"`
+	LockedAt    time.Time  `json:"locked_at"`
+	LockedUntil time.Time  `json:"locked_until"`
+	UnlockedBy  *uint      `json:"unlocked_by,omitempty"`
+	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// LoginPolicy throttles failed logins, every failure doubles the delay of the
+// next attempt and reaching the maximum failures locks the key
+type LoginPolicy struct {
+	// MaxFailures of a username before lockout
+	MaxFailures uint
+	// MaxIPFailures of a client ip before lockout, shared kiosks need more
+	MaxIPFailures uint
+	// Delay after the first failure
+	Delay time.Duration
+	// Lockout is how long a key stays locked
+	Lockout time.Duration
+	// Window after the last failure which failures are forgotten
+	Window time.Duration
+}
+
+// UserLoginKey is case-sensitive like usernames, so
+// failures of an account don't lock another one
+func UserLoginKey(uname string) string {
+	return "user:" + uname
+}
+
+func IPLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// MaxFailuresOf returns maximum failures of the key
+func (p LoginPolicy) MaxFailuresOf(key string) uint {
+	if strings.HasPrefix(key, "ip:") {
+		return p.MaxIPFailures
+	}
+	return p.MaxFailures
+}
+
+// RetryAt returns the earliest time of the next login attempt of the key
+func (p LoginPolicy) RetryAt(f *LoginFailures, now time.Time) time.Time {
+	if f == nil || f.Count == 0 {
+		return time.Time{}
+	}
+	if f.LockedUntil != nil && now.Before(*f.LockedUntil) {
+		return *f.LockedUntil
+	}
+	if now.Sub(f.LastAt) >= p.Window {
+		return time.Time{}
+	}
+
+	delay := p.Delay
+	for i := uint(1); i < f.Count && delay < p.Lockout; i++ {
+		delay *= 2
+	}
+	if delay > p.Lockout {
+		delay = p.Lockout
+	}
+	return f.LastAt.Add(delay)
+}
+
+type LoginAttemptRepository interface {
+	// Failures returns zero failures for unknown keys
+	Failures(ctx context.Context, key string) (*LoginFailures, error)
+	// AddFailure counts a failed login of the key at the time, counting restarts
+	// when the last failure is before since, returns the new count
+	AddFailure(ctx context.Context, key string, at, since time.Time) (uint, error)
+	// RemoveFailure takes back a failure counted by AddFailure
+	RemoveFailure(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets failures and lock of the key
+	Reset(ctx context.Context, key string) error
+	InsertLockout(ctx context.Context, l Lockout) error
+	ListLockouts(ctx context.Context) ([]Lockout, error)
+	// MarkUnlocked records the admin who unlocked active lockouts of the key
+	MarkUnlocked(ctx context.Context, key string, by uint, at time.Time) error
+	// DeleteStale deletes failures of keys which failed last
+	// and stay locked until before the time
+	DeleteStale(ctx context.Context, before time.Time) (uint, error)
+}
@@ -0,0 +1,65 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CartRepository is an autogenerated mock type for the CartRepository type
+type CartRepository struct {
+	mock.Mock
+}
+
+// Clear provides a mock function with given fields: ctx, userId
+func (_m *CartRepository) Clear(ctx context.Context, userId uint) error {
+	ret := _m.Called(ctx, userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Items provides a mock function with given fields: ctx, userId
+func (_m *CartRepository) Items(ctx context.Context, userId uint) (map[uint]uint, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 map[uint]uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) map[uint]uint); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetItem provides a mock function with given fields: ctx, userId, productId, count
+func (_m *CartRepository) SetItem(ctx context.Context, userId uint, productId uint, count uint) error {
+	ret := _m.Called(ctx, userId, productId, count)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint, uint) error); ok {
+		r0 = rf(ctx, userId, productId, count)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,28 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Dispenser is an autogenerated mock type for the Dispenser type
+type Dispenser struct {
+	mock.Mock
+}
+
+// Dispense provides a mock function with given fields: ctx, productId
+func (_m *Dispenser) Dispense(ctx context.Context, productId uint) error {
+	ret := _m.Called(ctx, productId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, productId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
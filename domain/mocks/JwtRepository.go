@@ -4,10 +4,10 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
+	domain "github.com/apm-dev/vending-machine/domain"
 	mock "github.com/stretchr/testify/mock"
-
-	time "time"
 )
 
 // JwtRepository is an autogenerated mock type for the JwtRepository type
@@ -15,6 +15,78 @@ type JwtRepository struct {
 	mock.Mock
 }
 
+// ActiveCount provides a mock function with given fields: ctx
+func (_m *JwtRepository) ActiveCount(ctx context.Context) (uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BeginTransaction provides a mock function with given fields: ctx
+func (_m *JwtRepository) BeginTransaction(ctx context.Context) (context.Context, domain.JwtRepository) {
+	ret := _m.Called(ctx)
+
+	var r0 context.Context
+	if rf, ok := ret.Get(0).(func(context.Context) context.Context); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+
+	var r1 domain.JwtRepository
+	if rf, ok := ret.Get(1).(func(context.Context) domain.JwtRepository); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(domain.JwtRepository)
+		}
+	}
+
+	return r0, r1
+}
+
+// Commit provides a mock function with given fields:
+func (_m *JwtRepository) Commit() {
+	_m.Called()
+}
+
+// DeleteExpired provides a mock function with given fields: ctx
+func (_m *JwtRepository) DeleteExpired(ctx context.Context) (uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteTokensOfUserExcept provides a mock function with given fields: ctx, userId, exceptionToken
 func (_m *JwtRepository) DeleteTokensOfUserExcept(ctx context.Context, userId uint, exceptionToken string) error {
 	ret := _m.Called(ctx, userId, exceptionToken)
@@ -50,13 +122,201 @@ func (_m *JwtRepository) Exists(ctx context.Context, token string) (bool, error)
 	return r0, r1
 }
 
-// Insert provides a mock function with given fields: ctx, userId, token, ttl
-func (_m *JwtRepository) Insert(ctx context.Context, userId uint, token string, ttl time.Duration) error {
-	ret := _m.Called(ctx, userId, token, ttl)
+// FindRefresh provides a mock function with given fields: ctx, token
+func (_m *JwtRepository) FindRefresh(ctx context.Context, token string) (*domain.RefreshToken, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *domain.RefreshToken
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.RefreshToken); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.RefreshToken)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindSession provides a mock function with given fields: ctx, id
+func (_m *JwtRepository) FindSession(ctx context.Context, id string) (*domain.Session, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Session); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindSessionByToken provides a mock function with given fields: ctx, token
+func (_m *JwtRepository) FindSessionByToken(ctx context.Context, token string) (*domain.Session, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Session); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, token, s, ttl
+func (_m *JwtRepository) Insert(ctx context.Context, token string, s domain.Session, ttl time.Duration) error {
+	ret := _m.Called(ctx, token, s, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Session, time.Duration) error); ok {
+		r0 = rf(ctx, token, s, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertRefresh provides a mock function with given fields: ctx, rt
+func (_m *JwtRepository) InsertRefresh(ctx context.Context, rt domain.RefreshToken) error {
+	ret := _m.Called(ctx, rt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.RefreshToken) error); ok {
+		r0 = rf(ctx, rt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListSessions provides a mock function with given fields: ctx, userId
+func (_m *JwtRepository) ListSessions(ctx context.Context, userId uint) ([]domain.Session, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []domain.Session
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Session); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Session)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkRefreshUsed provides a mock function with given fields: ctx, token
+func (_m *JwtRepository) MarkRefreshUsed(ctx context.Context, token string) (bool, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenameSession provides a mock function with given fields: ctx, id, name
+func (_m *JwtRepository) RenameSession(ctx context.Context, id string, name string) error {
+	ret := _m.Called(ctx, id, name)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeFamily provides a mock function with given fields: ctx, family
+func (_m *JwtRepository) RevokeFamily(ctx context.Context, family string) error {
+	ret := _m.Called(ctx, family)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, family)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Rollback provides a mock function with given fields:
+func (_m *JwtRepository) Rollback() {
+	_m.Called()
+}
+
+// Rotate provides a mock function with given fields: ctx, sessionId, token, ttl, client
+func (_m *JwtRepository) Rotate(ctx context.Context, sessionId string, token string, ttl time.Duration, client domain.ClientInfo) error {
+	ret := _m.Called(ctx, sessionId, token, ttl, client)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string, time.Duration) error); ok {
-		r0 = rf(ctx, userId, token, ttl)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Duration, domain.ClientInfo) error); ok {
+		r0 = rf(ctx, sessionId, token, ttl, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Touch provides a mock function with given fields: ctx, token
+func (_m *JwtRepository) Touch(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,174 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	domain "github.com/apm-dev/vending-machine/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// LoginAttemptRepository is an autogenerated mock type for the LoginAttemptRepository type
+type LoginAttemptRepository struct {
+	mock.Mock
+}
+
+// AddFailure provides a mock function with given fields: ctx, key, at, since
+func (_m *LoginAttemptRepository) AddFailure(ctx context.Context, key string, at time.Time, since time.Time) (uint, error) {
+	ret := _m.Called(ctx, key, at, since)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) uint); ok {
+		r0 = rf(ctx, key, at, since)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, key, at, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteStale provides a mock function with given fields: ctx, before
+func (_m *LoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (uint, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) uint); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Failures provides a mock function with given fields: ctx, key
+func (_m *LoginAttemptRepository) Failures(ctx context.Context, key string) (*domain.LoginFailures, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 *domain.LoginFailures
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.LoginFailures); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginFailures)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertLockout provides a mock function with given fields: ctx, l
+func (_m *LoginAttemptRepository) InsertLockout(ctx context.Context, l domain.Lockout) error {
+	ret := _m.Called(ctx, l)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Lockout) error); ok {
+		r0 = rf(ctx, l)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListLockouts provides a mock function with given fields: ctx
+func (_m *LoginAttemptRepository) ListLockouts(ctx context.Context) ([]domain.Lockout, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Lockout
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Lockout); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Lockout)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Lock provides a mock function with given fields: ctx, key, until
+func (_m *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	ret := _m.Called(ctx, key, until)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, key, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkUnlocked provides a mock function with given fields: ctx, key, by, at
+func (_m *LoginAttemptRepository) MarkUnlocked(ctx context.Context, key string, by uint, at time.Time) error {
+	ret := _m.Called(ctx, key, by, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint, time.Time) error); ok {
+		r0 = rf(ctx, key, by, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveFailure provides a mock function with given fields: ctx, key
+func (_m *LoginAttemptRepository) RemoveFailure(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reset provides a mock function with given fields: ctx, key
+func (_m *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,229 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	domain "github.com/apm-dev/vending-machine/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrderRepository is an autogenerated mock type for the OrderRepository type
+type OrderRepository struct {
+	mock.Mock
+}
+
+// BeginTransaction provides a mock function with given fields: ctx
+func (_m *OrderRepository) BeginTransaction(ctx context.Context) (context.Context, domain.OrderRepository) {
+	ret := _m.Called(ctx)
+
+	var r0 context.Context
+	if rf, ok := ret.Get(0).(func(context.Context) context.Context); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+
+	var r1 domain.OrderRepository
+	if rf, ok := ret.Get(1).(func(context.Context) domain.OrderRepository); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(domain.OrderRepository)
+		}
+	}
+
+	return r0, r1
+}
+
+// Commit provides a mock function with given fields:
+func (_m *OrderRepository) Commit() {
+	_m.Called()
+}
+
+// DailySales provides a mock function with given fields: ctx, sellerId, from, to
+func (_m *OrderRepository) DailySales(ctx context.Context, sellerId uint, from time.Time, to time.Time) ([]domain.DailySales, error) {
+	ret := _m.Called(ctx, sellerId, from, to)
+
+	var r0 []domain.DailySales
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time, time.Time) []domain.DailySales); ok {
+		r0 = rf(ctx, sellerId, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.DailySales)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, sellerId, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindById provides a mock function with given fields: ctx, id
+func (_m *OrderRepository) FindById(ctx context.Context, id uint) (*domain.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Order
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, o
+func (_m *OrderRepository) Insert(ctx context.Context, o domain.Order) (uint, error) {
+	ret := _m.Called(ctx, o)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Order) uint); ok {
+		r0 = rf(ctx, o)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Order) error); ok {
+		r1 = rf(ctx, o)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertRefund provides a mock function with given fields: ctx, r
+func (_m *OrderRepository) InsertRefund(ctx context.Context, r domain.Refund) (uint, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Refund) uint); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Refund) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, filter
+func (_m *OrderRepository) List(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []domain.Order
+	if rf, ok := ret.Get(0).(func(context.Context, domain.OrderFilter) []domain.Order); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.OrderFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rollback provides a mock function with given fields:
+func (_m *OrderRepository) Rollback() {
+	_m.Called()
+}
+
+// Sales provides a mock function with given fields: ctx, q
+func (_m *OrderRepository) Sales(ctx context.Context, q domain.SalesQuery) ([]domain.SalesRow, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 []domain.SalesRow
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SalesQuery) []domain.SalesRow); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SalesRow)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SalesQuery) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SalesTotals provides a mock function with given fields: ctx, from, to
+func (_m *OrderRepository) SalesTotals(ctx context.Context, from time.Time, to time.Time) (*domain.SalesTotals, error) {
+	ret := _m.Called(ctx, from, to)
+
+	var r0 *domain.SalesTotals
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) *domain.SalesTotals); ok {
+		r0 = rf(ctx, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.SalesTotals)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnitsBought provides a mock function with given fields: ctx, buyerId, productIds, since
+func (_m *OrderRepository) UnitsBought(ctx context.Context, buyerId uint, productIds []uint, since time.Time) (uint, error) {
+	ret := _m.Called(ctx, buyerId, productIds, since)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []uint, time.Time) uint); ok {
+		r0 = rf(ctx, buyerId, productIds, since)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, []uint, time.Time) error); ok {
+		r1 = rf(ctx, buyerId, productIds, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -14,6 +14,20 @@ type ProductRepository struct {
 	mock.Mock
 }
 
+// AddStock provides a mock function with given fields: ctx, id, count
+func (_m *ProductRepository) AddStock(ctx context.Context, id uint, count uint) error {
+	ret := _m.Called(ctx, id, count)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, id, count)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BeginTransaction provides a mock function with given fields: ctx
 func (_m *ProductRepository) BeginTransaction(ctx context.Context) (context.Context, domain.ProductRepository) {
 	ret := _m.Called(ctx)
@@ -81,6 +95,75 @@ func (_m *ProductRepository) FindById(ctx context.Context, id uint) (*domain.Pro
 	return r0, r1
 }
 
+// FindBySellerAndSku provides a mock function with given fields: ctx, sellerId, sku
+func (_m *ProductRepository) FindBySellerAndSku(ctx context.Context, sellerId uint, sku string) (*domain.Product, error) {
+	ret := _m.Called(ctx, sellerId, sku)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) *domain.Product); ok {
+		r0 = rf(ctx, sellerId, sku)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(ctx, sellerId, sku)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindDeletedById provides a mock function with given fields: ctx, id
+func (_m *ProductRepository) FindDeletedById(ctx context.Context, id uint) (*domain.Product, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.Product); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IdsByCategory provides a mock function with given fields: ctx, category
+func (_m *ProductRepository) IdsByCategory(ctx context.Context, category string) ([]uint, error) {
+	ret := _m.Called(ctx, category)
+
+	var r0 []uint
+	if rf, ok := ret.Get(0).(func(context.Context, string) []uint); ok {
+		r0 = rf(ctx, category)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, category)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, p
 func (_m *ProductRepository) Insert(ctx context.Context, p domain.Product) (uint, error) {
 	ret := _m.Called(ctx, p)
@@ -102,6 +185,20 @@ func (_m *ProductRepository) Insert(ctx context.Context, p domain.Product) (uint
 	return r0, r1
 }
 
+// InsertPriceChange provides a mock function with given fields: ctx, c
+func (_m *ProductRepository) InsertPriceChange(ctx context.Context, c domain.PriceChange) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PriceChange) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // List provides a mock function with given fields: ctx
 func (_m *ProductRepository) List(ctx context.Context) ([]domain.Product, error) {
 	ret := _m.Called(ctx)
@@ -125,11 +222,182 @@ func (_m *ProductRepository) List(ctx context.Context) ([]domain.Product, error)
 	return r0, r1
 }
 
+// ListBySeller provides a mock function with given fields: ctx, sellerId
+func (_m *ProductRepository) ListBySeller(ctx context.Context, sellerId uint) ([]domain.Product, error) {
+	ret := _m.Called(ctx, sellerId)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.Product); ok {
+		r0 = rf(ctx, sellerId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, sellerId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeleted provides a mock function with given fields: ctx
+func (_m *ProductRepository) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Product); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPriceChanges provides a mock function with given fields: ctx, productId
+func (_m *ProductRepository) ListPriceChanges(ctx context.Context, productId uint) ([]domain.PriceChange, error) {
+	ret := _m.Called(ctx, productId)
+
+	var r0 []domain.PriceChange
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.PriceChange); ok {
+		r0 = rf(ctx, productId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PriceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, productId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTranslations provides a mock function with given fields: ctx, ids
+func (_m *ProductRepository) ListTranslations(ctx context.Context, ids []uint) (map[uint]map[string]domain.Translation, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 map[uint]map[string]domain.Translation
+	if rf, ok := ret.Get(0).(func(context.Context, []uint) map[uint]map[string]domain.Translation); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]map[string]domain.Translation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (_m *ProductRepository) Purge(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *ProductRepository) Restore(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Rollback provides a mock function with given fields:
 func (_m *ProductRepository) Rollback() {
 	_m.Called()
 }
 
+// SaveTranslations provides a mock function with given fields: ctx, id, ts
+func (_m *ProductRepository) SaveTranslations(ctx context.Context, id uint, ts map[string]domain.Translation) error {
+	ret := _m.Called(ctx, id, ts)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, map[string]domain.Translation) error); ok {
+		r0 = rf(ctx, id, ts)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StockStats provides a mock function with given fields: ctx
+func (_m *ProductRepository) StockStats(ctx context.Context) (*domain.StockStats, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *domain.StockStats
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.StockStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.StockStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TakeStock provides a mock function with given fields: ctx, id, count
+func (_m *ProductRepository) TakeStock(ctx context.Context, id uint, count uint) error {
+	ret := _m.Called(ctx, id, count)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, id, count)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, p
 func (_m *ProductRepository) Update(ctx context.Context, p *domain.Product) error {
 	ret := _m.Called(ctx, p)
@@ -143,3 +411,17 @@ func (_m *ProductRepository) Update(ctx context.Context, p *domain.Product) erro
 
 	return r0
 }
+
+// UpdateDietInfo provides a mock function with given fields: ctx, id, info
+func (_m *ProductRepository) UpdateDietInfo(ctx context.Context, id uint, info domain.DietInfo) error {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.DietInfo) error); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	domain "github.com/apm-dev/vending-machine/domain"
 	mock "github.com/stretchr/testify/mock"
@@ -14,13 +15,13 @@ type ProductService struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: ctx, name, amount, cost
-func (_m *ProductService) Add(ctx context.Context, name string, amount uint, cost uint) (*domain.Product, error) {
-	ret := _m.Called(ctx, name, amount, cost)
+// Add provides a mock function with given fields: ctx, in
+func (_m *ProductService) Add(ctx context.Context, in domain.ProductInput) (*domain.Product, error) {
+	ret := _m.Called(ctx, in)
 
 	var r0 *domain.Product
-	if rf, ok := ret.Get(0).(func(context.Context, string, uint, uint) *domain.Product); ok {
-		r0 = rf(ctx, name, amount, cost)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ProductInput) *domain.Product); ok {
+		r0 = rf(ctx, in)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Product)
@@ -28,8 +29,8 @@ func (_m *ProductService) Add(ctx context.Context, name string, amount uint, cos
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, uint, uint) error); ok {
-		r1 = rf(ctx, name, amount, cost)
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ProductInput) error); ok {
+		r1 = rf(ctx, in)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -37,13 +38,36 @@ func (_m *ProductService) Add(ctx context.Context, name string, amount uint, cos
 	return r0, r1
 }
 
-// Buy provides a mock function with given fields: ctx, cart
-func (_m *ProductService) Buy(ctx context.Context, cart map[uint]uint) (*domain.Bill, error) {
-	ret := _m.Called(ctx, cart)
+// AddQuota provides a mock function with given fields: ctx, r
+func (_m *ProductService) AddQuota(ctx context.Context, r domain.QuotaRule) (*domain.QuotaRule, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 *domain.QuotaRule
+	if rf, ok := ret.Get(0).(func(context.Context, domain.QuotaRule) *domain.QuotaRule); ok {
+		r0 = rf(ctx, r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.QuotaRule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.QuotaRule) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Buy provides a mock function with given fields: ctx, cart, opts
+func (_m *ProductService) Buy(ctx context.Context, cart map[uint]uint, opts domain.BuyOptions) (*domain.Bill, error) {
+	ret := _m.Called(ctx, cart, opts)
 
 	var r0 *domain.Bill
-	if rf, ok := ret.Get(0).(func(context.Context, map[uint]uint) *domain.Bill); ok {
-		r0 = rf(ctx, cart)
+	if rf, ok := ret.Get(0).(func(context.Context, map[uint]uint, domain.BuyOptions) *domain.Bill); ok {
+		r0 = rf(ctx, cart, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Bill)
@@ -51,8 +75,8 @@ func (_m *ProductService) Buy(ctx context.Context, cart map[uint]uint) (*domain.
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, map[uint]uint) error); ok {
-		r1 = rf(ctx, cart)
+	if rf, ok := ret.Get(1).(func(context.Context, map[uint]uint, domain.BuyOptions) error); ok {
+		r1 = rf(ctx, cart, opts)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -74,8 +98,22 @@ func (_m *ProductService) Delete(ctx context.Context, id uint) error {
 	return r0
 }
 
-// List provides a mock function with given fields: ctx
-func (_m *ProductService) List(ctx context.Context) ([]domain.Product, error) {
+// DeleteQuota provides a mock function with given fields: ctx, id
+func (_m *ProductService) DeleteQuota(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Export provides a mock function with given fields: ctx
+func (_m *ProductService) Export(ctx context.Context) ([]domain.Product, error) {
 	ret := _m.Called(ctx)
 
 	var r0 []domain.Product
@@ -97,13 +135,315 @@ func (_m *ProductService) List(ctx context.Context) ([]domain.Product, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: ctx, id, name, amount, cost
-func (_m *ProductService) Update(ctx context.Context, id uint, name string, amount uint, cost uint) (*domain.Product, error) {
-	ret := _m.Called(ctx, id, name, amount, cost)
+// GetReservation provides a mock function with given fields: ctx
+func (_m *ProductService) GetReservation(ctx context.Context) (*domain.Reservation, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *domain.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context) *domain.Reservation); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, rows, dryRun
+func (_m *ProductService) Import(ctx context.Context, rows []domain.ImportRow, dryRun bool) (*domain.ImportReport, error) {
+	ret := _m.Called(ctx, rows, dryRun)
+
+	var r0 *domain.ImportReport
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.ImportRow, bool) *domain.ImportReport); ok {
+		r0 = rf(ctx, rows, dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.ImportReport)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []domain.ImportRow, bool) error); ok {
+		r1 = rf(ctx, rows, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, opts
+func (_m *ProductService) List(ctx context.Context, opts domain.ListOptions) ([]domain.Product, error) {
+	ret := _m.Called(ctx, opts)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListOptions) []domain.Product); ok {
+		r0 = rf(ctx, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ListOptions) error); ok {
+		r1 = rf(ctx, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeleted provides a mock function with given fields: ctx
+func (_m *ProductService) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Product); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListQuotas provides a mock function with given fields: ctx
+func (_m *ProductService) ListQuotas(ctx context.Context) ([]domain.QuotaRule, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.QuotaRule
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.QuotaRule); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.QuotaRule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PriceAt provides a mock function with given fields: ctx, id, at
+func (_m *ProductService) PriceAt(ctx context.Context, id uint, at time.Time) (uint, error) {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint, time.Time) uint); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, time.Time) error); ok {
+		r1 = rf(ctx, id, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PriceHistory provides a mock function with given fields: ctx, id
+func (_m *ProductService) PriceHistory(ctx context.Context, id uint) ([]domain.PriceChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []domain.PriceChange
+	if rf, ok := ret.Get(0).(func(context.Context, uint) []domain.PriceChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PriceChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (_m *ProductService) Purge(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QuotaStatus provides a mock function with given fields: ctx
+func (_m *ProductService) QuotaStatus(ctx context.Context) ([]domain.QuotaStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.QuotaStatus
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.QuotaStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.QuotaStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseReservation provides a mock function with given fields: ctx
+func (_m *ProductService) ReleaseReservation(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Reserve provides a mock function with given fields: ctx, cart, ttl
+func (_m *ProductService) Reserve(ctx context.Context, cart map[uint]uint, ttl time.Duration) (*domain.Reservation, error) {
+	ret := _m.Called(ctx, cart, ttl)
+
+	var r0 *domain.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, map[uint]uint, time.Duration) *domain.Reservation); ok {
+		r0 = rf(ctx, cart, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, map[uint]uint, time.Duration) error); ok {
+		r1 = rf(ctx, cart, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *ProductService) Restore(ctx context.Context, id uint) (*domain.Product, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.Product); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, id, in
+func (_m *ProductService) Update(ctx context.Context, id uint, in domain.ProductInput) (*domain.Product, error) {
+	ret := _m.Called(ctx, id, in)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.ProductInput) *domain.Product); ok {
+		r0 = rf(ctx, id, in)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, domain.ProductInput) error); ok {
+		r1 = rf(ctx, id, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateDietInfo provides a mock function with given fields: ctx, id, info
+func (_m *ProductService) UpdateDietInfo(ctx context.Context, id uint, info domain.DietInfo) (*domain.Product, error) {
+	ret := _m.Called(ctx, id, info)
+
+	var r0 *domain.Product
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.DietInfo) *domain.Product); ok {
+		r0 = rf(ctx, id, info)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Product)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, domain.DietInfo) error); ok {
+		r1 = rf(ctx, id, info)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateTranslations provides a mock function with given fields: ctx, id, ts
+func (_m *ProductService) UpdateTranslations(ctx context.Context, id uint, ts map[string]domain.Translation) (*domain.Product, error) {
+	ret := _m.Called(ctx, id, ts)
 
 	var r0 *domain.Product
-	if rf, ok := ret.Get(0).(func(context.Context, uint, string, uint, uint) *domain.Product); ok {
-		r0 = rf(ctx, id, name, amount, cost)
+	if rf, ok := ret.Get(0).(func(context.Context, uint, map[string]domain.Translation) *domain.Product); ok {
+		r0 = rf(ctx, id, ts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Product)
@@ -111,8 +451,8 @@ func (_m *ProductService) Update(ctx context.Context, id uint, name string, amou
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, uint, string, uint, uint) error); ok {
-		r1 = rf(ctx, id, name, amount, cost)
+	if rf, ok := ret.Get(1).(func(context.Context, uint, map[string]domain.Translation) error); ok {
+		r1 = rf(ctx, id, ts)
 	} else {
 		r1 = ret.Error(1)
 	}
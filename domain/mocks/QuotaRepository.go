@@ -0,0 +1,73 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/apm-dev/vending-machine/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// QuotaRepository is an autogenerated mock type for the QuotaRepository type
+type QuotaRepository struct {
+	mock.Mock
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *QuotaRepository) Delete(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Insert provides a mock function with given fields: ctx, r
+func (_m *QuotaRepository) Insert(ctx context.Context, r domain.QuotaRule) (uint, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.QuotaRule) uint); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.QuotaRule) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx
+func (_m *QuotaRepository) List(ctx context.Context) ([]domain.QuotaRule, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.QuotaRule
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.QuotaRule); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.QuotaRule)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
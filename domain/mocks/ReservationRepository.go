@@ -0,0 +1,159 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/apm-dev/vending-machine/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ReservationRepository is an autogenerated mock type for the ReservationRepository type
+type ReservationRepository struct {
+	mock.Mock
+}
+
+// BeginTransaction provides a mock function with given fields: ctx
+func (_m *ReservationRepository) BeginTransaction(ctx context.Context) (context.Context, domain.ReservationRepository) {
+	ret := _m.Called(ctx)
+
+	var r0 context.Context
+	if rf, ok := ret.Get(0).(func(context.Context) context.Context); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(context.Context)
+		}
+	}
+
+	var r1 domain.ReservationRepository
+	if rf, ok := ret.Get(1).(func(context.Context) domain.ReservationRepository); ok {
+		r1 = rf(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(domain.ReservationRepository)
+		}
+	}
+
+	return r0, r1
+}
+
+// Commit provides a mock function with given fields:
+func (_m *ReservationRepository) Commit() {
+	_m.Called()
+}
+
+// DeleteByUser provides a mock function with given fields: ctx, userId
+func (_m *ReservationRepository) DeleteByUser(ctx context.Context, userId uint) error {
+	ret := _m.Called(ctx, userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx
+func (_m *ReservationRepository) DeleteExpired(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindActiveByUser provides a mock function with given fields: ctx, userId
+func (_m *ReservationRepository) FindActiveByUser(ctx context.Context, userId uint) (*domain.Reservation, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 *domain.Reservation
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.Reservation); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Reservation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HeldCounts provides a mock function with given fields: ctx, exceptUserId
+func (_m *ReservationRepository) HeldCounts(ctx context.Context, exceptUserId uint) (map[uint]uint, error) {
+	ret := _m.Called(ctx, exceptUserId)
+
+	var r0 map[uint]uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) map[uint]uint); ok {
+		r0 = rf(ctx, exceptUserId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[uint]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, exceptUserId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, r
+func (_m *ReservationRepository) Insert(ctx context.Context, r domain.Reservation) (uint, error) {
+	ret := _m.Called(ctx, r)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Reservation) uint); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Reservation) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rollback provides a mock function with given fields:
+func (_m *ReservationRepository) Rollback() {
+	_m.Called()
+}
+
+// Update provides a mock function with given fields: ctx, r
+func (_m *ReservationRepository) Update(ctx context.Context, r *domain.Reservation) error {
+	ret := _m.Called(ctx, r)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Reservation) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -0,0 +1,252 @@
+// Code generated by mockery v2.9.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	domain "github.com/apm-dev/vending-machine/domain"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// TwoFactorRepository is an autogenerated mock type for the TwoFactorRepository type
+type TwoFactorRepository struct {
+	mock.Mock
+}
+
+// AddChallengeAttempt provides a mock function with given fields: ctx, token
+func (_m *TwoFactorRepository) AddChallengeAttempt(ctx context.Context, token string) (uint, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, string) uint); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, userId
+func (_m *TwoFactorRepository) Delete(ctx context.Context, userId uint) error {
+	ret := _m.Called(ctx, userId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteChallenge provides a mock function with given fields: ctx, token
+func (_m *TwoFactorRepository) DeleteChallenge(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpiredChallenges provides a mock function with given fields: ctx
+func (_m *TwoFactorRepository) DeleteExpiredChallenges(ctx context.Context) (uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context) uint); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Find provides a mock function with given fields: ctx, userId
+func (_m *TwoFactorRepository) Find(ctx context.Context, userId uint) (*domain.TwoFactor, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 *domain.TwoFactor
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.TwoFactor); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.TwoFactor)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindChallenge provides a mock function with given fields: ctx, token
+func (_m *TwoFactorRepository) FindChallenge(ctx context.Context, token string) (*domain.LoginChallenge, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 *domain.LoginChallenge
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.LoginChallenge); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.LoginChallenge)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InsertChallenge provides a mock function with given fields: ctx, c
+func (_m *TwoFactorRepository) InsertChallenge(ctx context.Context, c domain.LoginChallenge) error {
+	ret := _m.Called(ctx, c)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginChallenge) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplaceBackupCodes provides a mock function with given fields: ctx, userId, codes
+func (_m *TwoFactorRepository) ReplaceBackupCodes(ctx context.Context, userId uint, codes []string) error {
+	ret := _m.Called(ctx, userId, codes)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, []string) error); ok {
+		r0 = rf(ctx, userId, codes)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RequiredRoles provides a mock function with given fields: ctx
+func (_m *TwoFactorRepository) RequiredRoles(ctx context.Context) ([]domain.Role, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.Role
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Role); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Role)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: ctx, tf
+func (_m *TwoFactorRepository) Save(ctx context.Context, tf domain.TwoFactor) error {
+	ret := _m.Called(ctx, tf)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.TwoFactor) error); ok {
+		r0 = rf(ctx, tf)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetRequiredRoles provides a mock function with given fields: ctx, roles
+func (_m *TwoFactorRepository) SetRequiredRoles(ctx context.Context, roles []domain.Role) error {
+	ret := _m.Called(ctx, roles)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []domain.Role) error); ok {
+		r0 = rf(ctx, roles)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseBackupCode provides a mock function with given fields: ctx, userId, code
+func (_m *TwoFactorRepository) UseBackupCode(ctx context.Context, userId uint, code string) (bool, error) {
+	ret := _m.Called(ctx, userId, code)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) bool); ok {
+		r0 = rf(ctx, userId, code)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, string) error); ok {
+		r1 = rf(ctx, userId, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseStep provides a mock function with given fields: ctx, userId, step
+func (_m *TwoFactorRepository) UseStep(ctx context.Context, userId uint, step uint64) (bool, error) {
+	ret := _m.Called(ctx, userId, step)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint64) bool); ok {
+		r0 = rf(ctx, userId, step)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint, uint64) error); ok {
+		r1 = rf(ctx, userId, step)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
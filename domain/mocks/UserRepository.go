@@ -4,6 +4,7 @@ package mocks
 
 import (
 	context "context"
+	time "time"
 
 	domain "github.com/apm-dev/vending-machine/domain"
 	mock "github.com/stretchr/testify/mock"
@@ -14,6 +15,20 @@ type UserRepository struct {
 	mock.Mock
 }
 
+// AddDeposit provides a mock function with given fields: ctx, id, amount
+func (_m *UserRepository) AddDeposit(ctx context.Context, id uint, amount uint) error {
+	ret := _m.Called(ctx, id, amount)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, id, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BeginTransaction provides a mock function with given fields: ctx
 func (_m *UserRepository) BeginTransaction(ctx context.Context) (context.Context, domain.UserRepository) {
 	ret := _m.Called(ctx)
@@ -44,18 +59,48 @@ func (_m *UserRepository) Commit() {
 	_m.Called()
 }
 
+// CountByRole provides a mock function with given fields: ctx
+func (_m *UserRepository) CountByRole(ctx context.Context) (map[domain.Role]uint, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[domain.Role]uint
+	if rf, ok := ret.Get(0).(func(context.Context) map[domain.Role]uint); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[domain.Role]uint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, id
-func (_m *UserRepository) Delete(ctx context.Context, id uint) error {
+func (_m *UserRepository) Delete(ctx context.Context, id uint) (uint, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
 		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(uint)
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // FindById provides a mock function with given fields: ctx, id
@@ -104,6 +149,29 @@ func (_m *UserRepository) FindByUsername(ctx context.Context, un string) (*domai
 	return r0, r1
 }
 
+// FindDeletedById provides a mock function with given fields: ctx, id
+func (_m *UserRepository) FindDeletedById(ctx context.Context, id uint) (*domain.User, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.User
+	if rf, ok := ret.Get(0).(func(context.Context, uint) *domain.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Insert provides a mock function with given fields: ctx, u
 func (_m *UserRepository) Insert(ctx context.Context, u domain.User) (uint, error) {
 	ret := _m.Called(ctx, u)
@@ -148,11 +216,132 @@ func (_m *UserRepository) List(ctx context.Context) ([]domain.User, error) {
 	return r0, r1
 }
 
+// ListDeleted provides a mock function with given fields: ctx
+func (_m *UserRepository) ListDeleted(ctx context.Context) ([]domain.User, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []domain.User
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.User); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Purge(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetDeposit provides a mock function with given fields: ctx, id
+func (_m *UserRepository) ResetDeposit(ctx context.Context, id uint) (uint, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, uint) uint); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *UserRepository) Restore(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Rollback provides a mock function with given fields:
 func (_m *UserRepository) Rollback() {
 	_m.Called()
 }
 
+// SetDisabledAt provides a mock function with given fields: ctx, id, at
+func (_m *UserRepository) SetDisabledAt(ctx context.Context, id uint, at *time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, *time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SpendDeposit provides a mock function with given fields: ctx, id, amount
+func (_m *UserRepository) SpendDeposit(ctx context.Context, id uint, amount uint) error {
+	ret := _m.Called(ctx, id, amount)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, uint) error); ok {
+		r0 = rf(ctx, id, amount)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SumDeposits provides a mock function with given fields: ctx, role
+func (_m *UserRepository) SumDeposits(ctx context.Context, role domain.Role) (uint, error) {
+	ret := _m.Called(ctx, role)
+
+	var r0 uint
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Role) uint); ok {
+		r0 = rf(ctx, role)
+	} else {
+		r0 = ret.Get(0).(uint)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Role) error); ok {
+		r1 = rf(ctx, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, u
 func (_m *UserRepository) Update(ctx context.Context, u *domain.User) error {
 	ret := _m.Called(ctx, u)
@@ -166,3 +355,45 @@ func (_m *UserRepository) Update(ctx context.Context, u *domain.User) error {
 
 	return r0
 }
+
+// UpdateDiet provides a mock function with given fields: ctx, id, diet
+func (_m *UserRepository) UpdateDiet(ctx context.Context, id uint, diet domain.DietaryProfile) error {
+	ret := _m.Called(ctx, id, diet)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.DietaryProfile) error); ok {
+		r0 = rf(ctx, id, diet)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, id, hash
+func (_m *UserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	ret := _m.Called(ctx, id, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, string) error); ok {
+		r0 = rf(ctx, id, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: ctx, id, role
+func (_m *UserRepository) UpdateRole(ctx context.Context, id uint, role domain.Role) error {
+	ret := _m.Called(ctx, id, role)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint, domain.Role) error); ok {
+		r0 = rf(ctx, id, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
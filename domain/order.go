@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Order is a persisted purchase made by product.Service.Buy
+type Order struct {
+	Id        uint        `json:"id"`
+	BuyerId   uint        `json:"buyer_id"`
+	Total     uint        `json:"total"`
+	Items     []OrderItem `json:"items"`
+	Refunds   []Refund    `json:"refunds"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type OrderItem struct {
+	Id        uint   `json:"id"`
+	ProductId uint   `json:"product_id"`
+	SellerId  uint   `json:"seller_id"`
+	Name      string `json:"name"`
+	Count     uint   `json:"count"`
+	// Price is the unit price at the time of sale
+	Price    uint `json:"price"`
+	Refunded uint `json:"refunded"`
+}
+
+// Refundable returns count of items which are not refunded yet
+func (i OrderItem) Refundable() uint {
+	return i.Count - i.Refunded
+}
+
+// Refund is the audit record of giving money of (a part of) an order back,
+// seller earnings are order items net of refunded ones
+type Refund struct {
+	Id      uint   `json:"id"`
+	OrderId uint   `json:"order_id"`
+	ActorId uint   `json:"actor_id"`
+	Reason  string `json:"reason"`
+	Amount  uint   `json:"amount"`
+	// Restock says were refunded items put back in stock or not
+	Restock   bool         `json:"restock"`
+	Items     []RefundItem `json:"items"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RefundItem struct {
+	OrderItemId uint `json:"order_item_id"`
+	ProductId   uint `json:"product_id"`
+	Count       uint `json:"count"`
+	Amount      uint `json:"amount"`
+}
+
+// OrderFilter narrows down orders, zero values mean no filter
+type OrderFilter struct {
+	BuyerId  uint
+	SellerId uint
+}
+
+type OrderService interface {
+	// List returns own orders of buyers, orders containing own products
+	// of sellers and all orders for admins
+	List(ctx context.Context) ([]Order, error)
+	Get(ctx context.Context, id uint) (*Order, error)
+	// Refund gives money of the order back to its buyer, lines is a map of
+	// order item id => count and empty lines refunds all refundable items,
+	// only admins and sellers of refunded items can do it
+	Refund(ctx context.Context, id uint, lines map[uint]uint, reason string, restock bool) (*Refund, error)
+}
+
+type OrderRepository interface {
+	DBTransaction
+	BeginTransaction(ctx context.Context) (context.Context, OrderRepository)
+	Insert(ctx context.Context, o Order) (uint, error)
+	// FindById returns order with its items and refunds
+	FindById(ctx context.Context, id uint) (*Order, error)
+	List(ctx context.Context, filter OrderFilter) ([]Order, error)
+	// InsertRefund persists refund and increases refunded count of order items,
+	// returns ErrNothingToRefund when an item doesn't have enough refundable items
+	InsertRefund(ctx context.Context, r Refund) (uint, error)
+	// DailySales returns units sold per product and day in [from, to)
+	DailySales(ctx context.Context, sellerId uint, from, to time.Time) ([]DailySales, error)
+	// Sales returns units and revenue per product and period of the query
+	Sales(ctx context.Context, q SalesQuery) ([]SalesRow, error)
+	// UnitsBought sums units of the products the buyer bought since then, net of refunds
+	UnitsBought(ctx context.Context, buyerId uint, productIds []uint, since time.Time) (uint, error)
+	// SalesTotals sums orders created in [from, to)
+	SalesTotals(ctx context.Context, from, to time.Time) (*SalesTotals, error)
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// StockStats is stock of active products
+type StockStats struct {
+	Units uint `json:"units"`
+	// Value is units times their current price
+	Value      uint `json:"value"`
+	OutOfStock uint `json:"out_of_stock"`
+}
+
+// SalesTotals is sales of a time range, net of refunds
+type SalesTotals struct {
+	Orders  uint `json:"orders"`
+	Units   uint `json:"units"`
+	Revenue uint `json:"revenue"`
+}
+
+// Overview is the operational state of the machine for admins
+type Overview struct {
+	// Users counts active users by role
+	Users map[Role]uint `json:"users"`
+	// BuyerCredit is sum of deposits held for buyers
+	BuyerCredit    uint        `json:"buyer_credit"`
+	Stock          StockStats  `json:"stock"`
+	SalesToday     SalesTotals `json:"sales_today"`
+	ActiveSessions uint        `json:"active_sessions"`
+	GeneratedAt    time.Time   `json:"generated_at"`
+}
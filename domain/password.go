@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/apm-dev/vending-machine/pkg/password"
+	"github.com/pkg/errors"
+)
+
+// PasswordHasher hashes passwords, Verify reports whether the hash
+// should be replaced because it's of an old algorithm or cost
+type PasswordHasher interface {
+	Hash(passwd string) (string, error)
+	Verify(hash, passwd string) (ok, rehash bool)
+}
+
+// passwordHasher hashes passwords of users, bcrypt of default cost
+// unless the service configures another one
+var passwordHasher PasswordHasher = password.Default()
+
+func SetPasswordHasher(h PasswordHasher) {
+	passwordHasher = h
+}
+
+// PasswordPolicy is checked when passwords are set, zero fields don't restrict
+type PasswordPolicy struct {
+	MinLength uint
+	MaxLength uint
+	// MaxBytes limits UTF-8 length of password, bcrypt only uses 72 bytes
+	MaxBytes uint
+	// MinClasses is the number of character classes (lower case,
+	// upper case, digits and symbols) which password must contain
+	MinClasses uint
+	// Banned are lower cased passwords which are too common to use
+	Banned map[string]struct{}
+}
+
+// Validate returns wrapped ErrWeakPassword which says why password is rejected
+func (p PasswordPolicy) Validate(uname, passwd string) error {
+	min := p.MinLength
+	if min == 0 {
+		min = 1
+	}
+	length := uint(utf8.RuneCountInString(passwd))
+	if length < min {
+		return errors.Wrapf(ErrWeakPassword, "must be at least %d characters", min)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return errors.Wrapf(ErrWeakPassword, "must be at most %d characters", p.MaxLength)
+	}
+	if p.MaxBytes > 0 && uint(len(passwd)) > p.MaxBytes {
+		return errors.Wrapf(ErrWeakPassword, "must be at most %d bytes", p.MaxBytes)
+	}
+	if classes := passwordClasses(passwd); classes < p.MinClasses {
+		return errors.Wrapf(ErrWeakPassword,
+			"must contain %d of lower case, upper case, digits and symbols", p.MinClasses,
+		)
+	}
+	if strings.EqualFold(passwd, uname) {
+		return errors.Wrap(ErrWeakPassword, "must not be the username")
+	}
+	if _, banned := p.Banned[strings.ToLower(passwd)]; banned {
+		return errors.Wrap(ErrWeakPassword, "is too common")
+	}
+	return nil
+}
+
+func passwordClasses(passwd string) uint {
+	var lower, upper, digit, other uint
+	for _, r := range passwd {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
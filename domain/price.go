@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// PriceChange is a record of product price being set,
+// Old is zero for the price product was added with
+type PriceChange struct {
+	Id        uint      `json:"id"`
+	ProductId uint      `json:"product_id"`
+	Old       uint      `json:"old"`
+	New       uint      `json:"new"`
+	ChangedBy uint      `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PriceAt returns price which was in effect at the given time.
+// changes must be sorted by ChangedAt, current is used when
+// product has no recorded history.
+func PriceAt(changes []PriceChange, current uint, at time.Time) uint {
+	if len(changes) == 0 {
+		return current
+	}
+
+	// before the first record product had its old price
+	price := changes[0].Old
+	if price == 0 {
+		price = changes[0].New
+	}
+	for _, c := range changes {
+		if c.ChangedAt.After(at) {
+			break
+		}
+		price = c.New
+	}
+
+	return price
+}
@@ -1,25 +1,169 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Product struct {
-	Id       uint   `json:"id"`
-	Name     string `json:"name"`
-	Count    uint   `json:"count"`
-	Price    uint   `json:"price"`
-	SellerId uint   `json:"seller_id"`
+	Id uint `json:"id"`
+	// Sku is an optional external identifier, unique per seller
+	Sku  string `json:"sku"`
+	Name string `json:"name"`
+	// Description and Name are in the default language of the machine
+	Description string `json:"description"`
+	// Category groups products for quota rules, e.g. "energy-drink"
+	Category string `json:"category,omitempty"`
+	// Lang is the language of Name and Description when listed localized
+	Lang string `json:"lang,omitempty"`
+	// Translations of the product keyed by language
+	Translations map[string]Translation `json:"translations,omitempty"`
+	Count        uint                   `json:"count"`
+	Price        uint                   `json:"price"`
+	SellerId     uint                   `json:"seller_id"`
+	DietInfo
+	// Conflicts with dietary profile of the caller, filled by listing
+	Conflicts []string `json:"conflicts,omitempty"`
+	// DeletedAt is set for soft-deleted products only
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type Bill struct {
+	OrderId    uint   `json:"order_id"`
 	TotalSpent uint   `json:"total_spent"`
 	Items      []Item `json:"items"`
-	Refund     []uint `json:"refund"`
+	// Skipped lists what could not be bought in partial mode
+	Skipped []SkippedItem `json:"skipped,omitempty"`
+	Refund  []uint        `json:"refund"`
 }
 
 type Item struct {
-	Name  string `json:"name"`
-	Count uint   `json:"count"`
-	Price uint   `json:"price"`
+	ProductId uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Count     uint   `json:"count"`
+	// Dispensed items are the ones which dropped and are paid for
+	Dispensed uint `json:"dispensed"`
+	Price     uint `json:"price"`
+}
+
+type SkippedItem struct {
+	ProductId uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Count     uint   `json:"count"`
+	Reason    string `json:"reason"`
+}
+
+// ProductInput is what sellers provide to add or update a product
+type ProductInput struct {
+	Sku         string
+	Name        string
+	Description string
+	Category    string
+	Count       uint
+	Price       uint
+}
+
+// Validate checks the rules of single products, imported rows use it too
+func (in ProductInput) Validate() error {
+	if !ValidProductName(in.Name) || utf8.RuneCountInString(in.Description) > MaxDescriptionLen ||
+		utf8.RuneCountInString(in.Category) > MaxCategoryLen {
+		return ErrInvalidParams
+	}
+	if in.Count == 0 {
+		return ErrInvalidParams
+	}
+	if in.Price == 0 || in.Price%5 != 0 {
+		return ErrInvalidCost
+	}
+	return nil
+}
+
+// maximum lengths are in characters, so they're the same in any script
+const (
+	MaxProductNameLen = 64
+	MaxDescriptionLen = 1024
+	MaxCategoryLen    = 32
+)
+
+// ValidProductName accepts names in any script made of letters, digits,
+// spaces and a few punctuation marks like "Crème brûlée" or "Coca-Cola"
+func ValidProductName(name string) bool {
+	if name == "" || name != strings.TrimSpace(name) || utf8.RuneCountInString(name) > MaxProductNameLen {
+		return false
+	}
+	hasAlnum := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r):
+			hasAlnum = true
+		case unicode.IsMark(r), r == ' ', strings.ContainsRune("-'&.,()/+%", r):
+		default:
+			return false
+		}
+	}
+	return hasAlnum
+}
+
+// ImportRow is one product row of a seller catalog file
+type ImportRow struct {
+	Line int
+	ProductInput
+}
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+type ImportResult struct {
+	Line      int    `json:"line"`
+	Action    string `json:"action"`
+	ProductId uint   `json:"product_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool           `json:"dry_run"`
+	Created int            `json:"created"`
+	Updated int            `json:"updated"`
+	Failed  int            `json:"failed"`
+	Rows    []ImportResult `json:"rows"`
+}
+
+// Add appends result of a row to the report
+func (r *ImportReport) Add(result ImportResult) {
+	switch result.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// ListOptions tunes product listing
+type ListOptions struct {
+	// HideConflicts removes products which conflict with dietary
+	// profile of the caller instead of flagging them
+	HideConflicts bool
+	// Langs are preferred languages of the caller, most preferred first
+	Langs []string
+}
+
+// BuyOptions tunes purchase behaviour
+type BuyOptions struct {
+	// StrictDiet refuses products which conflict with
+	// dietary profile of the buyer
+	StrictDiet bool
+	// Partial buys whatever can be fulfilled within stock and
+	// balance instead of failing the whole purchase
+	Partial bool
 }
 
 func NewProduct(name string, amountAvailable, cost, sellerId uint) *Product {
@@ -32,11 +176,44 @@ func NewProduct(name string, amountAvailable, cost, sellerId uint) *Product {
 }
 
 type ProductService interface {
-	Add(ctx context.Context, name string, amount, cost uint) (*Product, error)
-	List(ctx context.Context) ([]Product, error)
-	Update(ctx context.Context, id uint, name string, amount, cost uint) (*Product, error)
+	Add(ctx context.Context, in ProductInput) (*Product, error)
+	List(ctx context.Context, opts ListOptions) ([]Product, error)
+	Update(ctx context.Context, id uint, in ProductInput) (*Product, error)
+	// Import creates or updates products of the seller matching them by
+	// sku or name, dry run only reports what would happen
+	Import(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error)
+	// Export returns catalog of the seller
+	Export(ctx context.Context) ([]Product, error)
+	// PriceHistory returns price changes of the product, oldest first
+	PriceHistory(ctx context.Context, id uint) ([]PriceChange, error)
+	// PriceAt returns price of the product which was in effect at the time
+	PriceAt(ctx context.Context, id uint, at time.Time) (uint, error)
+	// UpdateTranslations replaces translations of the product
+	UpdateTranslations(ctx context.Context, id uint, ts map[string]Translation) (*Product, error)
+	// AddQuota creates a purchase quota rule (ADMIN only)
+	AddQuota(ctx context.Context, r QuotaRule) (*QuotaRule, error)
+	// ListQuotas returns all quota rules (ADMIN only)
+	ListQuotas(ctx context.Context) ([]QuotaRule, error)
+	// DeleteQuota removes a quota rule (ADMIN only)
+	DeleteQuota(ctx context.Context, id uint) error
+	// QuotaStatus returns what's left of quotas applying to the buyer
+	QuotaStatus(ctx context.Context) ([]QuotaStatus, error)
+	// UpdateDietInfo replaces allergen and nutrition labelling of the product
+	UpdateDietInfo(ctx context.Context, id uint, info DietInfo) (*Product, error)
 	Delete(ctx context.Context, id uint) error
-	Buy(ctx context.Context, cart map[uint]uint) (*Bill, error)
+	Buy(ctx context.Context, cart map[uint]uint, opts BuyOptions) (*Bill, error)
+	// Reserve holds stock of the cart for the buyer until ttl passes,
+	// it replaces previous reservation of the buyer
+	Reserve(ctx context.Context, cart map[uint]uint, ttl time.Duration) (*Reservation, error)
+	GetReservation(ctx context.Context) (*Reservation, error)
+	ReleaseReservation(ctx context.Context) error
+	// ListDeleted returns soft-deleted products (ADMIN only)
+	ListDeleted(ctx context.Context) ([]Product, error)
+	// Restore brings back soft-deleted product if its seller is still
+	// active and has no other product with the same name (ADMIN only)
+	Restore(ctx context.Context, id uint) (*Product, error)
+	// Purge permanently deletes soft-deleted product (ADMIN only)
+	Purge(ctx context.Context, id uint) error
 }
 
 type ProductRepository interface {
@@ -45,6 +222,33 @@ type ProductRepository interface {
 	Insert(ctx context.Context, p Product) (uint, error)
 	FindById(ctx context.Context, id uint) (*Product, error)
 	List(ctx context.Context) ([]Product, error)
+	ListBySeller(ctx context.Context, sellerId uint) ([]Product, error)
+	// FindBySellerAndSku returns ErrProductNotFound when seller has no product of the sku
+	FindBySellerAndSku(ctx context.Context, sellerId uint, sku string) (*Product, error)
 	Update(ctx context.Context, p *Product) error
+	// UpdateDietInfo replaces only allergen and nutrition labelling of the product
+	UpdateDietInfo(ctx context.Context, id uint, info DietInfo) error
+	// AddStock atomically increases count of the product
+	AddStock(ctx context.Context, id, count uint) error
+	// TakeStock atomically decreases count of the product,
+	// returns ErrInsufficientProductsAmount when there isn't enough
+	TakeStock(ctx context.Context, id, count uint) error
 	Delete(ctx context.Context, id uint) error
+	ListDeleted(ctx context.Context) ([]Product, error)
+	FindDeletedById(ctx context.Context, id uint) (*Product, error)
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently deletes the product row with its prices, translations,
+	// cart and reservation items and quota rules
+	Purge(ctx context.Context, id uint) error
+	// ListTranslations returns translations of the products keyed by product id
+	ListTranslations(ctx context.Context, ids []uint) (map[uint]map[string]Translation, error)
+	// SaveTranslations replaces translations of the product
+	SaveTranslations(ctx context.Context, id uint, ts map[string]Translation) error
+	// IdsByCategory returns ids of products of the category, deleted ones too
+	IdsByCategory(ctx context.Context, category string) ([]uint, error)
+	// StockStats aggregates stock of active products
+	StockStats(ctx context.Context) (*StockStats, error)
+	InsertPriceChange(ctx context.Context, c PriceChange) error
+	// ListPriceChanges returns price history of the product, oldest first
+	ListPriceChanges(ctx context.Context, productId uint) ([]PriceChange, error)
 }
@@ -0,0 +1,130 @@
+package domain
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// periods of quota rules
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// QuotaRule limits units a buyer can buy of a product or a category
+// in a period, like "max 2 energy drinks per day per person"
+type QuotaRule struct {
+	Id   uint   `json:"id"`
+	Name string `json:"name"`
+	// one of ProductId and Category is set
+	ProductId uint   `json:"product_id,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Limit     uint   `json:"limit"`
+	Period    string `json:"period"`
+	// Rolling counts the last period up to now (e.g. 24 hours)
+	// instead of the calendar one (e.g. today)
+	Rolling bool `json:"rolling"`
+	// BuyerIds limits the rule to these buyers, empty applies to all
+	BuyerIds []uint `json:"buyer_ids,omitempty"`
+}
+
+func (r QuotaRule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" || r.Limit == 0 {
+		return ErrInvalidParams
+	}
+	if (r.ProductId == 0) == (r.Category == "") {
+		return ErrInvalidParams
+	}
+	switch r.Period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+	default:
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+// AppliesToBuyer reports whether rule limits the buyer
+func (r QuotaRule) AppliesToBuyer(buyerId uint) bool {
+	if len(r.BuyerIds) == 0 {
+		return true
+	}
+	for _, id := range r.BuyerIds {
+		if id == buyerId {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesTo reports whether rule limits the buyer buying the product
+func (r QuotaRule) AppliesTo(buyerId uint, p *Product) bool {
+	if !r.AppliesToBuyer(buyerId) {
+		return false
+	}
+	if r.ProductId != 0 {
+		return r.ProductId == p.Id
+	}
+	return p.Category != "" && strings.EqualFold(r.Category, p.Category)
+}
+
+// WindowStart returns since when purchases count against the rule
+func (r QuotaRule) WindowStart(now time.Time) time.Time {
+	if r.Rolling {
+		switch r.Period {
+		case PeriodWeek:
+			return now.AddDate(0, 0, -7)
+		case PeriodMonth:
+			return now.AddDate(0, -1, 0)
+		default:
+			return now.AddDate(0, 0, -1)
+		}
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch r.Period {
+	case PeriodWeek:
+		// weeks start on monday
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	case PeriodMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	default:
+		return today
+	}
+}
+
+// ResetsAt returns when calendar quota starts over, rolling quotas
+// free up gradually so it's nil for them
+func (r QuotaRule) ResetsAt(now time.Time) *time.Time {
+	if r.Rolling {
+		return nil
+	}
+	start := r.WindowStart(now)
+	var t time.Time
+	switch r.Period {
+	case PeriodWeek:
+		t = start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		t = start.AddDate(0, 1, 0)
+	default:
+		t = start.AddDate(0, 0, 1)
+	}
+	return &t
+}
+
+// QuotaStatus is what's left of a quota rule for a buyer
+type QuotaStatus struct {
+	Rule      QuotaRule  `json:"rule"`
+	Used      uint       `json:"used"`
+	Remaining uint       `json:"remaining"`
+	Since     time.Time  `json:"since"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+}
+
+type QuotaRepository interface {
+	Insert(ctx context.Context, r QuotaRule) (uint, error)
+	List(ctx context.Context) ([]QuotaRule, error)
+	Delete(ctx context.Context, id uint) error
+}
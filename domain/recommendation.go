@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Recommendations are suggestions of in stock products for a buyer
+type Recommendations struct {
+	// Favourites are products the buyer usually buys
+	Favourites []Product `json:"favourites"`
+	// BoughtTogether are products often bought with the favourites,
+	// or with the requested product
+	BoughtTogether []Product `json:"bought_together"`
+	// BuiltAt is when the model was last rebuilt from orders
+	BuiltAt time.Time `json:"built_at"`
+}
+
+type RecommendationOptions struct {
+	// ProductId asks for products bought together with it instead
+	// of with favourites of the buyer
+	ProductId uint
+	// Limit is the maximum number of products of each list
+	Limit int
+}
+
+type RecommendationService interface {
+	// For returns recommendations for the buyer in the context
+	For(ctx context.Context, opts RecommendationOptions) (*Recommendations, error)
+	// Rebuild recomputes the model from purchase history (ADMIN only)
+	Rebuild(ctx context.Context) error
+	// Refresh recomputes the model, it's meant for scheduled rebuilds
+	Refresh(ctx context.Context) error
+}
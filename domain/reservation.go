@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Reservation holds stock of a buyer cart until it expires,
+// each buyer has at most one active reservation
+type Reservation struct {
+	Id     uint `json:"id"`
+	UserId uint `json:"user_id"`
+	// map of product id => count
+	Items     map[uint]uint `json:"items"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
+
+func (r *Reservation) IsExpired() bool {
+	return !r.ExpiresAt.After(time.Now())
+}
+
+type ReservationRepository interface {
+	DBTransaction
+	BeginTransaction(ctx context.Context) (context.Context, ReservationRepository)
+	Insert(ctx context.Context, r Reservation) (uint, error)
+	// FindActiveByUser returns not expired reservation of the user
+	FindActiveByUser(ctx context.Context, userId uint) (*Reservation, error)
+	// HeldCounts returns product id => count held by
+	// not expired reservations of all users except given one
+	HeldCounts(ctx context.Context, exceptUserId uint) (map[uint]uint, error)
+	Update(ctx context.Context, r *Reservation) error
+	DeleteByUser(ctx context.Context, userId uint) error
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+const CLIENT ContextKey = "client"
+
+// Session is a login of the user, tokens rotated by refresh
+// belong to the same session, so its id is the token family
+type Session struct {
+	Id     string `json:"id"`
+	UserId uint   `json:"user_id"`
+	// Name is set by the user to recognize the device
+	Name       string    `json:"name"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current is true for session of the requesting token
+	Current bool `json:"current"`
+}
+
+// ClientInfo describes the client of a request, recorded on sessions
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+// ClientFromContext returns client of the request or zero value when unknown
+func ClientFromContext(ctx context.Context) ClientInfo {
+	if c, ok := ctx.Value(CLIENT).(ClientInfo); ok {
+		return c
+	}
+	return ClientInfo{}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// TokenPair is issued on register, login and every refresh.
+// Access token is short-lived jwt, refresh token is an opaque
+// string which is exchanged once for a new pair.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is lifetime of access token in seconds
+	ExpiresIn uint `json:"expires_in"`
+}
+
+// RefreshToken is persisted refresh token, tokens rotated from
+// the same login share a family, so reusing any of them revokes
+// the whole chain.
+type RefreshToken struct {
+	Token  string
+	UserId uint
+	Family string
+	// UsedAt is set once the token is exchanged
+	UsedAt    *time.Time
+	ExpiredAt time.Time
+}
+
+func (t *RefreshToken) Used() bool {
+	return t.UsedAt != nil
+}
+
+func (t *RefreshToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiredAt)
+}
+
+// JWK is a public key which verifies access tokens, in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// modulus and exponent of RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// curve and public key of Ed25519 keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is served to other services which verify access tokens
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// TwoFactor is the TOTP enrolment of a user, it protects logins
+// once the user confirmed it with a code of the authenticator app
+type TwoFactor struct {
+	UserId uint
+	// Secret is the base32 TOTP key shared with the authenticator app
+	Secret      string
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+func (t *TwoFactor) Enabled() bool {
+	return t.ConfirmedAt != nil
+}
+
+// TwoFactorEnrollment is shown once to be scanned by an authenticator app,
+// URI is the otpauth:// uri which clients render as QR code
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// LoginChallenge is issued instead of tokens when login needs
+// a second factor, its token is exchanged with a code for tokens
+type LoginChallenge struct {
+	Token  string `json:"challenge_token"`
+	UserId uint   `json:"-"`
+	// Enroll says user must enrol 2FA first, the code confirms the enrolment
+	Enroll     bool                 `json:"enroll"`
+	Enrollment *TwoFactorEnrollment `json:"enrollment,omitempty"`
+	ExpiresIn  int64                `json:"expires_in"`
+	ExpiredAt  time.Time            `json:"-"`
+}
+
+// LoginResult has tokens of a completed login or a challenge
+// when login needs a second factor
+type LoginResult struct {
+	*TokenPair
+	Challenge *LoginChallenge `json:"challenge,omitempty"`
+	// BackupCodes are shown once, when 2FA is enrolled on login
+	BackupCodes []string `json:"backup_codes,omitempty"`
+	// OtherSessions says is there another active session using this account
+	OtherSessions bool `json:"-"`
+}
+
+// TwoFactorPolicy configures TOTP of logins
+type TwoFactorPolicy struct {
+	// Issuer is the account issuer shown by authenticator apps
+	Issuer string
+	// ChallengeTTL is how long a login challenge can be answered
+	ChallengeTTL time.Duration
+	// MaxAttempts of a challenge, it's revoked after that
+	MaxAttempts uint
+	// Skew is the number of time steps around now which are accepted (clock drift)
+	Skew uint
+	// BackupCodes is the number of generated backup codes
+	BackupCodes uint
+}
+
+type TwoFactorRepository interface {
+	// Find returns ErrTwoFactorNotFound when user has no enrolment
+	Find(ctx context.Context, userId uint) (*TwoFactor, error)
+	// Save inserts or replaces enrolment of the user
+	Save(ctx context.Context, tf TwoFactor) error
+	// Delete deletes enrolment and backup codes of the user
+	Delete(ctx context.Context, userId uint) error
+	// UseStep atomically records the time step of an accepted code, returns
+	// false when a code of the step or a later one was used already (replay)
+	UseStep(ctx context.Context, userId uint, step uint64) (bool, error)
+	// ReplaceBackupCodes persists codes instead of the current ones of the user
+	ReplaceBackupCodes(ctx context.Context, userId uint, codes []string) error
+	// UseBackupCode deletes the code, returns false when user has no such code
+	UseBackupCode(ctx context.Context, userId uint, code string) (bool, error)
+	InsertChallenge(ctx context.Context, c LoginChallenge) error
+	// FindChallenge returns ErrInvalidToken when challenge doesn't exist or is expired
+	FindChallenge(ctx context.Context, token string) (*LoginChallenge, error)
+	// AddChallengeAttempt counts a wrong code of the challenge, returns the count
+	AddChallengeAttempt(ctx context.Context, token string) (uint, error)
+	DeleteChallenge(ctx context.Context, token string) error
+	// DeleteExpiredChallenges returns number of deleted challenges
+	DeleteExpiredChallenges(ctx context.Context) (uint, error)
+	// RequiredRoles returns roles which must use 2FA
+	RequiredRoles(ctx context.Context) ([]Role, error)
+	SetRequiredRoles(ctx context.Context, roles []Role) error
+}
@@ -6,12 +6,14 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	USER  ContextKey = "user"
 	TOKEN ContextKey = "token"
+	// SUBJECT is id of the user of a validly signed token, set before
+	// the token is checked in db so it may belong to a revoked session
+	SUBJECT ContextKey = "subject"
 
 	ADMIN  Role = "admin"
 	SELLER Role = "seller"
@@ -24,14 +26,23 @@ type (
 )
 
 type User struct {
-	Id        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"`
-	Role      Role      `json:"role"`
-	Deposit   uint      `json:"deposit"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	DeletedAt time.Time `json:"-"`
+	Id       uint   `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+	Role     Role   `json:"role"`
+	Deposit  uint   `json:"deposit"`
+	// Diet is the dietary profile of buyer
+	Diet      DietaryProfile `json:"diet"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	// DeletedAt is set for soft-deleted users only
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// DisabledAt is set for accounts disabled by admins, they can't login
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+func (r Role) Valid() bool {
+	return r == ADMIN || r == SELLER || r == BUYER
 }
 
 func NewUser(uname, passwd string, role Role) (*User, error) {
@@ -52,17 +63,30 @@ func NewUser(uname, passwd string, role Role) (*User, error) {
 func (u *User) SetPassword(passwd string) error {
 	const op string = "domain.user.SetPassword"
 	// storing hash of password for security reasons
-	hash, err := bcrypt.GenerateFromPassword([]byte(passwd), bcrypt.DefaultCost)
+	hash, err := passwordHasher.Hash(passwd)
 	if err != nil {
 		return errors.Wrap(err, op)
 	}
-	u.Password = string(hash)
+	u.Password = hash
 	return nil
 }
 
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+// CheckPassword reports whether password is correct, hash of old algorithm
+// or cost is replaced by a current one and rehashed is true so it gets saved
+func (u *User) CheckPassword(password string) (ok, rehashed bool) {
+	ok, rehash := passwordHasher.Verify(u.Password, password)
+	if !ok || !rehash {
+		return ok, false
+	}
+	// keeping the old hash still lets the user in
+	if err := u.SetPassword(password); err != nil {
+		return true, false
+	}
+	return true, true
+}
+
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
 
 func (u *User) AddDeposit(coin Coin) {
@@ -98,24 +122,82 @@ func TokenFromContext(ctx context.Context) (string, error) {
 }
 
 type UserService interface {
-	// Register creates new user and return jwt token or error
-	Register(ctx context.Context, uname, pass string, role Role) (string, error)
-	// Login checks credentials, generate and return jwt token and a boolean
-	// which says is there another active session using this account or not
-	Login(ctx context.Context, uname, pass string) (string, bool, error)
+	// Register creates new user and return token pair or error,
+	// users of roles which must use 2FA get an enrolment challenge instead
+	Register(ctx context.Context, uname, pass string, role Role) (*LoginResult, error)
+	// Login checks credentials, generate and return token pair and whether
+	// there is another active session using this account or not,
+	// users with 2FA get a challenge instead of tokens,
+	// failed logins are throttled by username and client ip
+	Login(ctx context.Context, uname, pass string) (*LoginResult, error)
+	// VerifyLogin completes login of the challenge with a TOTP or backup code
+	VerifyLogin(ctx context.Context, challenge, code string) (*LoginResult, error)
+	// Refresh exchanges refresh token for a new token pair,
+	// reusing an exchanged token revokes all tokens of its family
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
 	// Authorize parses jwt token and return related user
 	Authorize(ctx context.Context, token string) (*User, error)
+	// Subject verifies signature of jwt token and returns id of its user,
+	// it doesn't touch db so revoked tokens aren't detected
+	Subject(ctx context.Context, token string) (uint, error)
+	// PublicKeys returns keys which verify access tokens
+	PublicKeys(ctx context.Context) JWKSet
 	// TerminateActiveSessions terminates all other active sessions
 	TerminateActiveSessions(ctx context.Context) error
+	// Logout terminates the current session
+	Logout(ctx context.Context) error
+	// Sessions lists active sessions of the user
+	Sessions(ctx context.Context) ([]Session, error)
+	// RenameSession names a session of the user
+	RenameSession(ctx context.Context, id, name string) error
+	// RevokeSession terminates a session of the user, ADMIN can revoke any session
+	RevokeSession(ctx context.Context, id string) error
+	// UserSessions lists active sessions of any user (ADMIN only)
+	UserSessions(ctx context.Context, userId uint) ([]Session, error)
+	// RevokeUserSessions terminates all sessions of any user (ADMIN only)
+	RevokeUserSessions(ctx context.Context, userId uint) error
 	// Deposit increases buyer(user) deposit
 	Deposit(ctx context.Context, coin Coin) (uint, error)
 	// ResetDeposit reset buyer(user) deposits back to zero
 	ResetDeposit(ctx context.Context) ([]uint, error)
-	// User CRUD
-	Update(ctx context.Context, passwd string) error
-	Delete(ctx context.Context) ([]uint, error)
+	// UpdateDietaryProfile saves dietary profile of buyer(user)
+	UpdateDietaryProfile(ctx context.Context, profile DietaryProfile) (*User, error)
+	// User CRUD, users update and delete themselves, ADMIN any user
+	Update(ctx context.Context, id uint, passwd string) error
+	Delete(ctx context.Context, id uint) ([]uint, error)
 	Get(ctx context.Context, id uint) (*User, error)
 	List(ctx context.Context) ([]User, error)
+	// ListDeleted returns soft-deleted users (ADMIN only)
+	ListDeleted(ctx context.Context) ([]User, error)
+	// Restore brings back soft-deleted user with its deposit (ADMIN only)
+	Restore(ctx context.Context, id uint) (*User, error)
+	// Purge permanently deletes soft-deleted user (ADMIN only)
+	Purge(ctx context.Context, id uint) error
+	// SetRole changes role of the user (ADMIN only)
+	SetRole(ctx context.Context, id uint, role Role) (*User, error)
+	// SetDisabled disables or re-enables the user (ADMIN only),
+	// sessions of disabled users are terminated
+	SetDisabled(ctx context.Context, id uint, disabled bool) (*User, error)
+	// BootstrapAdmin creates the admin when there is no admin yet
+	BootstrapAdmin(ctx context.Context, uname, pass string) error
+	// Unlock forgets failed logins of the user and ends its lockout (ADMIN only)
+	Unlock(ctx context.Context, id uint) error
+	// Lockouts lists recorded lockouts (ADMIN only)
+	Lockouts(ctx context.Context) ([]Lockout, error)
+	// EnrollTwoFactor starts TOTP enrolment of the user, it's enabled on confirm
+	EnrollTwoFactor(ctx context.Context) (*TwoFactorEnrollment, error)
+	// ConfirmTwoFactor enables 2FA with a code of the enrolment and returns backup codes
+	ConfirmTwoFactor(ctx context.Context, code string) ([]string, error)
+	// RegenerateBackupCodes replaces backup codes of the user
+	RegenerateBackupCodes(ctx context.Context, code string) ([]string, error)
+	// DisableTwoFactor disables 2FA of the user with a TOTP or backup code
+	DisableTwoFactor(ctx context.Context, code string) error
+	// ResetTwoFactor removes 2FA of the user who lost the device (ADMIN only)
+	ResetTwoFactor(ctx context.Context, id uint) error
+	// TwoFactorRoles returns roles which must use 2FA (ADMIN only)
+	TwoFactorRoles(ctx context.Context) ([]Role, error)
+	// SetTwoFactorRoles sets roles which must use 2FA (ADMIN only)
+	SetTwoFactorRoles(ctx context.Context, roles []Role) error
 }
 
 type UserRepository interface {
@@ -126,12 +208,69 @@ type UserRepository interface {
 	FindByUsername(ctx context.Context, un string) (*User, error)
 	List(ctx context.Context) ([]User, error)
 	Update(ctx context.Context, u *User) error
-	Delete(ctx context.Context, id uint) error
+	// UpdatePassword replaces only the password hash of the user
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+	// UpdateRole replaces only the role of the user
+	UpdateRole(ctx context.Context, id uint, role Role) error
+	// SetDisabledAt replaces only disable time of the user, nil re-enables it
+	SetDisabledAt(ctx context.Context, id uint, at *time.Time) error
+	// UpdateDiet replaces only the dietary profile of the user
+	UpdateDiet(ctx context.Context, id uint, diet DietaryProfile) error
+	// AddDeposit atomically increases deposit of the user
+	AddDeposit(ctx context.Context, id, amount uint) error
+	// ResetDeposit atomically zeroes deposit of the user and returns what it was
+	ResetDeposit(ctx context.Context, id uint) (uint, error)
+	// SpendDeposit atomically decreases deposit of the user,
+	// returns ErrInsufficientBalance when there isn't enough
+	SpendDeposit(ctx context.Context, id, amount uint) error
+	// Delete soft-deletes the user, zeroes its deposit which is refunded
+	// and returns it, the refunded deposit is kept for restore
+	Delete(ctx context.Context, id uint) (uint, error)
+	ListDeleted(ctx context.Context) ([]User, error)
+	FindDeletedById(ctx context.Context, id uint) (*User, error)
+	// Restore brings back soft-deleted user and credits back its refunded deposit
+	Restore(ctx context.Context, id uint) error
+	// Purge permanently deletes the user row
+	Purge(ctx context.Context, id uint) error
+	// CountByRole counts active users of each role
+	CountByRole(ctx context.Context) (map[Role]uint, error)
+	// SumDeposits returns total deposit of active users of the role
+	SumDeposits(ctx context.Context, role Role) (uint, error)
 }
 
 type JwtRepository interface {
-	Insert(ctx context.Context, userId uint, token string, ttl time.Duration) error
+	DBTransaction
+	BeginTransaction(ctx context.Context) (context.Context, JwtRepository)
+	// Insert persists access token which starts or continues the session
+	Insert(ctx context.Context, token string, s Session, ttl time.Duration) error
+	// Exists reports whether the token is persisted and not expired
 	Exists(ctx context.Context, token string) (bool, error)
+	// Touch updates last use time of the session of the token
+	Touch(ctx context.Context, token string) error
+	// Rotate replaces access token of the session on refresh
+	Rotate(ctx context.Context, sessionId, token string, ttl time.Duration, client ClientInfo) error
+	ListSessions(ctx context.Context, userId uint) ([]Session, error)
+	// FindSession returns ErrSessionNotFound when session doesn't exist
+	FindSession(ctx context.Context, id string) (*Session, error)
+	// FindSessionByToken returns ErrSessionNotFound when token doesn't exist
+	FindSessionByToken(ctx context.Context, token string) (*Session, error)
+	RenameSession(ctx context.Context, id, name string) error
+	// UserTokensCount counts alive sessions of the user
 	UserTokensCount(ctx context.Context, uid uint) (uint, error)
 	DeleteTokensOfUserExcept(ctx context.Context, userId uint, exceptionToken string) error
+	// ActiveCount counts alive sessions of all users, a session is alive
+	// while its access token isn't expired or it can still be refreshed
+	ActiveCount(ctx context.Context) (uint, error)
+	InsertRefresh(ctx context.Context, rt RefreshToken) error
+	// FindRefresh returns ErrInvalidToken when token doesn't exist
+	FindRefresh(ctx context.Context, token string) (*RefreshToken, error)
+	// MarkRefreshUsed atomically sets used time of an unused token,
+	// returns false when token was already used
+	MarkRefreshUsed(ctx context.Context, token string) (bool, error)
+	// RevokeFamily deletes refresh and access tokens of the family (session),
+	// returns ErrSessionNotFound for an empty family
+	RevokeFamily(ctx context.Context, family string) error
+	// DeleteExpired deletes expired refresh tokens and access tokens of sessions
+	// which can't be refreshed anymore, returns number of deleted rows
+	DeleteExpired(ctx context.Context) (uint, error)
 }
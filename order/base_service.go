@@ -0,0 +1,92 @@
+package order
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+type Service struct {
+	or domain.OrderRepository
+	ur domain.UserRepository
+	pr domain.ProductRepository
+}
+
+func InitService(
+	or domain.OrderRepository,
+	ur domain.UserRepository,
+	pr domain.ProductRepository,
+) domain.OrderService {
+	return &Service{or: or, ur: ur, pr: pr}
+}
+
+func (s *Service) List(ctx context.Context) ([]domain.Order, error) {
+	const op string = "order.service.List"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	var filter domain.OrderFilter
+	switch u.Role {
+	case domain.BUYER:
+		filter.BuyerId = u.Id
+	case domain.SELLER:
+		filter.SellerId = u.Id
+	}
+
+	orders, err := s.or.List(ctx, filter)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return orders, nil
+}
+
+func (s *Service) Get(ctx context.Context, id uint) (*domain.Order, error) {
+	const op string = "order.service.Get"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	o, err := s.or.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, domain.ErrOrderNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if !canSee(u, o) {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	return o, nil
+}
+
+// canSee says is the order visible to the user or not,
+// buyers see their own orders and sellers orders of their products
+func canSee(u *domain.User, o *domain.Order) bool {
+	switch u.Role {
+	case domain.ADMIN:
+		return true
+	case domain.BUYER:
+		return o.BuyerId == u.Id
+	case domain.SELLER:
+		for _, i := range o.Items {
+			if i.SellerId == u.Id {
+				return true
+			}
+		}
+	}
+	return false
+}
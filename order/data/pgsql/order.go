@@ -0,0 +1,149 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type Order struct {
+	ID        uint        `gorm:"primarykey"`
+	BuyerID   uint        `gorm:"index;column:buyer_id"`
+	Total     uint        `gorm:"column:total"`
+	Items     []OrderItem `gorm:"foreignKey:OrderID"`
+	Refunds   []Refund    `gorm:"foreignKey:OrderID"`
+	CreatedAt time.Time   `gorm:"index"`
+}
+
+type OrderItem struct {
+	ID        uint   `gorm:"primarykey"`
+	OrderID   uint   `gorm:"index;column:order_id"`
+	ProductID uint   `gorm:"index;column:product_id"`
+	SellerID  uint   `gorm:"index;column:seller_id"`
+	Name      string `gorm:"column:name"`
+	Count     uint   `gorm:"column:count"`
+	Price     uint   `gorm:"column:price"`
+	Refunded  uint   `gorm:"column:refunded"`
+}
+
+type Refund struct {
+	ID        uint         `gorm:"primarykey"`
+	OrderID   uint         `gorm:"index;column:order_id"`
+	ActorID   uint         `gorm:"column:actor_id"`
+	Reason    string       `gorm:"column:reason"`
+	Amount    uint         `gorm:"column:amount"`
+	Restock   bool         `gorm:"column:restock"`
+	Items     []RefundItem `gorm:"foreignKey:RefundID"`
+	CreatedAt time.Time
+}
+
+type RefundItem struct {
+	ID          uint `gorm:"primarykey"`
+	RefundID    uint `gorm:"index;column:refund_id"`
+	OrderItemID uint `gorm:"column:order_item_id"`
+	ProductID   uint `gorm:"column:product_id"`
+	Count       uint `gorm:"column:count"`
+	Amount      uint `gorm:"column:amount"`
+}
+
+func (o *Order) TableName() string {
+	return "orders"
+}
+
+func (i *OrderItem) TableName() string {
+	return "order_items"
+}
+
+func (r *Refund) TableName() string {
+	return "refunds"
+}
+
+func (i *RefundItem) TableName() string {
+	return "refund_items"
+}
+
+func (o *Order) FromDomain(order domain.Order) {
+	o.ID = order.Id
+	o.BuyerID = order.BuyerId
+	o.Total = order.Total
+	o.CreatedAt = order.CreatedAt
+	o.Items = make([]OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		o.Items[i] = OrderItem{
+			ID:        item.Id,
+			OrderID:   order.Id,
+			ProductID: item.ProductId,
+			SellerID:  item.SellerId,
+			Name:      item.Name,
+			Count:     item.Count,
+			Price:     item.Price,
+			Refunded:  item.Refunded,
+		}
+	}
+}
+
+func (o *Order) ToDomain() *domain.Order {
+	order := &domain.Order{
+		Id:        o.ID,
+		BuyerId:   o.BuyerID,
+		Total:     o.Total,
+		CreatedAt: o.CreatedAt,
+		Items:     make([]domain.OrderItem, len(o.Items)),
+		Refunds:   make([]domain.Refund, len(o.Refunds)),
+	}
+	for i, item := range o.Items {
+		order.Items[i] = domain.OrderItem{
+			Id:        item.ID,
+			ProductId: item.ProductID,
+			SellerId:  item.SellerID,
+			Name:      item.Name,
+			Count:     item.Count,
+			Price:     item.Price,
+			Refunded:  item.Refunded,
+		}
+	}
+	for i, r := range o.Refunds {
+		order.Refunds[i] = *r.ToDomain()
+	}
+	return order
+}
+
+func (r *Refund) FromDomain(refund domain.Refund) {
+	r.ID = refund.Id
+	r.OrderID = refund.OrderId
+	r.ActorID = refund.ActorId
+	r.Reason = refund.Reason
+	r.Amount = refund.Amount
+	r.Restock = refund.Restock
+	r.Items = make([]RefundItem, len(refund.Items))
+	for i, item := range refund.Items {
+		r.Items[i] = RefundItem{
+			OrderItemID: item.OrderItemId,
+			ProductID:   item.ProductId,
+			Count:       item.Count,
+			Amount:      item.Amount,
+		}
+	}
+}
+
+func (r *Refund) ToDomain() *domain.Refund {
+	refund := &domain.Refund{
+		Id:        r.ID,
+		OrderId:   r.OrderID,
+		ActorId:   r.ActorID,
+		Reason:    r.Reason,
+		Amount:    r.Amount,
+		Restock:   r.Restock,
+		CreatedAt: r.CreatedAt,
+		Items:     make([]domain.RefundItem, len(r.Items)),
+	}
+	for i, item := range r.Items {
+		refund.Items[i] = domain.RefundItem{
+			OrderItemId: item.OrderItemID,
+			ProductId:   item.ProductID,
+			Count:       item.Count,
+			Amount:      item.Amount,
+		}
+	}
+	return refund
+}
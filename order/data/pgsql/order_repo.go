@@ -0,0 +1,129 @@
+package pgsql
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/pgsqlhelper"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type OrderRepository struct {
+	db *gorm.DB
+}
+
+func InitOrderRepository(db *gorm.DB) domain.OrderRepository {
+	return &OrderRepository{
+		db: db,
+	}
+}
+
+func (r *OrderRepository) BeginTransaction(ctx context.Context) (context.Context, domain.OrderRepository) {
+	if tx, ok := pgsqlhelper.TransactionFromContext(ctx); ok {
+		return ctx, InitOrderRepository(tx)
+	}
+	tx := r.db.Begin()
+	ctx = pgsqlhelper.TransactionToContext(ctx, tx)
+	return ctx, InitOrderRepository(tx)
+}
+
+func (r *OrderRepository) Commit() {
+	r.db.Commit()
+}
+
+func (r *OrderRepository) Rollback() {
+	r.db.Rollback()
+}
+
+func (r *OrderRepository) Insert(ctx context.Context, o domain.Order) (uint, error) {
+	const op string = "order.data.pgsql.order_repo.Insert"
+
+	dbo := new(Order)
+	dbo.FromDomain(o)
+
+	err := r.db.WithContext(ctx).Create(&dbo).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return dbo.ID, nil
+}
+
+func (r *OrderRepository) FindById(ctx context.Context, id uint) (*domain.Order, error) {
+	const op string = "order.data.pgsql.order_repo.FindById"
+
+	dbo := new(Order)
+
+	err := r.db.WithContext(ctx).
+		Preload("Items").Preload("Refunds.Items").
+		First(&dbo, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrOrderNotFound, op)
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return dbo.ToDomain(), nil
+}
+
+func (r *OrderRepository) List(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	const op string = "order.data.pgsql.order_repo.List"
+
+	var dbos []Order
+
+	q := r.db.WithContext(ctx).
+		Preload("Items").Preload("Refunds.Items").
+		Order("created_at DESC")
+	if filter.BuyerId != 0 {
+		q = q.Where("buyer_id = ?", filter.BuyerId)
+	}
+	if filter.SellerId != 0 {
+		q = q.Where("id IN (?)", r.db.Model(&OrderItem{}).
+			Select("order_id").Where("seller_id = ?", filter.SellerId),
+		)
+	}
+
+	err := q.Find(&dbos).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	orders := make([]domain.Order, len(dbos))
+	for i, dbo := range dbos {
+		orders[i] = *dbo.ToDomain()
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepository) InsertRefund(ctx context.Context, refund domain.Refund) (uint, error) {
+	const op string = "order.data.pgsql.order_repo.InsertRefund"
+
+	dbr := new(Refund)
+	dbr.FromDomain(refund)
+
+	err := r.db.WithContext(ctx).Create(&dbr).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	for _, item := range refund.Items {
+		// a concurrent refund of the item may have taken its refundable count,
+		// so the check is part of the update
+		result := r.db.WithContext(ctx).Model(&OrderItem{}).
+			Where("id = ? AND refunded + ? <= count", item.OrderItemId, item.Count).
+			Update("refunded", gorm.Expr("refunded + ?", item.Count))
+		if result.Error != nil {
+			return 0, errors.Wrap(result.Error, op)
+		}
+		if result.RowsAffected == 0 {
+			return 0, errors.Wrapf(domain.ErrNothingToRefund,
+				"%s: order item %d has less than %d refundable items", op, item.OrderItemId, item.Count,
+			)
+		}
+	}
+
+	return dbr.ID, nil
+}
@@ -0,0 +1,138 @@
+package pgsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+)
+
+func (r *OrderRepository) DailySales(ctx context.Context, sellerId uint, from, to time.Time) ([]domain.DailySales, error) {
+	const op string = "order.data.pgsql.order_repo.DailySales"
+
+	var rows []struct {
+		ProductID uint
+		Day       time.Time
+		Units     uint
+	}
+
+	err := r.db.WithContext(ctx).Table("order_items AS i").
+		Select("i.product_id, date_trunc('day', o.created_at) AS day, SUM(i.count - i.refunded) AS units").
+		Joins("JOIN orders AS o ON o.id = i.order_id").
+		Where("i.seller_id = ? AND o.created_at >= ? AND o.created_at < ?", sellerId, from, to).
+		Group("i.product_id, day").
+		Order("day, i.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	sales := make([]domain.DailySales, len(rows))
+	for i, row := range rows {
+		sales[i] = domain.DailySales{
+			ProductId: row.ProductID,
+			Day:       row.Day,
+			Units:     row.Units,
+		}
+	}
+
+	return sales, nil
+}
+
+func (r *OrderRepository) Sales(ctx context.Context, q domain.SalesQuery) ([]domain.SalesRow, error) {
+	const op string = "order.data.pgsql.order_repo.Sales"
+
+	var period string
+	switch q.Bucket {
+	case domain.BucketDay:
+		period = "to_char(date_trunc('day', o.created_at), 'YYYY-MM-DD')"
+	case domain.BucketWeek:
+		period = "to_char(date_trunc('week', o.created_at), 'YYYY-MM-DD')"
+	case domain.BucketHourOfDay:
+		period = "to_char(o.created_at, 'HH24')"
+	default:
+		return nil, errors.Wrapf(domain.ErrInvalidParams, "%s: unknown bucket %q", op, q.Bucket)
+	}
+
+	var rows []struct {
+		Period    string
+		ProductID uint
+		SellerID  uint
+		Name      string
+		Units     uint
+		Revenue   uint
+	}
+
+	db := r.db.WithContext(ctx).Table("order_items AS i").
+		Select(period+" AS period, i.product_id, i.seller_id, MAX(i.name) AS name, "+
+			"SUM(i.count - i.refunded) AS units, SUM((i.count - i.refunded) * i.price) AS revenue").
+		Joins("JOIN orders AS o ON o.id = i.order_id").
+		Where("o.created_at >= ? AND o.created_at < ?", q.From, q.To)
+	if q.SellerId != 0 {
+		db = db.Where("i.seller_id = ?", q.SellerId)
+	}
+
+	err := db.Group("period, i.product_id, i.seller_id").
+		Order("period, i.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	sales := make([]domain.SalesRow, len(rows))
+	for i, row := range rows {
+		sales[i] = domain.SalesRow{
+			Period: row.Period,
+			ProductSales: domain.ProductSales{
+				ProductId: row.ProductID,
+				SellerId:  row.SellerID,
+				Name:      row.Name,
+				Units:     row.Units,
+				Revenue:   row.Revenue,
+			},
+		}
+	}
+
+	return sales, nil
+}
+
+func (r *OrderRepository) SalesTotals(ctx context.Context, from, to time.Time) (*domain.SalesTotals, error) {
+	const op string = "order.data.pgsql.order_repo.SalesTotals"
+
+	totals := new(domain.SalesTotals)
+
+	err := r.db.WithContext(ctx).Table("order_items AS i").
+		Select("COUNT(DISTINCT i.order_id) AS orders, "+
+			"COALESCE(SUM(i.count - i.refunded), 0) AS units, "+
+			"COALESCE(SUM((i.count - i.refunded) * i.price), 0) AS revenue").
+		Joins("JOIN orders AS o ON o.id = i.order_id").
+		Where("o.created_at >= ? AND o.created_at < ?", from, to).
+		Scan(totals).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return totals, nil
+}
+
+func (r *OrderRepository) UnitsBought(ctx context.Context, buyerId uint, productIds []uint, since time.Time) (uint, error) {
+	const op string = "order.data.pgsql.order_repo.UnitsBought"
+
+	if len(productIds) == 0 {
+		return 0, nil
+	}
+
+	var units uint
+
+	err := r.db.WithContext(ctx).Table("order_items AS i").
+		Select("COALESCE(SUM(i.count - i.refunded), 0)").
+		Joins("JOIN orders AS o ON o.id = i.order_id").
+		Where("o.buyer_id = ? AND i.product_id IN ? AND o.created_at >= ?", buyerId, productIds, since).
+		Scan(&units).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return units, nil
+}
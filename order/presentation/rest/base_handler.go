@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+type OrderHandler struct {
+	os domain.OrderService
+}
+
+// InitOrderHandler
+// auth echo group which uses auth middleware
+func InitOrderHandler(auth *echo.Group, os domain.OrderService) *OrderHandler {
+	h := &OrderHandler{os: os}
+
+	og := auth.Group("/orders")
+	og.GET("/", h.List)
+	og.GET("/:id", h.Get)
+	og.POST("/:id/refund", h.Refund)
+
+	return h
+}
+
+func (h *OrderHandler) List(c echo.Context) error {
+	orders, err := h.os.List(c.Request().Context())
+	return checkErrorThenResponse(c, err, orders)
+}
+
+func (h *OrderHandler) Get(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	o, err := h.os.Get(c.Request().Context(), uint(id))
+
+	return checkErrorThenResponse(c, err, o)
+}
+
+func checkErrorThenResponse(c echo.Context, err error, content interface{}) error {
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", content,
+	))
+}
@@ -0,0 +1,32 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/order/presentation/rest/requests"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+func (h *OrderHandler) Refund(c echo.Context) error {
+	req := new(requests.Refund)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	r, err := h.os.Refund(c.Request().Context(),
+		uint(id), req.Lines, req.Reason, req.Restock,
+	)
+
+	return checkErrorThenResponse(c, err, r)
+}
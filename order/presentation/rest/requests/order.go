@@ -0,0 +1,9 @@
+package requests
+
+type Refund struct {
+	Reason string `json:"reason" validate:"required,max=256"`
+	// map of order item id => count, empty means full refund
+	Lines map[uint]uint `json:"lines"`
+	// put refunded items back in stock
+	Restock bool `json:"restock"`
+}
@@ -0,0 +1,155 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Refund gives money of the order back to its buyer, lines is a map of
+// order item id => count and empty lines refunds all refundable items,
+// only admins and sellers of refunded items can do it
+func (s *Service) Refund(ctx context.Context, id uint, lines map[uint]uint, reason string, restock bool) (*domain.Refund, error) {
+	const op string = "order.service.Refund"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if u.Role != domain.ADMIN && u.Role != domain.SELLER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domain.ErrInvalidParams
+	}
+
+	o, err := s.or.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrOrderNotFound) {
+			return nil, domain.ErrOrderNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	refund := &domain.Refund{
+		OrderId: o.Id,
+		ActorId: u.Id,
+		Reason:  reason,
+		Restock: restock,
+		Items:   make([]domain.RefundItem, 0, len(o.Items)),
+	}
+
+	for _, item := range o.Items {
+		count, requested := lines[item.Id]
+		if len(lines) == 0 {
+			// full refund, sellers only refund their own items
+			if u.Role == domain.SELLER && item.SellerId != u.Id {
+				continue
+			}
+			count = item.Refundable()
+		} else if !requested {
+			continue
+		}
+		if u.Role == domain.SELLER && item.SellerId != u.Id {
+			return nil, domain.ErrPermissionDenied
+		}
+		if count > item.Refundable() {
+			return nil, errors.Wrapf(domain.ErrInvalidParams,
+				"order item %d has %d refundable items", item.Id, item.Refundable(),
+			)
+		}
+		if count == 0 {
+			continue
+		}
+		refund.Items = append(refund.Items, domain.RefundItem{
+			OrderItemId: item.Id,
+			ProductId:   item.ProductId,
+			Count:       count,
+			Amount:      count * item.Price,
+		})
+		refund.Amount += count * item.Price
+	}
+
+	// every requested line must belong to the order
+	for itemId := range lines {
+		if !hasItem(o, itemId) {
+			return nil, errors.Wrapf(domain.ErrInvalidParams, "order item %d is not in order %d", itemId, o.Id)
+		}
+	}
+
+	if len(refund.Items) == 0 {
+		return nil, domain.ErrNothingToRefund
+	}
+
+	// passing same tx object in the context
+	// when we rollback(commit) one repo,
+	// others will rollback(commit) too
+	ctx, or := s.or.BeginTransaction(ctx)
+	ctx, ur := s.ur.BeginTransaction(ctx)
+	ctx, pr := s.pr.BeginTransaction(ctx)
+
+	err = ur.AddDeposit(ctx, o.BuyerId, refund.Amount)
+	if err != nil {
+		or.Rollback()
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if restock {
+		for _, item := range refund.Items {
+			err = pr.AddStock(ctx, item.ProductId, item.Count)
+			if err != nil {
+				// product may be deleted since the sale
+				if errors.Is(err, domain.ErrProductNotFound) {
+					logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+					continue
+				}
+				or.Rollback()
+				logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+				return nil, domain.ErrInternalServer
+			}
+		}
+	}
+
+	// counts checked above may be refunded concurrently,
+	// the insert rechecks them atomically
+	refund.Id, err = or.InsertRefund(ctx, *refund)
+	if err != nil {
+		or.Rollback()
+		if errors.Is(err, domain.ErrNothingToRefund) {
+			logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+			return nil, domain.ErrNothingToRefund
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	or.Commit()
+
+	logger.Log(logger.INFO, fmt.Sprintf(
+		"%s refunded %d of order %d: %s", u.Username, refund.Amount, o.Id, reason,
+	))
+
+	return refund, nil
+}
+
+func hasItem(o *domain.Order, itemId uint) bool {
+	for _, i := range o.Items {
+		if i.Id == itemId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/order"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Refund(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		lines   map[uint]uint
+		reason  string
+		restock bool
+	}
+	type wants struct {
+		err    error
+		amount uint
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	or := new(mocks.OrderRepository)
+	ur := new(mocks.UserRepository)
+	pr := new(mocks.ProductRepository)
+
+	admin := &domain.User{Id: 1, Role: domain.ADMIN}
+	seller := &domain.User{Id: 2, Role: domain.SELLER}
+	adminContext := context.WithValue(context.Background(), domain.USER, admin)
+	sellerContext := context.WithValue(context.Background(), domain.USER, seller)
+
+	newOrder := func() *domain.Order {
+		return &domain.Order{
+			Id:      10,
+			BuyerId: 5,
+			Total:   50,
+			Items: []domain.OrderItem{
+				{Id: 1, ProductId: 1, SellerId: 2, Name: "Cake", Count: 2, Price: 5},
+				{Id: 2, ProductId: 2, SellerId: 3, Name: "Soda", Count: 4, Price: 10, Refunded: 1},
+			},
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name: "should credit buyer and restock all refundable items when admin refunds whole order",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).Return(newOrder(), nil).Once()
+
+				or.On("BeginTransaction", mock.Anything).Return(adminContext, or).Once()
+				ur.On("BeginTransaction", mock.Anything).Return(adminContext, ur).Once()
+				pr.On("BeginTransaction", mock.Anything).Return(adminContext, pr).Once()
+
+				ur.On("AddDeposit", mock.Anything, uint(5), uint(40)).Return(nil).Once()
+				pr.On("AddStock", mock.Anything, uint(1), uint(2)).Return(nil).Once()
+				pr.On("AddStock", mock.Anything, uint(2), uint(3)).Return(nil).Once()
+				or.On("InsertRefund", mock.Anything, mock.AnythingOfType("domain.Refund")).
+					Return(uint(1), nil).Once()
+
+				or.On("Commit").Once()
+			},
+			args: args{
+				ctx:     adminContext,
+				reason:  "product stuck in the machine",
+				restock: true,
+			},
+			wants: wants{
+				err:    nil,
+				amount: 40,
+			},
+		},
+		{
+			name: "should refund only requested line of own product when seller refunds",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).Return(newOrder(), nil).Once()
+
+				or.On("BeginTransaction", mock.Anything).Return(sellerContext, or).Once()
+				ur.On("BeginTransaction", mock.Anything).Return(sellerContext, ur).Once()
+				pr.On("BeginTransaction", mock.Anything).Return(sellerContext, pr).Once()
+
+				ur.On("AddDeposit", mock.Anything, uint(5), uint(5)).Return(nil).Once()
+				or.On("InsertRefund", mock.Anything, mock.AnythingOfType("domain.Refund")).
+					Return(uint(2), nil).Once()
+
+				or.On("Commit").Once()
+			},
+			args: args{
+				ctx:    sellerContext,
+				lines:  map[uint]uint{1: 1},
+				reason: "expired",
+			},
+			wants: wants{
+				err:    nil,
+				amount: 5,
+			},
+		},
+		{
+			name: "should roll back when a concurrent refund took the refundable items",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).Return(newOrder(), nil).Once()
+
+				or.On("BeginTransaction", mock.Anything).Return(adminContext, or).Once()
+				ur.On("BeginTransaction", mock.Anything).Return(adminContext, ur).Once()
+				pr.On("BeginTransaction", mock.Anything).Return(adminContext, pr).Once()
+
+				ur.On("AddDeposit", mock.Anything, uint(5), uint(10)).Return(nil).Once()
+				or.On("InsertRefund", mock.Anything, mock.AnythingOfType("domain.Refund")).
+					Return(uint(0), domain.ErrNothingToRefund).Once()
+
+				or.On("Rollback").Once()
+			},
+			args: args{
+				ctx:    adminContext,
+				lines:  map[uint]uint{2: 1},
+				reason: "expired",
+			},
+			wants: wants{
+				err: domain.ErrNothingToRefund,
+			},
+		},
+		{
+			name: "should fail when seller refunds product of another seller",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).Return(newOrder(), nil).Once()
+			},
+			args: args{
+				ctx:    sellerContext,
+				lines:  map[uint]uint{2: 1},
+				reason: "expired",
+			},
+			wants: wants{
+				err: domain.ErrPermissionDenied,
+			},
+		},
+		{
+			name: "should fail when refunding more than refundable items",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).Return(newOrder(), nil).Once()
+			},
+			args: args{
+				ctx:    adminContext,
+				lines:  map[uint]uint{2: 4},
+				reason: "expired",
+			},
+			wants: wants{
+				err: domain.ErrInvalidParams,
+			},
+		},
+		{
+			name:    "should fail when buyer refunds",
+			prepare: func() {},
+			args: args{
+				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+					Id: 5, Role: domain.BUYER,
+				}),
+				reason: "changed my mind",
+			},
+			wants: wants{
+				err: domain.ErrPermissionDenied,
+			},
+		},
+		{
+			name:    "should fail when reason is missing",
+			prepare: func() {},
+			args: args{
+				ctx:    adminContext,
+				reason: " ",
+			},
+			wants: wants{
+				err: domain.ErrInvalidParams,
+			},
+		},
+		{
+			name: "should fail when order is not found",
+			prepare: func() {
+				or.On("FindById", mock.Anything, uint(10)).
+					Return(nil, domain.ErrOrderNotFound).Once()
+			},
+			args: args{
+				ctx:    adminContext,
+				reason: "expired",
+			},
+			wants: wants{
+				err: domain.ErrOrderNotFound,
+			},
+		},
+	}
+
+	svc := order.InitService(or, ur, pr)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		refund, err := svc.Refund(tc.args.ctx, 10, tc.args.lines, tc.args.reason, tc.args.restock)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, refund, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+			assert.EqualValues(t, tc.wants.amount, refund.Amount, tc.name)
+		}
+	}
+	or.AssertExpectations(t)
+	ur.AssertExpectations(t)
+	pr.AssertExpectations(t)
+}
@@ -0,0 +1,58 @@
+package httputil_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClientIP(t *testing.T) {
+	trusted, err := httputil.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "should ignore forwarded headers of untrusted peers",
+			remote:  "203.0.113.7:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"},
+			want:    "203.0.113.7",
+		},
+		{
+			name:    "should skip trusted proxies in X-Forwarded-For from the right",
+			remote:  "10.0.0.2:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.9, 192.168.1.1"},
+			want:    "198.51.100.9",
+		},
+		{
+			name:    "should use X-Real-IP of a trusted proxy",
+			remote:  "192.168.1.1:4000",
+			headers: map[string]string{"X-Real-IP": "198.51.100.9"},
+			want:    "198.51.100.9",
+		},
+		{
+			name:   "should use the peer when a trusted proxy forwards nothing",
+			remote: "10.0.0.2:4000",
+			want:   "10.0.0.2",
+		},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("POST", "/login", nil)
+		r.RemoteAddr = tc.remote
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		assert.Equal(t, tc.want, httputil.ClientIP(r, trusted), tc.name)
+	}
+
+	_, err = httputil.ParseTrustedProxies([]string{"proxy"})
+	assert.Error(t, err)
+}
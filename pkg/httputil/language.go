@@ -0,0 +1,52 @@
+package httputil
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+// ParseAcceptLanguage returns languages of Accept-Language header
+// ordered by their quality, e.g. "fa-IR,fa;q=0.9,en;q=0.8" gives
+// [fa-ir fa en]. Wildcard, invalid and q=0 entries are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	ws := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang, ok := domain.NormalizeLang(fields[0])
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		ws = append(ws, weighted{lang: lang, q: q})
+	}
+	sort.SliceStable(ws, func(i, j int) bool {
+		return ws[i].q > ws[j].q
+	})
+
+	langs := make([]string, len(ws))
+	for i, w := range ws {
+		langs[i] = w.lang
+	}
+	return langs
+}
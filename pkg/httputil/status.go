@@ -5,6 +5,7 @@ import (
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/labstack/echo"
+	"github.com/pkg/errors"
 )
 
 func StatusCode(err error) int {
@@ -14,17 +15,33 @@ func StatusCode(err error) int {
 	if httpErr, ok := err.(*echo.HTTPError); ok {
 		return httpErr.Code
 	}
-	switch err {
-	case domain.ErrWrongCredentials, domain.ErrInvalidToken, domain.ErrUnauthorized:
+	// services may wrap domain errors to add details
+	is := func(targets ...error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case is(domain.ErrWrongCredentials, domain.ErrInvalidToken, domain.ErrUnauthorized):
 		return http.StatusUnauthorized
-	case domain.ErrPermissionDenied:
+	case is(domain.ErrPermissionDenied, domain.ErrUserDisabled, domain.ErrTwoFactorRequired):
 		return http.StatusForbidden
-	case domain.ErrInvalidParams:
+	case is(domain.ErrInvalidParams, domain.ErrInvalidCost, domain.ErrWeakPassword):
 		return http.StatusBadRequest
-	case domain.ErrUserNotFound, domain.ErrProductNotFound:
+	case is(domain.ErrUserNotFound, domain.ErrProductNotFound, domain.ErrReservationNotFound,
+		domain.ErrOrderNotFound, domain.ErrSessionNotFound, domain.ErrTwoFactorNotFound):
 		return http.StatusNotFound
-	case domain.ErrInsufficientProductsAmount, domain.ErrInsufficientBalance:
+	case is(domain.ErrUserAlreadyExists, domain.ErrRestoreConflict, domain.ErrDuplicateSku,
+		domain.ErrTwoFactorEnabled):
+		return http.StatusConflict
+	case is(domain.ErrInsufficientProductsAmount, domain.ErrInsufficientBalance,
+		domain.ErrDietaryConflict, domain.ErrNothingToRefund, domain.ErrQuotaExceeded):
 		return http.StatusUnprocessableEntity
+	case is(domain.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
@@ -3,6 +3,7 @@ package httputil
 import (
 	"net/http"
 
+	"github.com/apm-dev/vending-machine/domain"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo"
 )
@@ -11,8 +12,13 @@ type CustomValidator struct {
 	validator *validator.Validate
 }
 
-func InitCustomValidator() *CustomValidator{
-	return &CustomValidator{validator: validator.New()}
+func InitCustomValidator() *CustomValidator {
+	v := validator.New()
+	// unicode aware alternative of alphanum for product names
+	_ = v.RegisterValidation("productname", func(fl validator.FieldLevel) bool {
+		return domain.ValidProductName(fl.Field().String())
+	})
+	return &CustomValidator{validator: v}
 }
 
 func (cv *CustomValidator) Validate(i interface{}) error {
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	Bcrypt   = "bcrypt"
+	Argon2id = "argon2id"
+)
+
+// BcryptMaxBytes is the longest password bcrypt hashes, bytes after it are ignored
+const BcryptMaxBytes = 72
+
+// Argon2Params of argon2id hashes, memory is in KiB
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// DefaultArgon2Params are the recommended parameters of RFC 9106 for low memory
+var DefaultArgon2Params = Argon2Params{
+	Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32, SaltLen: 16,
+}
+
+// Hasher hashes passwords with the configured algorithm and verifies hashes of
+// any supported algorithm, hashes carry their parameters (PHC/modular format)
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+func NewHasher(algorithm string, bcryptCost int, argon2 Argon2Params) (*Hasher, error) {
+	const op string = "pkg.password.NewHasher"
+
+	switch algorithm {
+	case Bcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, errors.Errorf("%s: invalid bcrypt cost %d", op, bcryptCost)
+		}
+	case Argon2id:
+		if argon2.Time == 0 || argon2.Memory == 0 || argon2.Threads == 0 ||
+			argon2.KeyLen == 0 || argon2.SaltLen == 0 {
+			return nil, errors.Errorf("%s: invalid argon2id parameters %+v", op, argon2)
+		}
+	default:
+		return nil, errors.Errorf("%s: unknown algorithm %q", op, algorithm)
+	}
+	return &Hasher{algorithm: algorithm, bcryptCost: bcryptCost, argon2: argon2}, nil
+}
+
+// Default hashes with bcrypt of default cost, which is what users had before hashers
+func Default() *Hasher {
+	return &Hasher{algorithm: Bcrypt, bcryptCost: bcrypt.DefaultCost, argon2: DefaultArgon2Params}
+}
+
+func (h *Hasher) Hash(passwd string) (string, error) {
+	const op string = "pkg.password.Hasher.Hash"
+
+	if h.algorithm == Argon2id {
+		salt := make([]byte, h.argon2.SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", errors.Wrap(err, op)
+		}
+		p := h.argon2
+		key := argon2.IDKey([]byte(passwd), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, p.Memory, p.Time, p.Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(passwd), h.bcryptCost)
+	if err != nil {
+		return "", errors.Wrap(err, op)
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether passwd matches the hash and whether the hash
+// should be replaced, because it's of another algorithm or parameters
+func (h *Hasher) Verify(hash, passwd string) (ok, rehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(passwd), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false
+		}
+		return true, h.algorithm != Argon2id || p != h.argon2
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(passwd)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, err != nil || h.algorithm != Bcrypt || cost != h.bcryptCost
+}
+
+func decodeArgon2id(hash string) (p Argon2Params, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, err
+	}
+	if version != argon2.Version {
+		return p, nil, nil, errors.Errorf("unsupported argon2 version %d", version)
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, err
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, err
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, err
+	}
+	p.SaltLen, p.KeyLen = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,94 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/apm-dev/vending-machine/pkg/password"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHasherVerify(t *testing.T) {
+	type testCase struct {
+		name   string
+		hashBy *password.Hasher
+		passwd string
+		ok     bool
+		rehash bool
+	}
+
+	params := password.Argon2Params{Time: 1, Memory: 1024, Threads: 1, KeyLen: 16, SaltLen: 8}
+	stronger := params
+	stronger.Time = 2
+
+	newHasher := func(algorithm string, cost int, p password.Argon2Params) *password.Hasher {
+		h, err := password.NewHasher(algorithm, cost, p)
+		require.NoError(t, err)
+		return h
+	}
+	// verifier is configured with argon2id
+	verifier := newHasher(password.Argon2id, bcrypt.MinCost, params)
+
+	testCases := []testCase{
+		{
+			name:   "current argon2id hash",
+			hashBy: verifier,
+			passwd: "s3cret-pass",
+			ok:     true,
+		},
+		{
+			name:   "wrong password",
+			hashBy: verifier,
+			passwd: "s3cret-pass",
+			ok:     false,
+		},
+		{
+			name:   "argon2id hash of other params is rehashed",
+			hashBy: newHasher(password.Argon2id, bcrypt.MinCost, stronger),
+			passwd: "s3cret-pass",
+			ok:     true,
+			rehash: true,
+		},
+		{
+			name:   "bcrypt hash is rehashed",
+			hashBy: newHasher(password.Bcrypt, bcrypt.MinCost, params),
+			passwd: "s3cret-pass",
+			ok:     true,
+			rehash: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := tc.hashBy.Hash(tc.passwd)
+			require.NoError(t, err)
+
+			passwd := tc.passwd
+			if !tc.ok {
+				passwd += "x"
+			}
+			ok, rehash := verifier.Verify(hash, passwd)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.rehash, rehash)
+		})
+	}
+}
+
+func TestHasherVerifyBcryptCost(t *testing.T) {
+	old, err := password.NewHasher(password.Bcrypt, bcrypt.MinCost, password.Argon2Params{})
+	require.NoError(t, err)
+	current, err := password.NewHasher(password.Bcrypt, bcrypt.MinCost+1, password.Argon2Params{})
+	require.NoError(t, err)
+
+	hash, err := old.Hash("s3cret-pass")
+	require.NoError(t, err)
+
+	ok, rehash := current.Verify(hash, "s3cret-pass")
+	assert.True(t, ok)
+	assert.True(t, rehash)
+
+	ok, rehash = old.Verify(hash, "s3cret-pass")
+	assert.True(t, ok)
+	assert.False(t, rehash)
+}
@@ -0,0 +1,35 @@
+package password
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ReadList reads a password list file, one password per line, lines are
+// lower cased and empty lines or lines starting with # are skipped
+func ReadList(path string) (map[string]struct{}, error) {
+	const op string = "pkg.password.ReadList"
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	defer f.Close()
+
+	list := make(map[string]struct{})
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list[strings.ToLower(line)] = struct{}{}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	return list, nil
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/labstack/echo"
+	"github.com/pkg/errors"
+)
+
+// Rule limits routes which start with any of Prefixes, requests of
+// a rule are counted together by user or by ip of anonymous clients
+type Rule struct {
+	Name     string
+	Prefixes []string
+	Limit
+}
+
+func (r *Rule) matches(path string) bool {
+	for _, p := range r.Prefixes {
+		if p == "*" || strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware applies the first rule which matches the route of request,
+// it should run after the subject of token is set so users aren't limited by ip.
+// Forwarded ips of anonymous clients are honoured only from trusted proxies
+func Middleware(store Store, rules []Rule, trustedProxies []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			const op string = "pkg.ratelimit.Middleware"
+
+			rule := match(rules, c.Path())
+			if rule == nil {
+				return next(c)
+			}
+
+			res, err := store.Take(c.Request().Context(), key(c, rule, trustedProxies), rule.Limit, time.Now())
+			if err != nil {
+				// failing store shouldn't take the api down
+				logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("X-RateLimit-Limit", strconv.FormatUint(uint64(rule.Requests), 10))
+			header.Set("X-RateLimit-Remaining", strconv.FormatUint(uint64(res.Remaining), 10))
+			if !res.Allowed {
+				retry := int(math.Ceil(res.RetryAfter.Seconds()))
+				header.Set("Retry-After", strconv.Itoa(retry))
+				return c.JSON(http.StatusTooManyRequests, httputil.MakeResponse(
+					http.StatusTooManyRequests,
+					fmt.Sprintf("too many requests, retry in %ds", retry),
+					nil,
+				))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func match(rules []Rule, path string) *Rule {
+	for i := range rules {
+		if rules[i].Requests > 0 && rules[i].Window > 0 && rules[i].matches(path) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func key(c echo.Context, rule *Rule, trustedProxies []*net.IPNet) string {
+	if id, ok := c.Request().Context().Value(domain.SUBJECT).(uint); ok {
+		return fmt.Sprintf("%s:user:%d", rule.Name, id)
+	}
+	if u, err := domain.UserFromContext(c.Request().Context()); err == nil {
+		return fmt.Sprintf("%s:user:%d", rule.Name, u.Id)
+	}
+	return fmt.Sprintf("%s:ip:%s", rule.Name, httputil.ClientIP(c.Request(), trustedProxies))
+}
@@ -0,0 +1,111 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/pkg/ratelimit"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	type testCase struct {
+		name      string
+		path      string
+		user      uint
+		ip        string
+		forwarded string
+		status    int
+	}
+
+	trusted, err := httputil.ParseTrustedProxies([]string{"10.0.0.1"})
+	require.NoError(t, err)
+
+	e := echo.New()
+	// sets subject like JwtSubject does
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id, err := strconv.Atoi(c.Request().Header.Get("X-User")); err == nil {
+				ctx := context.WithValue(c.Request().Context(), domain.SUBJECT, uint(id))
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+			return next(c)
+		}
+	})
+	e.Use(ratelimit.Middleware(ratelimit.NewMemoryStore(), []ratelimit.Rule{
+		{Name: "login", Prefixes: []string{"/login"}, Limit: ratelimit.Limit{Requests: 2, Window: time.Minute}},
+		{Name: "default", Prefixes: []string{"*"}, Limit: ratelimit.Limit{Requests: 1, Window: time.Hour}},
+	}, trusted))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/login", ok)
+	e.POST("/deposit", ok)
+
+	testCases := []testCase{
+		{name: "first login of ip", path: "/login", ip: "1.1.1.1", status: http.StatusOK},
+		{name: "second login of ip", path: "/login", ip: "1.1.1.1", status: http.StatusOK},
+		{name: "third login of ip is limited", path: "/login", ip: "1.1.1.1", status: http.StatusTooManyRequests},
+		{name: "other ip has its own limit", path: "/login", ip: "2.2.2.2", status: http.StatusOK},
+		{name: "forwarded ip of untrusted peer is ignored", path: "/login", ip: "2.2.2.2", forwarded: "4.4.4.4", status: http.StatusOK},
+		{name: "spoofing forwarded ip doesn't reset the limit", path: "/login", ip: "2.2.2.2", forwarded: "5.5.5.5", status: http.StatusTooManyRequests},
+		{name: "forwarded ip of trusted proxy is honoured", path: "/login", ip: "10.0.0.1", forwarded: "1.1.1.1", status: http.StatusTooManyRequests},
+		{name: "routes of other rules have their own limit", path: "/deposit", ip: "1.1.1.1", status: http.StatusOK},
+		{name: "user is limited by id", path: "/deposit", user: 1, ip: "1.1.1.1", status: http.StatusOK},
+		{name: "user is limited on another ip", path: "/deposit", user: 1, ip: "3.3.3.3", status: http.StatusTooManyRequests},
+		{name: "other users aren't limited", path: "/deposit", user: 2, ip: "3.3.3.3", status: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+		req.RemoteAddr = tc.ip + ":4000"
+		if tc.forwarded != "" {
+			req.Header.Set(echo.HeaderXRealIP, tc.forwarded)
+		}
+		if tc.user > 0 {
+			req.Header.Set("X-User", strconv.Itoa(int(tc.user)))
+		}
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, tc.status, rec.Code, tc.name)
+		if tc.status != http.StatusTooManyRequests {
+			continue
+		}
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"), tc.name)
+		var res httputil.BaseResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res), tc.name)
+		assert.Equal(t, http.StatusTooManyRequests, res.Code, tc.name)
+	}
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Requests: 2, Window: 10 * time.Second}
+	now := time.Now()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := store.Take(ctx, "k", limit, now)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := store.Take(ctx, "k", limit, now)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	// one request refills every 5 seconds
+	assert.Equal(t, 5*time.Second, res.RetryAfter)
+
+	res, err = store.Take(ctx, "k", limit, now.Add(5*time.Second))
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
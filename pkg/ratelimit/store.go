@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests in every Window, requests refill evenly
+// through the window so bursts up to Requests are allowed
+type Limit struct {
+	Requests uint
+	Window   time.Duration
+}
+
+// Result of taking a request from the limit of a key
+type Result struct {
+	Allowed   bool
+	Remaining uint
+	// RetryAfter is the wait until the next request is allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps state of limits, the in-memory store suits a single
+// instance, instances behind a load balancer need a shared store
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// idle time of the bucket after which it's full again
+	full time.Duration
+}
+
+// MemoryStore is a token bucket store kept in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	// time to refill one request
+	refill := limit.Window / time.Duration(limit.Requests)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now, full: limit.Window}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(refill)
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) * float64(refill))
+		return Result{Allowed: false, RetryAfter: wait}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: uint(b.tokens)}, nil
+}
+
+// sweep drops buckets which are full again, at most once a minute,
+// so keys of one-off clients don't pile up
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) >= b.full {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Package totp implements time-based one-time passwords (RFC 6238) with the
+// defaults authenticator apps support: HMAC-SHA1, 6 digits and 30 seconds steps
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Digits = 6
+	Period = 30 * time.Second
+	// secretSize is the recommended key length of HMAC-SHA1
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random base32 encoded secret
+func GenerateSecret() (string, error) {
+	const op string = "pkg.totp.GenerateSecret"
+
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, op)
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// URI returns the otpauth:// provisioning uri of the secret,
+// authenticator apps scan it when it's rendered as a QR code
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(int(Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// Step returns the time step (counter) of t
+func Step(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period/time.Second))
+}
+
+// Code returns the code of the secret at t
+func Code(secret string, t time.Time) (string, error) {
+	const op string = "pkg.totp.Code"
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", errors.Wrap(err, op)
+	}
+	return hotp(key, Step(t)), nil
+}
+
+// Validate reports whether code is the code of the secret at t or of skew steps
+// around it (clock drift), the matching step is returned so callers can
+// reject reusing a code
+func Validate(secret, code string, t time.Time, skew uint) (uint64, bool) {
+	key, err := decodeSecret(secret)
+	if err != nil || len(code) != Digits {
+		return 0, false
+	}
+
+	now := Step(t)
+	for i := -int64(skew); i <= int64(skew); i++ {
+		step := uint64(int64(now) + i)
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hotp is the HOTP (RFC 4226) code of the counter
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	// last 6 (Digits) decimal digits
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(secret, "="))
+}
@@ -0,0 +1,71 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secret of the RFC 6238 test vectors ("12345678901234567890")
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA1, last 6 digits
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		code, err := totp.Code(rfcSecret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tc.code, code, tc.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := totp.Code(rfcSecret, now)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		code string
+		at   time.Time
+		ok   bool
+	}{
+		{name: "code of the step", code: code, at: now, ok: true},
+		{name: "code of the previous step within skew", code: code, at: now.Add(totp.Period), ok: true},
+		{name: "code out of skew", code: code, at: now.Add(2 * totp.Period), ok: false},
+		{name: "wrong code", code: "000000", at: now, ok: false},
+		{name: "malformed code", code: "12345", at: now, ok: false},
+	}
+
+	for _, tc := range testCases {
+		step, ok := totp.Validate(rfcSecret, tc.code, tc.at, 1)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		if tc.ok {
+			assert.Equal(t, totp.Step(now), step, tc.name)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	uri := totp.URI("Vending Machine", "bob", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Vending%20Machine:bob?"), uri)
+	assert.Contains(t, uri, "secret="+secret)
+	assert.Contains(t, uri, "issuer=Vending+Machine")
+}
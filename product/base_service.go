@@ -3,6 +3,7 @@ package product
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/logger"
@@ -12,18 +13,35 @@ import (
 type Service struct {
 	pr domain.ProductRepository
 	ur domain.UserRepository
-	pl sync.RWMutex
+	rr domain.ReservationRepository
+	or domain.OrderRepository
+	qr domain.QuotaRepository
+	d  domain.Dispenser
+	// maximum reservation ttl
+	rttl time.Duration
+	// language of product names and descriptions without translation
+	lang string
+	pl   sync.RWMutex
 }
 
-func InitService(pr domain.ProductRepository, ur domain.UserRepository) domain.ProductService {
-	return &Service{pr: pr, ur: ur}
+func InitService(
+	pr domain.ProductRepository,
+	ur domain.UserRepository,
+	rr domain.ReservationRepository,
+	or domain.OrderRepository,
+	qr domain.QuotaRepository,
+	d domain.Dispenser,
+	rttl time.Duration,
+	lang string,
+) domain.ProductService {
+	return &Service{pr: pr, ur: ur, rr: rr, or: or, qr: qr, d: d, rttl: rttl, lang: lang}
 }
 
-func (s *Service) Add(ctx context.Context, name string, amount uint, cost uint) (*domain.Product, error) {
+func (s *Service) Add(ctx context.Context, in domain.ProductInput) (*domain.Product, error) {
 	const op string = "product.service.Add"
 
-	if cost%5 != 0 {
-		return nil, domain.ErrInvalidCost
+	if err := in.Validate(); err != nil {
+		return nil, err
 	}
 	cu, err := domain.UserFromContext(ctx)
 	if err != nil {
@@ -35,18 +53,39 @@ func (s *Service) Add(ctx context.Context, name string, amount uint, cost uint)
 		return nil, domain.ErrPermissionDenied
 	}
 
-	p := domain.NewProduct(name, amount, cost, cu.Id)
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	if err := s.checkSku(ctx, cu.Id, 0, in.Sku); err != nil {
+		return nil, err
+	}
+
+	p := domain.NewProduct(in.Name, in.Count, in.Price, cu.Id)
+	p.Sku = in.Sku
+	p.Description = in.Description
+	p.Category = in.Category
 
-	p.Id, err = s.pr.Insert(ctx, *p)
+	ctx, pr := s.pr.BeginTransaction(ctx)
+
+	p.Id, err = pr.Insert(ctx, *p)
+	if err != nil {
+		pr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	// initial price starts the history
+	err = recordPrice(ctx, pr, p, 0, cu.Id)
 	if err != nil {
+		pr.Rollback()
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return nil, domain.ErrInternalServer
 	}
 
+	pr.Commit()
 	return p, nil
 }
 
-func (s *Service) List(ctx context.Context) ([]domain.Product, error) {
+func (s *Service) List(ctx context.Context, opts domain.ListOptions) ([]domain.Product, error) {
 	const op string = "product.service.List"
 
 	s.pl.RLock()
@@ -58,12 +97,57 @@ func (s *Service) List(ctx context.Context) ([]domain.Product, error) {
 		return nil, domain.ErrInternalServer
 	}
 
-	return ps, nil
+	// listing is public, caller is known only when a token is sent
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		u = &domain.User{}
+	}
+
+	// stock reserved by other buyers is not available to the caller
+	held, err := s.rr.HeldCounts(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	checkDiet := u.Role == domain.BUYER && !u.Diet.IsEmpty()
+
+	// translations are only needed when caller asks for a language
+	var ts map[uint]map[string]domain.Translation
+	if len(opts.Langs) > 0 && len(ps) > 0 {
+		ids := make([]uint, len(ps))
+		for i, p := range ps {
+			ids[i] = p.Id
+		}
+		ts, err = s.pr.ListTranslations(ctx, ids)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrInternalServer
+		}
+	}
+
+	result := make([]domain.Product, 0, len(ps))
+	for _, p := range ps {
+		p.Count = available(&p, held)
+		s.localize(&p, ts[p.Id], opts.Langs)
+		if checkDiet {
+			p.Conflicts = u.Diet.Conflicts(&p)
+			if len(p.Conflicts) > 0 && opts.HideConflicts {
+				continue
+			}
+		}
+		result = append(result, p)
+	}
+
+	return result, nil
 }
 
-func (s *Service) Update(ctx context.Context, id uint, name string, amount, cost uint) (*domain.Product, error) {
+func (s *Service) Update(ctx context.Context, id uint, in domain.ProductInput) (*domain.Product, error) {
 	const op string = "product.service.Update"
 
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
 	u, err := domain.UserFromContext(ctx)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
@@ -87,15 +171,73 @@ func (s *Service) Update(ctx context.Context, id uint, name string, amount, cost
 		return nil, domain.ErrPermissionDenied
 	}
 
-	p.Name = name
-	p.Count = amount
-	p.Price = cost
+	if err := s.checkSku(ctx, u.Id, p.Id, in.Sku); err != nil {
+		return nil, err
+	}
+
+	oldPrice := p.Price
+	p.Sku = in.Sku
+	p.Name = in.Name
+	p.Description = in.Description
+	p.Category = in.Category
+	p.Count = in.Count
+	p.Price = in.Price
+
+	ctx, pr := s.pr.BeginTransaction(ctx)
+
+	err = pr.Update(ctx, p)
+	if err != nil {
+		pr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	err = recordPrice(ctx, pr, p, oldPrice, u.Id)
+	if err != nil {
+		pr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	pr.Commit()
+	return p, nil
+}
+
+func (s *Service) UpdateDietInfo(ctx context.Context, id uint, info domain.DietInfo) (*domain.Product, error) {
+	const op string = "product.service.UpdateDietInfo"
+
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	// only sellers maintain labelling of products
+	if u.Role != domain.SELLER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	p, err := s.pr.FindById(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrProductNotFound
+	}
+	// only related seller can update it
+	if p.SellerId != u.Id {
+		return nil, domain.ErrPermissionDenied
+	}
 
-	err = s.pr.Update(ctx, p)
+	err = s.pr.UpdateDietInfo(ctx, p.Id, info)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return nil, domain.ErrInternalServer
 	}
+	p.DietInfo = info
 
 	return p, nil
 }
@@ -135,3 +277,26 @@ func (s *Service) Delete(ctx context.Context, id uint) error {
 
 	return nil
 }
+
+// checkSku makes sure sku is not used by another product of the seller
+func (s *Service) checkSku(ctx context.Context, sellerId, productId uint, sku string) error {
+	const op string = "product.service.checkSku"
+
+	if sku == "" {
+		return nil
+	}
+
+	p, err := s.pr.FindBySellerAndSku(ctx, sellerId, sku)
+	if err != nil {
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	if p.Id != productId {
+		return errors.Wrapf(domain.ErrDuplicateSku, "%q is used by product %d", sku, p.Id)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package product
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/algo"
@@ -9,7 +11,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (s *Service) Buy(ctx context.Context, cart map[uint]uint) (*domain.Bill, error) {
+func (s *Service) Buy(ctx context.Context, cart map[uint]uint, opts domain.BuyOptions) (*domain.Bill, error) {
 	const op string = "product.service.Buy"
 
 	u, err := domain.UserFromContext(ctx)
@@ -22,37 +24,170 @@ func (s *Service) Buy(ctx context.Context, cart map[uint]uint) (*domain.Bill, er
 		return nil, domain.ErrPermissionDenied
 	}
 
+	// the purchase is committed before dispensing, so dropped items
+	// are always paid and recorded, hardware runs without the lock
+	planned, skipped, orderId, err := s.charge(ctx, u, cart, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	deposit := u.Deposit
+	items := make([]domain.Item, 0, len(planned))
+	failed := make(map[uint]uint)
+	var totalPrice uint
+	for _, pi := range planned {
+		p := pi.product
+		deposit -= pi.count * p.Price
+		dispensed := s.dispense(ctx, p.Id, pi.count)
+		items = append(items, domain.Item{
+			ProductId: p.Id,
+			Name:      p.Name,
+			Count:     pi.count,
+			Dispensed: dispensed,
+			Price:     dispensed * p.Price,
+		})
+		totalPrice += dispensed * p.Price
+		if dispensed < pi.count {
+			failed[p.Id] = pi.count - dispensed
+		}
+	}
+
+	// buyer only pays for items which physically dropped
+	if len(failed) > 0 {
+		refunded, err := s.refundUndispensed(ctx, u, orderId, failed)
+		if err != nil {
+			// the order and the audit log have what's needed to refund it by hand
+			logger.Log(logger.ERROR, errors.Wrapf(err, "%s: order %d is charged for undispensed items %v",
+				op, orderId, failed).Error())
+			totalPrice = 0
+			for _, pi := range planned {
+				totalPrice += pi.count * pi.product.Price
+			}
+		} else {
+			deposit += refunded
+		}
+	}
+
+	bill := &domain.Bill{
+		OrderId:    orderId,
+		TotalSpent: totalPrice,
+		Items:      items,
+		Skipped:    skipped,
+		// calculating remaining user deposit by valid coins
+		Refund: algo.MinimumNumberOfElementsWhoseSumIs(domain.Coins, deposit),
+	}
+	return bill, nil
+}
+
+// charge checks the cart and in one transaction takes stock, charges the
+// buyer and records the order of planned items, which are yet to be dispensed
+func (s *Service) charge(ctx context.Context, u *domain.User, cart map[uint]uint, opts domain.BuyOptions) (
+	[]plannedItem, []domain.SkippedItem, uint, error,
+) {
+	const op string = "product.service.charge"
+
 	s.pl.Lock()
 	defer s.pl.Unlock()
 
-	products := make([]domain.Product, 0, len(cart))
-	items := make([]domain.Item, 0, len(products))
-	var totalPrice uint
+	// stock reserved by other buyers is not available
+	held, err := s.rr.HeldCounts(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, nil, 0, domain.ErrInternalServer
+	}
+
+	quotas, err := s.quotasOf(ctx, u.Id, time.Now())
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	planned := make([]plannedItem, 0, len(cart))
+	var skipped []domain.SkippedItem
+	var plannedPrice uint
+
+	// in partial mode failed checks skip (part of) the product,
+	// otherwise whole purchase fails naming the product
+	check := func(pid uint, name string, count uint, err error) error {
+		if !opts.Partial {
+			return errors.Wrapf(err, "product %d", pid)
+		}
+		skipped = append(skipped, domain.SkippedItem{
+			ProductId: pid,
+			Name:      name,
+			Count:     count,
+			Reason:    err.Error(),
+		})
+		return nil
+	}
 
-	for pid, count := range cart {
+	for _, pid := range cartProductIds(cart) {
+		count := cart[pid]
 		p, err := s.pr.FindById(ctx, pid)
 		if err != nil {
 			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-			return nil, domain.ErrProductNotFound
+			if err = check(pid, "", count, domain.ErrProductNotFound); err != nil {
+				return nil, nil, 0, err
+			}
+			continue
+		}
+		// refuse products which buyer can't eat in strict mode
+		if opts.StrictDiet && len(u.Diet.Conflicts(p)) > 0 {
+			if err = check(pid, p.Name, count, domain.ErrDietaryConflict); err != nil {
+				return nil, nil, 0, err
+			}
+			continue
 		}
 		// check product availability
-		if p.Count < count {
-			return nil, domain.ErrInsufficientProductsAmount
+		if a := available(p, held); a < count {
+			if err = check(pid, p.Name, count-a, domain.ErrInsufficientProductsAmount); err != nil {
+				return nil, nil, 0, err
+			}
+			count = a
 		}
-		// decrease product amount
-		p.Count -= count
-		products = append(products, *p)
-		items = append(items, domain.Item{
-			Name:  p.Name,
-			Count: count,
-			Price: count * p.Price,
-		})
-		// increase total price
-		totalPrice += count * p.Price
 		// check user balance
-		if u.Deposit < totalPrice {
-			return nil, domain.ErrInsufficientBalance
+		if p.Price > 0 {
+			if affordable := (u.Deposit - plannedPrice) / p.Price; affordable < count {
+				if err = check(pid, p.Name, count-affordable, domain.ErrInsufficientBalance); err != nil {
+					return nil, nil, 0, err
+				}
+				count = affordable
+			}
+		}
+		// respect purchase quotas of the buyer
+		if allowed, err := quotas.allow(ctx, p, count); err != nil {
+			if !errors.Is(err, domain.ErrQuotaExceeded) {
+				return nil, nil, 0, err
+			}
+			if err = check(pid, p.Name, count-allowed, err); err != nil {
+				return nil, nil, 0, err
+			}
+			count = allowed
+		}
+		if count == 0 {
+			continue
 		}
+		quotas.use(p, count)
+		planned = append(planned, plannedItem{product: p, count: count})
+		plannedPrice += count * p.Price
+	}
+
+	// nothing could be fulfilled in partial mode
+	if len(planned) == 0 {
+		return planned, skipped, 0, nil
+	}
+
+	orderItems := make([]domain.OrderItem, 0, len(planned))
+	bought := make(map[uint]uint, len(planned))
+	for _, pi := range planned {
+		p := pi.product
+		bought[p.Id] = pi.count
+		orderItems = append(orderItems, domain.OrderItem{
+			ProductId: p.Id,
+			SellerId:  p.SellerId,
+			Name:      p.Name,
+			Count:     pi.count,
+			Price:     p.Price,
+		})
 	}
 
 	// passing same tx object in the context
@@ -60,32 +195,140 @@ func (s *Service) Buy(ctx context.Context, cart map[uint]uint) (*domain.Bill, er
 	// another will rollback(commit) too
 	ctx, pr := s.pr.BeginTransaction(ctx)
 	ctx, ur := s.ur.BeginTransaction(ctx)
+	ctx, rr := s.rr.BeginTransaction(ctx)
+	ctx, or := s.or.BeginTransaction(ctx)
 
-	for _, p := range products {
-		err = pr.Update(ctx, &p)
+	// stock and deposit are changed atomically in sql, so deposits,
+	// resets and refunds which don't hold the lock aren't overwritten
+	for _, pi := range planned {
+		err = pr.TakeStock(ctx, pi.product.Id, pi.count)
 		if err != nil {
 			pr.Rollback()
+			if errors.Is(err, domain.ErrInsufficientProductsAmount) {
+				return nil, nil, 0, errors.Wrapf(domain.ErrInsufficientProductsAmount, "product %d", pi.product.Id)
+			}
 			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-			return nil, domain.ErrInternalServer
+			return nil, nil, 0, domain.ErrInternalServer
 		}
 	}
-
-	u.Deposit -= totalPrice
-	err = ur.Update(ctx, u)
+	err = ur.SpendDeposit(ctx, u.Id, plannedPrice)
 	if err != nil {
 		ur.Rollback()
+		if errors.Is(err, domain.ErrInsufficientBalance) {
+			return nil, nil, 0, domain.ErrInsufficientBalance
+		}
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return nil, domain.ErrInternalServer
+		return nil, nil, 0, domain.ErrInternalServer
+	}
+	// persist the purchase for refunds and reports
+	orderId, err := or.Insert(ctx, domain.Order{
+		BuyerId: u.Id,
+		Total:   plannedPrice,
+		Items:   orderItems,
+	})
+	if err != nil {
+		or.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, nil, 0, domain.ErrInternalServer
+	}
+	// purchase consumes the stock hold of buyer
+	err = consumeReservation(ctx, rr, u.Id, bought)
+	if err != nil {
+		rr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, nil, 0, domain.ErrInternalServer
 	}
-	// calculating remaining user deposit by valid coins
-	refund := algo.MinimumNumberOfElementsWhoseSumIs(domain.Coins, u.Deposit)
 
 	// there is no difference to call pr.Commit()
 	// they are in the same transaction
 	ur.Commit()
-	return &domain.Bill{
-		TotalSpent: totalPrice,
-		Items:      items,
-		Refund:     refund,
-	}, nil
+	return planned, skipped, orderId, nil
+}
+
+// refundUndispensed gives money of items which didn't drop back and puts
+// them back in stock, it's recorded as a refund of the order by the buyer
+func (s *Service) refundUndispensed(ctx context.Context, u *domain.User, orderId uint, failed map[uint]uint) (uint, error) {
+	const op string = "product.service.refundUndispensed"
+
+	o, err := s.or.FindById(ctx, orderId)
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	refund := domain.Refund{
+		OrderId: orderId,
+		ActorId: u.Id,
+		Reason:  domain.ErrDispenseFailed.Error(),
+		Restock: true,
+		Items:   make([]domain.RefundItem, 0, len(failed)),
+	}
+	for _, item := range o.Items {
+		count := failed[item.ProductId]
+		if count == 0 {
+			continue
+		}
+		refund.Items = append(refund.Items, domain.RefundItem{
+			OrderItemId: item.Id,
+			ProductId:   item.ProductId,
+			Count:       count,
+			Amount:      count * item.Price,
+		})
+		refund.Amount += count * item.Price
+	}
+
+	ctx, or := s.or.BeginTransaction(ctx)
+	ctx, ur := s.ur.BeginTransaction(ctx)
+	ctx, pr := s.pr.BeginTransaction(ctx)
+
+	if refund.Amount > 0 {
+		if err = ur.AddDeposit(ctx, u.Id, refund.Amount); err != nil {
+			or.Rollback()
+			return 0, errors.Wrap(err, op)
+		}
+	}
+	for _, item := range refund.Items {
+		if err = pr.AddStock(ctx, item.ProductId, item.Count); err != nil {
+			or.Rollback()
+			return 0, errors.Wrap(err, op)
+		}
+	}
+	if _, err = or.InsertRefund(ctx, refund); err != nil {
+		or.Rollback()
+		return 0, errors.Wrap(err, op)
+	}
+
+	or.Commit()
+	return refund.Amount, nil
+}
+
+// cartProductIds returns sorted product ids of the cart,
+// so products are checked and billed in a stable order
+func cartProductIds(cart map[uint]uint) []uint {
+	ids := make([]uint, 0, len(cart))
+	for pid := range cart {
+		ids = append(ids, pid)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+type plannedItem struct {
+	product *domain.Product
+	count   uint
+}
+
+// dispense drops count items of the product one by one and returns
+// the number of dropped ones, it stops on first failure since a jammed
+// slot won't drop the rest either
+func (s *Service) dispense(ctx context.Context, pid, count uint) uint {
+	const op string = "product.service.dispense"
+
+	var dispensed uint
+	for ; dispensed < count; dispensed++ {
+		if err := s.d.Dispense(ctx, pid); err != nil {
+			logger.Log(logger.WARN, errors.Wrapf(err, "%s: product %d", op, pid).Error())
+			break
+		}
+	}
+	return dispensed
 }
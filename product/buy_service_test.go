@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/domain/mocks"
@@ -16,6 +17,7 @@ func Test_Service_Buy(t *testing.T) {
 	type args struct {
 		ctx  context.Context
 		cart map[uint]uint
+		opts domain.BuyOptions
 	}
 	type wants struct {
 		err  error
@@ -30,6 +32,15 @@ func Test_Service_Buy(t *testing.T) {
 
 	pr := new(mocks.ProductRepository)
 	ur := new(mocks.UserRepository)
+	rr := new(mocks.ReservationRepository)
+	or := new(mocks.OrderRepository)
+	d := new(mocks.Dispenser)
+	qr := new(mocks.QuotaRepository)
+	// quota rules of the current case
+	var rules []domain.QuotaRule
+	qr.On("List", mock.Anything).Return(func(context.Context) []domain.QuotaRule {
+		return rules
+	}, nil)
 	valueCtx := "*context.valueCtx"
 	// cart => productID : count
 	normalCart := map[uint]uint{1: 2, 2: 1}
@@ -47,22 +58,37 @@ func Test_Service_Buy(t *testing.T) {
 		{
 			name: "should succeed when a buyer with sufficient balance request valid products",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.Anything, mock.Anything).
 					Return(cake, nil).Once()
 
 				pr.On("FindById", mock.Anything, mock.Anything).
 					Return(soda, nil).Once()
 
+				d.On("Dispense", mock.Anything, mock.Anything).
+					Return(nil).Times(3)
+
 				pr.On("BeginTransaction", mock.Anything).
 					Return(normalContext, pr).Once()
 				ur.On("BeginTransaction", mock.Anything).
 					Return(normalContext, ur).Once()
+				rr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, rr).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
 
-				pr.On("Update", mock.Anything, mock.Anything).
+				pr.On("TakeStock", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil).Twice()
-				ur.On("Update", mock.Anything, mock.Anything).
+				ur.On("SpendDeposit", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil).Once()
 
+				or.On("Insert", mock.Anything, mock.Anything).
+					Return(uint(7), nil).Once()
+				rr.On("FindActiveByUser", mock.Anything, mock.Anything).
+					Return(nil, domain.ErrReservationNotFound).Once()
+
 				ur.On("Commit").Once()
 			},
 			args: args{
@@ -75,15 +101,160 @@ func Test_Service_Buy(t *testing.T) {
 			wants: wants{
 				err: nil,
 				bill: &domain.Bill{
+					OrderId:    7,
 					TotalSpent: 20,
 					Items: []domain.Item{
-						{Name: "Cake", Count: 2, Price: 10},
-						{Name: "Soda", Count: 1, Price: 10},
+						{ProductId: 1, Name: "Cake", Count: 2, Dispensed: 2, Price: 10},
+						{ProductId: 2, Name: "Soda", Count: 1, Dispensed: 1, Price: 10},
 					},
 					Refund: []uint{20, 10, 5},
 				},
 			},
 		},
+		{
+			name: "should buy what can be fulfilled and report the rest in partial mode",
+			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
+				pr.On("FindById", mock.Anything, uint(1)).
+					Return(nil, errors.New("record not found")).Once()
+				pr.On("FindById", mock.Anything, uint(2)).
+					Return(&domain.Product{Id: 2, Name: "Cake", Price: 5, Count: 500}, nil).Once()
+				pr.On("FindById", mock.Anything, uint(3)).
+					Return(&domain.Product{Id: 3, Name: "Soda", Price: 10, Count: 2}, nil).Once()
+
+				d.On("Dispense", mock.Anything, mock.Anything).
+					Return(nil).Times(4)
+
+				pr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, pr).Once()
+				ur.On("BeginTransaction", mock.Anything).
+					Return(normalContext, ur).Once()
+				rr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, rr).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
+
+				pr.On("TakeStock", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil).Twice()
+				ur.On("SpendDeposit", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil).Once()
+
+				or.On("Insert", mock.Anything, mock.Anything).
+					Return(uint(7), nil).Once()
+				rr.On("FindActiveByUser", mock.Anything, mock.Anything).
+					Return(nil, domain.ErrReservationNotFound).Once()
+
+				ur.On("Commit").Once()
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+					Role:    domain.BUYER,
+					Deposit: 30,
+				}),
+				cart: map[uint]uint{1: 1, 2: 3, 3: 5},
+				opts: domain.BuyOptions{Partial: true},
+			},
+			wants: wants{
+				err: nil,
+				bill: &domain.Bill{
+					OrderId:    7,
+					TotalSpent: 25,
+					Items: []domain.Item{
+						{ProductId: 2, Name: "Cake", Count: 3, Dispensed: 3, Price: 15},
+						{ProductId: 3, Name: "Soda", Count: 1, Dispensed: 1, Price: 10},
+					},
+					Skipped: []domain.SkippedItem{
+						{ProductId: 1, Count: 1, Reason: domain.ErrProductNotFound.Error()},
+						{ProductId: 3, Name: "Soda", Count: 3, Reason: domain.ErrInsufficientProductsAmount.Error()},
+						{ProductId: 3, Name: "Soda", Count: 1, Reason: domain.ErrInsufficientBalance.Error()},
+					},
+					Refund: []uint{5},
+				},
+			},
+		},
+		{
+			name: "should charge only dispensed items when dispenser fails",
+			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
+				pr.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.Product{Id: 1, Name: "Cake", Price: 5, Count: 10}, nil).Once()
+				pr.On("FindById", mock.Anything, uint(2)).
+					Return(&domain.Product{Id: 2, Name: "Soda", Price: 10, Count: 10}, nil).Once()
+
+				d.On("Dispense", mock.Anything, uint(1)).
+					Return(nil).Once()
+				d.On("Dispense", mock.Anything, uint(1)).
+					Return(domain.ErrDispenseFailed).Once()
+				d.On("Dispense", mock.Anything, uint(2)).
+					Return(domain.ErrDispenseFailed).Once()
+
+				pr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, pr).Once()
+				ur.On("BeginTransaction", mock.Anything).
+					Return(normalContext, ur).Once()
+				rr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, rr).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
+
+				// whole purchase is charged before dispensing
+				pr.On("TakeStock", mock.Anything, uint(1), uint(3)).Return(nil).Once()
+				pr.On("TakeStock", mock.Anything, uint(2), uint(1)).Return(nil).Once()
+				ur.On("SpendDeposit", mock.Anything, uint(0), uint(25)).Return(nil).Once()
+
+				or.On("Insert", mock.Anything, mock.Anything).
+					Return(uint(8), nil).Once()
+				rr.On("FindActiveByUser", mock.Anything, mock.Anything).
+					Return(nil, domain.ErrReservationNotFound).Once()
+
+				ur.On("Commit").Once()
+
+				// items which didn't drop are refunded and restocked
+				or.On("FindById", mock.Anything, uint(8)).Return(&domain.Order{
+					Id: 8,
+					Items: []domain.OrderItem{
+						{Id: 1, ProductId: 1, Count: 3, Price: 5},
+						{Id: 2, ProductId: 2, Count: 1, Price: 10},
+					},
+				}, nil).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
+				ur.On("BeginTransaction", mock.Anything).
+					Return(normalContext, ur).Once()
+				pr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, pr).Once()
+				ur.On("AddDeposit", mock.Anything, uint(0), uint(20)).Return(nil).Once()
+				pr.On("AddStock", mock.Anything, uint(1), uint(2)).Return(nil).Once()
+				pr.On("AddStock", mock.Anything, uint(2), uint(1)).Return(nil).Once()
+				or.On("InsertRefund", mock.Anything, mock.MatchedBy(func(r domain.Refund) bool {
+					return r.OrderId == 8 && r.Amount == 20 && r.Restock && len(r.Items) == 2
+				})).Return(uint(1), nil).Once()
+				or.On("Commit").Once()
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+					Role:    domain.BUYER,
+					Deposit: 50,
+				}),
+				cart: map[uint]uint{1: 3, 2: 1},
+			},
+			wants: wants{
+				err: nil,
+				bill: &domain.Bill{
+					OrderId:    8,
+					TotalSpent: 5,
+					Items: []domain.Item{
+						{ProductId: 1, Name: "Cake", Count: 3, Dispensed: 1, Price: 5},
+						{ProductId: 2, Name: "Soda", Count: 1, Dispensed: 0, Price: 0},
+					},
+					Refund: []uint{20, 20, 5},
+				},
+			},
+		},
 		{
 			name:    "should fail when user is missing from context",
 			prepare: func() {},
@@ -113,6 +284,9 @@ func Test_Service_Buy(t *testing.T) {
 		{
 			name: "should fail when product not found",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.AnythingOfType(valueCtx), mock.Anything).
 					Return(nil, domain.ErrProductNotFound).Once()
 			},
@@ -128,6 +302,9 @@ func Test_Service_Buy(t *testing.T) {
 		{
 			name: "should fail when requested product has no sufficient amount",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.AnythingOfType(valueCtx), mock.Anything).
 					Return(&domain.Product{Price: 10, Count: 2}, nil).Once()
 			},
@@ -140,9 +317,30 @@ func Test_Service_Buy(t *testing.T) {
 				bill: nil,
 			},
 		},
+		{
+			name: "should fail when requested product is reserved by other buyers",
+			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, uint(1)).
+					Return(map[uint]uint{1: 499}, nil).Once()
+
+				pr.On("FindById", mock.AnythingOfType(valueCtx), mock.Anything).
+					Return(cake, nil).Once()
+			},
+			args: args{
+				ctx:  normalContext,
+				cart: map[uint]uint{1: 2},
+			},
+			wants: wants{
+				err:  domain.ErrInsufficientProductsAmount,
+				bill: nil,
+			},
+		},
 		{
 			name: "should fail when buyer has no sufficient balance",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.AnythingOfType(valueCtx), mock.Anything).
 					Return(&domain.Product{Price: 30, Count: 20}, nil).Once()
 			},
@@ -159,8 +357,37 @@ func Test_Service_Buy(t *testing.T) {
 			},
 		},
 		{
-			name: "should fail and rollback changes when products update fail",
+			name: "should fail in strict diet mode when product conflicts with buyer profile",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
+				pr.On("FindById", mock.AnythingOfType(valueCtx), mock.Anything).
+					Return(&domain.Product{
+						Price: 10, Count: 20,
+						DietInfo: domain.DietInfo{Allergens: []domain.Allergen{domain.Peanut}},
+					}, nil).Once()
+			},
+			args: args{
+				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+					Role:    domain.BUYER,
+					Deposit: 100,
+					Diet:    domain.DietaryProfile{Avoid: []domain.Allergen{domain.Peanut, domain.TreeNut}},
+				}),
+				cart: map[uint]uint{1: 1},
+				opts: domain.BuyOptions{StrictDiet: true},
+			},
+			wants: wants{
+				err:  domain.ErrDietaryConflict,
+				bill: nil,
+			},
+		},
+		{
+			name: "should fail and rollback changes without dispensing when taking stock fails",
+			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.AnythingOfType("*context.valueCtx"), mock.Anything).
 					Return(cake, nil).Once()
 
@@ -168,8 +395,12 @@ func Test_Service_Buy(t *testing.T) {
 					Return(normalContext, pr).Once()
 				ur.On("BeginTransaction", mock.Anything).
 					Return(normalContext, ur).Once()
+				rr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, rr).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
 
-				pr.On("Update", mock.AnythingOfType("*context.valueCtx"), mock.Anything).
+				pr.On("TakeStock", mock.AnythingOfType("*context.valueCtx"), mock.Anything, mock.Anything).
 					Return(errors.New("failed to update product")).Once()
 
 				pr.On("Rollback").Once()
@@ -184,8 +415,11 @@ func Test_Service_Buy(t *testing.T) {
 			},
 		},
 		{
-			name: "should fail and rollback changes when user update fail",
+			name: "should fail and rollback changes without dispensing when deposit was spent concurrently",
 			prepare: func() {
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+
 				pr.On("FindById", mock.Anything, mock.Anything).
 					Return(cake, nil).Once()
 
@@ -193,11 +427,15 @@ func Test_Service_Buy(t *testing.T) {
 					Return(normalContext, pr).Once()
 				ur.On("BeginTransaction", mock.Anything).
 					Return(normalContext, ur).Once()
+				rr.On("BeginTransaction", mock.Anything).
+					Return(normalContext, rr).Once()
+				or.On("BeginTransaction", mock.Anything).
+					Return(normalContext, or).Once()
 
-				pr.On("Update", mock.Anything, mock.Anything).
+				pr.On("TakeStock", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil).Once()
-				ur.On("Update", mock.Anything, mock.Anything).
-					Return(errors.New("failed to update user")).Once()
+				ur.On("SpendDeposit", mock.Anything, mock.Anything, mock.Anything).
+					Return(domain.ErrInsufficientBalance).Once()
 
 				ur.On("Rollback").Once()
 			},
@@ -206,19 +444,42 @@ func Test_Service_Buy(t *testing.T) {
 				cart: map[uint]uint{1: 2},
 			},
 			wants: wants{
-				err:  domain.ErrInternalServer,
+				err:  domain.ErrInsufficientBalance,
+				bill: nil,
+			},
+		},
+		{
+			name: "should fail naming the rule when purchase exceeds quota of the buyer",
+			prepare: func() {
+				rules = []domain.QuotaRule{
+					{Id: 1, Name: "cakes", ProductId: 1, Limit: 3, Period: domain.PeriodDay},
+					{Id: 2, Name: "sodas", ProductId: 2, Limit: 1, Period: domain.PeriodDay},
+				}
+				rr.On("HeldCounts", mock.Anything, mock.Anything).
+					Return(map[uint]uint{}, nil).Once()
+				pr.On("FindById", mock.Anything, uint(1)).
+					Return(cake, nil).Once()
+				or.On("UnitsBought", mock.Anything, uint(1), []uint{1}, mock.Anything).
+					Return(uint(2), nil).Once()
+			},
+			args: args{
+				ctx:  normalContext,
+				cart: map[uint]uint{1: 2},
+			},
+			wants: wants{
+				err:  domain.ErrQuotaExceeded,
 				bill: nil,
 			},
 		},
 	}
 
-	svc := product.InitService(pr, ur)
+	svc := product.InitService(pr, ur, rr, or, qr, d, time.Minute, "en")
 
 	for _, tc := range testCases {
 		// arrange
 		tc.prepare()
 		// action
-		bill, err := svc.Buy(tc.args.ctx, tc.args.cart)
+		bill, err := svc.Buy(tc.args.ctx, tc.args.cart, tc.args.opts)
 		// assert
 		if tc.wants.err != nil {
 			assert.ErrorIs(t, err, tc.wants.err, tc.name)
@@ -230,4 +491,7 @@ func Test_Service_Buy(t *testing.T) {
 	}
 	pr.AssertExpectations(t)
 	ur.AssertExpectations(t)
+	rr.AssertExpectations(t)
+	or.AssertExpectations(t)
+	d.AssertExpectations(t)
 }
@@ -0,0 +1,56 @@
+package dispenser
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+)
+
+// SimulatedDispenser stands in for the vending hardware,
+// it can be configured to fail randomly or for certain products
+type SimulatedDispenser struct {
+	failureRate float64
+	failing     map[uint]bool
+	rnd         *rand.Rand
+	mu          sync.Mutex
+}
+
+// InitSimulatedDispenser
+// failureRate probability of failure for each item, between 0 and 1
+// failingProducts ids of products which always fail to drop
+func InitSimulatedDispenser(failureRate float64, failingProducts []uint) domain.Dispenser {
+	failing := make(map[uint]bool, len(failingProducts))
+	for _, pid := range failingProducts {
+		failing[pid] = true
+	}
+	return &SimulatedDispenser{
+		failureRate: failureRate,
+		failing:     failing,
+		rnd:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (d *SimulatedDispenser) Dispense(ctx context.Context, productId uint) error {
+	const op string = "product.data.dispenser.simulated.Dispense"
+
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if d.failing[productId] {
+		return errors.Wrapf(domain.ErrDispenseFailed, "%s: product %d is jammed", op, productId)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.rnd.Float64() < d.failureRate {
+		return errors.Wrap(domain.ErrDispenseFailed, op)
+	}
+
+	return nil
+}
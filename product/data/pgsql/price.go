@@ -0,0 +1,40 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type ProductPrice struct {
+	ID        uint      `gorm:"primarykey"`
+	ProductID uint      `gorm:"index;column:product_id"`
+	Old       uint      `gorm:"column:old"`
+	New       uint      `gorm:"column:new"`
+	ChangedBy uint      `gorm:"column:changed_by"`
+	ChangedAt time.Time `gorm:"index;column:changed_at"`
+}
+
+func (p *ProductPrice) TableName() string {
+	return "product_prices"
+}
+
+func (p *ProductPrice) FromDomain(c domain.PriceChange) {
+	p.ID = c.Id
+	p.ProductID = c.ProductId
+	p.Old = c.Old
+	p.New = c.New
+	p.ChangedBy = c.ChangedBy
+	p.ChangedAt = c.ChangedAt
+}
+
+func (p *ProductPrice) ToDomain() domain.PriceChange {
+	return domain.PriceChange{
+		Id:        p.ID,
+		ProductId: p.ProductID,
+		Old:       p.Old,
+		New:       p.New,
+		ChangedBy: p.ChangedBy,
+		ChangedAt: p.ChangedAt,
+	}
+}
@@ -1,37 +1,100 @@
 package pgsql
 
 import (
+	"strings"
+
 	"github.com/apm-dev/vending-machine/domain"
 	"gorm.io/gorm"
 )
 
 type Product struct {
-	Name     string `gorm:"column:name"`
-	Count    uint   `gorm:"column:count"`
-	Price    uint   `gorm:"column:cost"`
-	SellerID uint   `gorm:"column:seller_id"`
+	Sku  string `gorm:"index;size:64;column:sku"`
+	Name string `gorm:"column:name"`
+	// description in the default language, translations are kept aside
+	Description string `gorm:"size:1024;column:description"`
+	Category    string `gorm:"index;size:32;column:category"`
+	Count       uint   `gorm:"column:count"`
+	Price       uint   `gorm:"column:cost"`
+	SellerID    uint   `gorm:"column:seller_id"`
+	// comma separated allergens and diet labels
+	Allergens string    `gorm:"column:allergens"`
+	Labels    string    `gorm:"column:labels"`
+	Nutrition Nutrition `gorm:"embedded;embeddedPrefix:nutrition_"`
 	// gorm model contains id, created_at, updated_at, deleted_at by default
 	gorm.Model
 }
 
+type Nutrition struct {
+	ServingSize  uint    `gorm:"column:serving_size"`
+	Energy       uint    `gorm:"column:energy"`
+	Fat          float64 `gorm:"column:fat"`
+	SaturatedFat float64 `gorm:"column:saturated_fat"`
+	Carbohydrate float64 `gorm:"column:carbohydrate"`
+	Sugars       float64 `gorm:"column:sugars"`
+	Protein      float64 `gorm:"column:protein"`
+	Salt         float64 `gorm:"column:salt"`
+}
+
 func (p *Product) TableName() string {
 	return "products"
 }
 
 func (p *Product) FromDomain(product domain.Product) {
 	p.ID = product.Id
+	p.Sku = product.Sku
 	p.Name = product.Name
+	p.Description = product.Description
+	p.Category = product.Category
 	p.Count = product.Count
 	p.Price = product.Price
 	p.SellerID = product.SellerId
+
+	allergens := make([]string, len(product.Allergens))
+	for i, a := range product.Allergens {
+		allergens[i] = string(a)
+	}
+	p.Allergens = strings.Join(allergens, ",")
+
+	labels := make([]string, len(product.Labels))
+	for i, l := range product.Labels {
+		labels[i] = string(l)
+	}
+	p.Labels = strings.Join(labels, ",")
+
+	p.Nutrition = Nutrition(product.Nutrition)
 }
 
 func (p *Product) ToDomain() *domain.Product {
-	return &domain.Product{
-		Id:       p.ID,
-		Name:     p.Name,
-		Count:    p.Count,
-		Price:    p.Price,
-		SellerId: p.SellerID,
+	product := &domain.Product{
+		Id:          p.ID,
+		Sku:         p.Sku,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category,
+		Count:       p.Count,
+		Price:       p.Price,
+		SellerId:    p.SellerID,
 	}
+
+	if p.DeletedAt.Valid {
+		product.DeletedAt = &p.DeletedAt.Time
+	}
+
+	product.Allergens = make([]domain.Allergen, 0)
+	if p.Allergens != "" {
+		for _, a := range strings.Split(p.Allergens, ",") {
+			product.Allergens = append(product.Allergens, domain.Allergen(a))
+		}
+	}
+
+	product.Labels = make([]domain.DietLabel, 0)
+	if p.Labels != "" {
+		for _, l := range strings.Split(p.Labels, ",") {
+			product.Labels = append(product.Labels, domain.DietLabel(l))
+		}
+	}
+
+	product.Nutrition = domain.Nutrition(p.Nutrition)
+
+	return product
 }
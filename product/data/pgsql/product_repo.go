@@ -81,6 +81,40 @@ func (r *ProductRepository) List(ctx context.Context) ([]domain.Product, error)
 	return ps, nil
 }
 
+func (r *ProductRepository) ListBySeller(ctx context.Context, sellerId uint) ([]domain.Product, error) {
+	const op string = "product.data.pgsql.product_repo.ListBySeller"
+
+	var dbps []Product
+
+	err := r.db.WithContext(ctx).Where("seller_id = ?", sellerId).Find(&dbps).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var ps = make([]domain.Product, len(dbps))
+	for i, dbp := range dbps {
+		ps[i] = *dbp.ToDomain()
+	}
+
+	return ps, nil
+}
+
+func (r *ProductRepository) FindBySellerAndSku(ctx context.Context, sellerId uint, sku string) (*domain.Product, error) {
+	const op string = "product.data.pgsql.product_repo.FindBySellerAndSku"
+
+	dbp := new(Product)
+
+	err := r.db.WithContext(ctx).Where("seller_id = ? AND sku = ?", sellerId, sku).First(dbp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrProductNotFound, op)
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return dbp.ToDomain(), nil
+}
+
 func (r *ProductRepository) Update(ctx context.Context, p *domain.Product) error {
 	const op string = "product.data.pgsql.product_repo.Update"
 
@@ -95,6 +129,62 @@ func (r *ProductRepository) Update(ctx context.Context, p *domain.Product) error
 	return nil
 }
 
+func (r *ProductRepository) UpdateDietInfo(ctx context.Context, id uint, info domain.DietInfo) error {
+	const op string = "product.data.pgsql.product_repo.UpdateDietInfo"
+
+	dbp := new(Product)
+	dbp.FromDomain(domain.Product{DietInfo: info})
+
+	// other columns (e.g. count) are changed concurrently, so only diet columns are written
+	result := r.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ?", id).
+		Select("allergens", "labels", "nutrition_serving_size", "nutrition_energy",
+			"nutrition_fat", "nutrition_saturated_fat", "nutrition_carbohydrate",
+			"nutrition_sugars", "nutrition_protein", "nutrition_salt",
+		).
+		Updates(dbp)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrProductNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) AddStock(ctx context.Context, id, count uint) error {
+	const op string = "product.data.pgsql.product_repo.AddStock"
+
+	result := r.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ?", id).
+		Update("count", gorm.Expr("count + ?", count))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrProductNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) TakeStock(ctx context.Context, id, count uint) error {
+	const op string = "product.data.pgsql.product_repo.TakeStock"
+
+	result := r.db.WithContext(ctx).Model(&Product{}).
+		Where("id = ? AND count >= ?", id, count).
+		Update("count", gorm.Expr("count - ?", count))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrInsufficientProductsAmount, op)
+	}
+
+	return nil
+}
+
 func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
 	const op string = "product.data.pgsql.product_repo.Delete"
 
@@ -105,3 +195,205 @@ func (r *ProductRepository) Delete(ctx context.Context, id uint) error {
 
 	return nil
 }
+
+func (r *ProductRepository) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	const op string = "product.data.pgsql.product_repo.ListDeleted"
+
+	var dbps []Product
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&dbps).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var ps = make([]domain.Product, len(dbps))
+	for i, dbp := range dbps {
+		ps[i] = *dbp.ToDomain()
+	}
+
+	return ps, nil
+}
+
+func (r *ProductRepository) FindDeletedById(ctx context.Context, id uint) (*domain.Product, error) {
+	const op string = "product.data.pgsql.product_repo.FindDeletedById"
+
+	dbp := new(Product)
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&dbp, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrProductNotFound, op)
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return dbp.ToDomain(), nil
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id uint) error {
+	const op string = "product.data.pgsql.product_repo.Restore"
+
+	err := r.db.WithContext(ctx).Unscoped().Model(&Product{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) Purge(ctx context.Context, id uint) error {
+	const op string = "product.data.pgsql.product_repo.Purge"
+
+	// rows keyed on the product go with it, orders keep it as history
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&ProductPrice{}, &ProductTranslation{}, &ReservationItem{}, &QuotaRule{},
+		} {
+			if err := tx.Where("product_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// carts are stored by the cart module
+		err := tx.Exec("DELETE FROM cart_items WHERE product_id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&Product{}, id).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) InsertPriceChange(ctx context.Context, c domain.PriceChange) error {
+	const op string = "product.data.pgsql.product_repo.InsertPriceChange"
+
+	dbc := new(ProductPrice)
+	dbc.FromDomain(c)
+
+	err := r.db.WithContext(ctx).Create(&dbc).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) ListPriceChanges(ctx context.Context, productId uint) ([]domain.PriceChange, error) {
+	const op string = "product.data.pgsql.product_repo.ListPriceChanges"
+
+	var dbcs []ProductPrice
+
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productId).
+		Order("changed_at, id").
+		Find(&dbcs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	var cs = make([]domain.PriceChange, len(dbcs))
+	for i, dbc := range dbcs {
+		cs[i] = dbc.ToDomain()
+	}
+
+	return cs, nil
+}
+
+func (r *ProductRepository) ListTranslations(ctx context.Context, ids []uint) (map[uint]map[string]domain.Translation, error) {
+	const op string = "product.data.pgsql.product_repo.ListTranslations"
+
+	var dbts []ProductTranslation
+
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ?", ids).
+		Find(&dbts).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	ts := make(map[uint]map[string]domain.Translation)
+	for _, dbt := range dbts {
+		if ts[dbt.ProductID] == nil {
+			ts[dbt.ProductID] = make(map[string]domain.Translation)
+		}
+		ts[dbt.ProductID][dbt.Lang] = domain.Translation{
+			Name:        dbt.Name,
+			Description: dbt.Description,
+		}
+	}
+
+	return ts, nil
+}
+
+func (r *ProductRepository) SaveTranslations(ctx context.Context, id uint, ts map[string]domain.Translation) error {
+	const op string = "product.data.pgsql.product_repo.SaveTranslations"
+
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", id).
+		Delete(&ProductTranslation{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if len(ts) == 0 {
+		return nil
+	}
+
+	dbts := make([]ProductTranslation, 0, len(ts))
+	for lang, t := range ts {
+		dbts = append(dbts, ProductTranslation{
+			ProductID:   id,
+			Lang:        lang,
+			Name:        t.Name,
+			Description: t.Description,
+		})
+	}
+
+	err = r.db.WithContext(ctx).Create(&dbts).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) StockStats(ctx context.Context) (*domain.StockStats, error) {
+	const op string = "product.data.pgsql.product_repo.StockStats"
+
+	stats := new(domain.StockStats)
+
+	err := r.db.WithContext(ctx).Model(&Product{}).
+		Select("COALESCE(SUM(count), 0) AS units, " +
+			"COALESCE(SUM(count * cost), 0) AS value, " +
+			"COUNT(*) FILTER (WHERE count = 0) AS out_of_stock").
+		Scan(stats).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return stats, nil
+}
+
+func (r *ProductRepository) IdsByCategory(ctx context.Context, category string) ([]uint, error) {
+	const op string = "product.data.pgsql.product_repo.IdsByCategory"
+
+	var ids []uint
+
+	err := r.db.WithContext(ctx).Unscoped().Model(&Product{}).
+		Where("lower(category) = lower(?)", category).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return ids, nil
+}
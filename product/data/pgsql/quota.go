@@ -0,0 +1,63 @@
+package pgsql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type QuotaRule struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"size:64;column:name"`
+	ProductID uint   `gorm:"index;column:product_id"`
+	Category  string `gorm:"index;size:32;column:category"`
+	Limit     uint   `gorm:"column:quota_limit"`
+	Period    string `gorm:"size:16;column:period"`
+	Rolling   bool   `gorm:"column:rolling"`
+	// comma separated ids of buyers
+	BuyerIDs string `gorm:"column:buyer_ids"`
+}
+
+func (r *QuotaRule) TableName() string {
+	return "quota_rules"
+}
+
+func (r *QuotaRule) FromDomain(rule domain.QuotaRule) {
+	r.ID = rule.Id
+	r.Name = rule.Name
+	r.ProductID = rule.ProductId
+	r.Category = rule.Category
+	r.Limit = rule.Limit
+	r.Period = rule.Period
+	r.Rolling = rule.Rolling
+
+	ids := make([]string, len(rule.BuyerIds))
+	for i, id := range rule.BuyerIds {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	r.BuyerIDs = strings.Join(ids, ",")
+}
+
+func (r *QuotaRule) ToDomain() domain.QuotaRule {
+	rule := domain.QuotaRule{
+		Id:        r.ID,
+		Name:      r.Name,
+		ProductId: r.ProductID,
+		Category:  r.Category,
+		Limit:     r.Limit,
+		Period:    r.Period,
+		Rolling:   r.Rolling,
+	}
+
+	if r.BuyerIDs != "" {
+		for _, s := range strings.Split(r.BuyerIDs, ",") {
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err == nil {
+				rule.BuyerIds = append(rule.BuyerIds, uint(id))
+			}
+		}
+	}
+
+	return rule
+}
@@ -0,0 +1,65 @@
+package pgsql
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+func InitQuotaRepository(db *gorm.DB) domain.QuotaRepository {
+	return &QuotaRepository{
+		db: db,
+	}
+}
+
+func (r *QuotaRepository) Insert(ctx context.Context, rule domain.QuotaRule) (uint, error) {
+	const op string = "product.data.pgsql.quota_repo.Insert"
+
+	dbr := new(QuotaRule)
+	dbr.FromDomain(rule)
+
+	err := r.db.WithContext(ctx).Create(&dbr).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return dbr.ID, nil
+}
+
+func (r *QuotaRepository) List(ctx context.Context) ([]domain.QuotaRule, error) {
+	const op string = "product.data.pgsql.quota_repo.List"
+
+	var dbrs []QuotaRule
+
+	err := r.db.WithContext(ctx).Order("id").Find(&dbrs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	rules := make([]domain.QuotaRule, len(dbrs))
+	for i, dbr := range dbrs {
+		rules[i] = dbr.ToDomain()
+	}
+
+	return rules, nil
+}
+
+func (r *QuotaRepository) Delete(ctx context.Context, id uint) error {
+	const op string = "product.data.pgsql.quota_repo.Delete"
+
+	result := r.db.WithContext(ctx).Delete(&QuotaRule{}, id)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrInvalidParams, op)
+	}
+
+	return nil
+}
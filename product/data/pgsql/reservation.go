@@ -0,0 +1,57 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type Reservation struct {
+	ID        uint              `gorm:"primarykey"`
+	UserID    uint              `gorm:"uniqueIndex;column:user_id"`
+	ExpiresAt time.Time         `gorm:"index;column:expires_at"`
+	Items     []ReservationItem `gorm:"foreignKey:ReservationID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+}
+
+type ReservationItem struct {
+	ID            uint `gorm:"primarykey"`
+	ReservationID uint `gorm:"index;column:reservation_id"`
+	ProductID     uint `gorm:"index;column:product_id"`
+	Count         uint `gorm:"column:count"`
+}
+
+func (r *Reservation) TableName() string {
+	return "reservations"
+}
+
+func (i *ReservationItem) TableName() string {
+	return "reservation_items"
+}
+
+func (r *Reservation) FromDomain(reservation domain.Reservation) {
+	r.ID = reservation.Id
+	r.UserID = reservation.UserId
+	r.ExpiresAt = reservation.ExpiresAt
+	r.Items = make([]ReservationItem, 0, len(reservation.Items))
+	for pid, count := range reservation.Items {
+		r.Items = append(r.Items, ReservationItem{
+			ReservationID: reservation.Id,
+			ProductID:     pid,
+			Count:         count,
+		})
+	}
+}
+
+func (r *Reservation) ToDomain() *domain.Reservation {
+	reservation := &domain.Reservation{
+		Id:        r.ID,
+		UserId:    r.UserID,
+		ExpiresAt: r.ExpiresAt,
+		Items:     make(map[uint]uint, len(r.Items)),
+	}
+	for _, i := range r.Items {
+		reservation.Items[i.ProductID] += i.Count
+	}
+	return reservation
+}
@@ -0,0 +1,144 @@
+package pgsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/pgsqlhelper"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ReservationRepository struct {
+	db *gorm.DB
+}
+
+func InitReservationRepository(db *gorm.DB) domain.ReservationRepository {
+	return &ReservationRepository{
+		db: db,
+	}
+}
+
+func (r *ReservationRepository) BeginTransaction(ctx context.Context) (context.Context, domain.ReservationRepository) {
+	if tx, ok := pgsqlhelper.TransactionFromContext(ctx); ok {
+		return ctx, InitReservationRepository(tx)
+	}
+	tx := r.db.Begin()
+	ctx = pgsqlhelper.TransactionToContext(ctx, tx)
+	return ctx, InitReservationRepository(tx)
+}
+
+func (r *ReservationRepository) Commit() {
+	r.db.Commit()
+}
+
+func (r *ReservationRepository) Rollback() {
+	r.db.Rollback()
+}
+
+func (r *ReservationRepository) Insert(ctx context.Context, rsv domain.Reservation) (uint, error) {
+	const op string = "product.data.pgsql.reservation_repo.Insert"
+
+	dbr := new(Reservation)
+	dbr.FromDomain(rsv)
+
+	err := r.db.WithContext(ctx).Create(&dbr).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return dbr.ID, nil
+}
+
+func (r *ReservationRepository) FindActiveByUser(ctx context.Context, userId uint) (*domain.Reservation, error) {
+	const op string = "product.data.pgsql.reservation_repo.FindActiveByUser"
+
+	dbr := new(Reservation)
+
+	err := r.db.WithContext(ctx).Preload("Items").
+		Where("user_id = ? AND expires_at > ?", userId, time.Now()).
+		First(&dbr).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrReservationNotFound, op)
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return dbr.ToDomain(), nil
+}
+
+func (r *ReservationRepository) HeldCounts(ctx context.Context, exceptUserId uint) (map[uint]uint, error) {
+	const op string = "product.data.pgsql.reservation_repo.HeldCounts"
+
+	var rows []struct {
+		ProductID uint
+		Held      uint
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("reservation_items AS ri").
+		Select("ri.product_id AS product_id, SUM(ri.count) AS held").
+		Joins("JOIN reservations AS r ON r.id = ri.reservation_id").
+		Where("r.expires_at > ? AND r.user_id <> ?", time.Now(), exceptUserId).
+		Group("ri.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	held := make(map[uint]uint, len(rows))
+	for _, row := range rows {
+		held[row.ProductID] = row.Held
+	}
+	return held, nil
+}
+
+func (r *ReservationRepository) Update(ctx context.Context, rsv *domain.Reservation) error {
+	const op string = "product.data.pgsql.reservation_repo.Update"
+
+	dbr := new(Reservation)
+	dbr.FromDomain(*rsv)
+
+	// items are replaced by the new ones
+	err := r.db.WithContext(ctx).
+		Where("reservation_id = ?", rsv.Id).
+		Delete(&ReservationItem{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	err = r.db.WithContext(ctx).Save(&dbr).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) DeleteByUser(ctx context.Context, userId uint) error {
+	const op string = "product.data.pgsql.reservation_repo.DeleteByUser"
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Delete(&Reservation{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *ReservationRepository) DeleteExpired(ctx context.Context) error {
+	const op string = "product.data.pgsql.reservation_repo.DeleteExpired"
+
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", time.Now()).
+		Delete(&Reservation{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package pgsql
+
+type ProductTranslation struct {
+	ID          uint   `gorm:"primarykey"`
+	ProductID   uint   `gorm:"uniqueIndex:idx_product_lang;column:product_id"`
+	Lang        string `gorm:"uniqueIndex:idx_product_lang;size:16;column:lang"`
+	Name        string `gorm:"column:name"`
+	Description string `gorm:"size:1024;column:description"`
+}
+
+func (t *ProductTranslation) TableName() string {
+	return "product_translations"
+}
@@ -0,0 +1,83 @@
+package product
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func (s *Service) UpdateTranslations(ctx context.Context, id uint, ts map[string]domain.Translation) (*domain.Product, error) {
+	const op string = "product.service.UpdateTranslations"
+
+	normalized := make(map[string]domain.Translation, len(ts))
+	for lang, t := range ts {
+		lang, ok := domain.NormalizeLang(lang)
+		if !ok {
+			return nil, errors.Wrapf(domain.ErrInvalidParams, "invalid language %q", lang)
+		}
+		if err := t.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "translation %q", lang)
+		}
+		normalized[lang] = t
+	}
+	// base name and description are the default language
+	delete(normalized, s.lang)
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if u.Role != domain.SELLER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	p, err := s.pr.FindById(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrProductNotFound
+	}
+	// only related seller can translate it
+	if p.SellerId != u.Id {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	ctx, pr := s.pr.BeginTransaction(ctx)
+	err = pr.SaveTranslations(ctx, id, normalized)
+	if err != nil {
+		pr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	pr.Commit()
+
+	p.Lang = s.lang
+	p.Translations = normalized
+	return p, nil
+}
+
+// localize replaces name and description of the product with
+// the first preferred language it's translated to
+func (s *Service) localize(p *domain.Product, ts map[string]domain.Translation, langs []string) {
+	p.Lang = s.lang
+
+	lang, ok := domain.MatchLang(langs, func(lang string) bool {
+		_, ok := ts[lang]
+		return ok || lang == s.lang
+	})
+	if !ok || lang == s.lang {
+		return
+	}
+
+	t := ts[lang]
+	p.Lang = lang
+	p.Name = t.Name
+	if t.Description != "" {
+		p.Description = t.Description
+	}
+}
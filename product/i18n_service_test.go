@@ -0,0 +1,108 @@
+package product_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_List_Localized(t *testing.T) {
+	type wants struct {
+		names []string
+		langs []string
+	}
+	type testCase struct {
+		name  string
+		langs []string
+		wants wants
+	}
+
+	pr := new(mocks.ProductRepository)
+	rr := new(mocks.ReservationRepository)
+
+	pr.On("List", mock.Anything).Return([]domain.Product{
+		{Id: 1, Name: "Cake", Description: "Chocolate cake", Count: 2, Price: 10},
+		{Id: 2, Name: "Soda", Count: 5, Price: 5},
+	}, nil)
+	pr.On("ListTranslations", mock.Anything, []uint{1, 2}).Return(map[uint]map[string]domain.Translation{
+		1: {
+			"fa": {Name: "کیک", Description: "کیک شکلاتی"},
+			"de": {Name: "Kuchen"},
+		},
+	}, nil)
+	rr.On("HeldCounts", mock.Anything, uint(0)).Return(map[uint]uint{}, nil)
+
+	testCases := []testCase{
+		{
+			name:  "should use translation of the most preferred language",
+			langs: []string{"fa", "de"},
+			wants: wants{names: []string{"کیک", "Soda"}, langs: []string{"fa", "en"}},
+		},
+		{
+			name:  "should fall back from region to base language",
+			langs: []string{"de-at"},
+			wants: wants{names: []string{"Kuchen", "Soda"}, langs: []string{"de", "en"}},
+		},
+		{
+			name:  "should prefer default language when it comes first",
+			langs: []string{"en", "fa"},
+			wants: wants{names: []string{"Cake", "Soda"}, langs: []string{"en", "en"}},
+		},
+		{
+			name:  "should fall back to default language",
+			langs: []string{"fr"},
+			wants: wants{names: []string{"Cake", "Soda"}, langs: []string{"en", "en"}},
+		},
+	}
+
+	svc := product.InitService(pr, nil, rr, nil, nil, nil, time.Minute, "en")
+
+	for _, tc := range testCases {
+		// action
+		ps, err := svc.List(context.Background(), domain.ListOptions{Langs: tc.langs})
+		// assert
+		assert.NoError(t, err, tc.name)
+		names := make([]string, len(ps))
+		langs := make([]string, len(ps))
+		for i, p := range ps {
+			names[i] = p.Name
+			langs[i] = p.Lang
+		}
+		assert.Equal(t, tc.wants.names, names, tc.name)
+		assert.Equal(t, tc.wants.langs, langs, tc.name)
+	}
+}
+
+func Test_Service_UpdateTranslations_DescriptionLength(t *testing.T) {
+	pr := new(mocks.ProductRepository)
+
+	seller := &domain.User{Id: 3, Role: domain.SELLER}
+	ctx := context.WithValue(context.Background(), domain.USER, seller)
+	svc := product.InitService(pr, nil, nil, nil, nil, nil, time.Minute, "en")
+
+	// persian letters take two bytes, the limit is in characters
+	longest := strings.Repeat("ک", domain.MaxDescriptionLen)
+	pr.On("FindById", mock.Anything, uint(1)).
+		Return(&domain.Product{Id: 1, Name: "Cake", SellerId: 3}, nil).Once()
+	pr.On("BeginTransaction", mock.Anything).Return(ctx, pr).Once()
+	pr.On("SaveTranslations", mock.Anything, uint(1), mock.Anything).Return(nil).Once()
+	pr.On("Commit").Once()
+
+	_, err := svc.UpdateTranslations(ctx, 1, map[string]domain.Translation{
+		"fa": {Name: "کیک", Description: longest},
+	})
+	assert.NoError(t, err)
+
+	_, err = svc.UpdateTranslations(ctx, 1, map[string]domain.Translation{
+		"fa": {Name: "کیک", Description: longest + "ک"},
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidParams)
+	pr.AssertExpectations(t)
+}
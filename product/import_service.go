@@ -0,0 +1,171 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Import creates or updates catalog of the seller row by row.
+// Rows are matched with existing products by sku first, then by name.
+// Invalid rows are reported and skipped, the rest are written in one
+// transaction unless it's a dry run.
+func (s *Service) Import(ctx context.Context, rows []domain.ImportRow, dryRun bool) (*domain.ImportReport, error) {
+	const op string = "product.service.Import"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrUserNotFound
+	}
+	if u.Role != domain.SELLER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	ps, err := s.pr.ListBySeller(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	bySku := make(map[string]*domain.Product)
+	byName := make(map[string]*domain.Product)
+	for i := range ps {
+		p := &ps[i]
+		if p.Sku != "" {
+			bySku[p.Sku] = p
+		}
+		byName[strings.ToLower(p.Name)] = p
+	}
+	// line which already touched a product, to catch duplicate rows
+	touched := make(map[*domain.Product]int)
+
+	pr := s.pr
+	if !dryRun {
+		ctx, pr = s.pr.BeginTransaction(ctx)
+	}
+
+	report := &domain.ImportReport{DryRun: dryRun, Rows: make([]domain.ImportResult, 0, len(rows))}
+	fail := func(line int, err error) {
+		report.Add(domain.ImportResult{Line: line, Action: domain.ImportFailed, Error: err.Error()})
+	}
+
+	for _, row := range rows {
+		in := row.ProductInput
+		in.Sku = strings.TrimSpace(in.Sku)
+		in.Name = strings.TrimSpace(in.Name)
+
+		// same rules as adding or updating a single product
+		if err := in.Validate(); err != nil {
+			fail(row.Line, err)
+			continue
+		}
+
+		p, ok := bySku[in.Sku]
+		if in.Sku == "" || !ok {
+			p = byName[strings.ToLower(in.Name)]
+		}
+		// matched by name but file assigns a sku of another product
+		if p != nil && in.Sku != "" {
+			if other, ok := bySku[in.Sku]; ok && other != p {
+				fail(row.Line, errors.Wrapf(domain.ErrDuplicateSku, "%q is used by product %d", in.Sku, other.Id))
+				continue
+			}
+		}
+		if p != nil {
+			if line, ok := touched[p]; ok {
+				fail(row.Line, fmt.Errorf("duplicate of line %d", line))
+				continue
+			}
+		}
+
+		action := domain.ImportUpdated
+		if p != nil && in.Sku == "" {
+			// files without sku, description or category column keep the existing one
+			in.Sku = p.Sku
+		}
+		if p != nil && in.Description == "" {
+			in.Description = p.Description
+		}
+		if p != nil && in.Category == "" {
+			in.Category = p.Category
+		}
+		if p == nil {
+			action = domain.ImportCreated
+			p = domain.NewProduct(in.Name, in.Count, 0, u.Id)
+		}
+		oldPrice := p.Price
+		if p.Sku != "" && p.Sku != in.Sku {
+			delete(bySku, p.Sku)
+		}
+		delete(byName, strings.ToLower(p.Name))
+		p.Sku = in.Sku
+		p.Name = in.Name
+		p.Description = in.Description
+		p.Category = in.Category
+		p.Count = in.Count
+		p.Price = in.Price
+
+		if !dryRun {
+			if action == domain.ImportCreated {
+				p.Id, err = pr.Insert(ctx, *p)
+			} else {
+				err = pr.Update(ctx, p)
+			}
+			if err == nil {
+				err = recordPrice(ctx, pr, p, oldPrice, u.Id)
+			}
+			if err != nil {
+				pr.Rollback()
+				logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+				return nil, domain.ErrInternalServer
+			}
+		}
+
+		if p.Sku != "" {
+			bySku[p.Sku] = p
+		}
+		byName[strings.ToLower(p.Name)] = p
+		touched[p] = row.Line
+
+		report.Add(domain.ImportResult{Line: row.Line, Action: action, ProductId: p.Id})
+	}
+
+	if !dryRun {
+		pr.Commit()
+	}
+
+	return report, nil
+}
+
+// Export returns all products of the seller
+func (s *Service) Export(ctx context.Context) ([]domain.Product, error) {
+	const op string = "product.service.Export"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrUserNotFound
+	}
+	if u.Role != domain.SELLER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	s.pl.RLock()
+	defer s.pl.RUnlock()
+
+	ps, err := s.pr.ListBySeller(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return ps, nil
+}
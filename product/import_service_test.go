@@ -0,0 +1,152 @@
+package product_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Import(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		rows   []domain.ImportRow
+		dryRun bool
+	}
+	type wants struct {
+		err     error
+		actions []string
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	pr := new(mocks.ProductRepository)
+
+	sellerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 2, Role: domain.SELLER,
+	})
+	catalog := func() []domain.Product {
+		return []domain.Product{
+			{Id: 1, Sku: "CK-1", Name: "Cake", Count: 3, Price: 10, SellerId: 2},
+			{Id: 2, Name: "Soda", Count: 5, Price: 5, SellerId: 2},
+		}
+	}
+	row := func(line int, sku, name string, count, price uint) domain.ImportRow {
+		return domain.ImportRow{Line: line, ProductInput: domain.ProductInput{
+			Sku: sku, Name: name, Count: count, Price: price,
+		}}
+	}
+	rows := []domain.ImportRow{
+		// matched by sku, renamed
+		row(2, "CK-1", "Cheesecake", 4, 15),
+		// matched by name, keeps no sku
+		row(3, "", "soda", 10, 5),
+		// new product
+		row(4, "CH-1", "Chips", 7, 20),
+		// breaks the price rule of single products
+		row(5, "", "Gum", 1, 3),
+		// same product twice
+		row(6, "CH-1", "Chips", 8, 20),
+		// breaks the count rule of single products
+		row(7, "", "Mints", 0, 5),
+	}
+	actions := []string{
+		domain.ImportUpdated, domain.ImportUpdated, domain.ImportCreated,
+		domain.ImportFailed, domain.ImportFailed, domain.ImportFailed,
+	}
+
+	testCases := []testCase{
+		{
+			name: "should only report actions on dry run",
+			prepare: func() {
+				pr.On("ListBySeller", mock.Anything, uint(2)).Return(catalog(), nil).Once()
+			},
+			args: args{
+				ctx:    sellerContext,
+				rows:   rows,
+				dryRun: true,
+			},
+			wants: wants{
+				err:     nil,
+				actions: actions,
+			},
+		},
+		{
+			name: "should write valid rows in one transaction",
+			prepare: func() {
+				pr.On("ListBySeller", mock.Anything, uint(2)).Return(catalog(), nil).Once()
+				pr.On("BeginTransaction", mock.Anything).Return(sellerContext, pr).Once()
+				pr.On("Update", mock.Anything, mock.MatchedBy(func(p *domain.Product) bool {
+					return p.Id == 1 && p.Name == "Cheesecake" && p.Sku == "CK-1"
+				})).Return(nil).Once()
+				pr.On("Update", mock.Anything, mock.MatchedBy(func(p *domain.Product) bool {
+					return p.Id == 2 && p.Count == 10
+				})).Return(nil).Once()
+				pr.On("Insert", mock.Anything, mock.MatchedBy(func(p domain.Product) bool {
+					return p.Sku == "CH-1" && p.SellerId == 2
+				})).Return(uint(3), nil).Once()
+				// only changed and new prices are recorded
+				pr.On("InsertPriceChange", mock.Anything, mock.MatchedBy(func(c domain.PriceChange) bool {
+					return c.ProductId == 1 && c.Old == 10 && c.New == 15 && c.ChangedBy == 2
+				})).Return(nil).Once()
+				pr.On("InsertPriceChange", mock.Anything, mock.MatchedBy(func(c domain.PriceChange) bool {
+					return c.ProductId == 3 && c.Old == 0 && c.New == 20
+				})).Return(nil).Once()
+				pr.On("Commit").Once()
+			},
+			args: args{
+				ctx:  sellerContext,
+				rows: rows,
+			},
+			wants: wants{
+				err:     nil,
+				actions: actions,
+			},
+		},
+		{
+			name:    "should fail when buyer imports",
+			prepare: func() {},
+			args: args{
+				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+					Id: 5, Role: domain.BUYER,
+				}),
+				rows: rows,
+			},
+			wants: wants{
+				err: domain.ErrPermissionDenied,
+			},
+		},
+	}
+
+	svc := product.InitService(pr, nil, nil, nil, nil, nil, time.Minute, "en")
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		report, err := svc.Import(tc.args.ctx, tc.args.rows, tc.args.dryRun)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, report, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		got := make([]string, len(report.Rows))
+		for i, r := range report.Rows {
+			got[i] = r.Action
+		}
+		assert.Equal(t, tc.wants.actions, got, tc.name)
+		assert.Equal(t, 3, report.Failed, tc.name)
+	}
+	pr.AssertExpectations(t)
+}
@@ -26,15 +26,56 @@ func InitProductHandler(e *echo.Echo, auth *echo.Group, ps domain.ProductService
 	pg = auth.Group("/products")
 	pg.POST("/", h.Add)
 	pg.PUT("/:id", h.Update)
+	pg.PUT("/:id/diet", h.UpdateDietInfo)
+	pg.PUT("/:id/translations", h.UpdateTranslations)
+	pg.GET("/:id/prices", h.PriceHistory)
+	pg.GET("/:id/price", h.PriceAt)
 	pg.DELETE("/:id", h.Delete)
 
+	// catalog of seller as csv
+	pg.POST("/import", h.Import)
+	pg.GET("/export", h.Export)
+
 	pg.POST("/buy", h.Buy)
 
+	pg.POST("/reservation", h.Reserve)
+	pg.GET("/reservation", h.GetReservation)
+	pg.DELETE("/reservation", h.ReleaseReservation)
+
+	// admin trash of soft-deleted products
+	tg := auth.Group("/admin/trash/products")
+	tg.GET("/", h.ListDeleted)
+	tg.POST("/:id/restore", h.Restore)
+	tg.DELETE("/:id", h.Purge)
+
+	// purchase quotas, managed by admins and checked by buyers
+	qg := auth.Group("/admin/quotas")
+	qg.GET("/", h.ListQuotas)
+	qg.POST("/", h.AddQuota)
+	qg.DELETE("/:id", h.DeleteQuota)
+	auth.GET("/quotas", h.QuotaStatus)
+
 	return h
 }
 
 func (h *ProductHandler) List(c echo.Context) error {
-	ps, err := h.ps.List(c.Request().Context())
+	req := new(requests.ListProducts)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	langs := httputil.ParseAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+	if lang, ok := domain.NormalizeLang(req.Lang); ok {
+		langs = append([]string{lang}, langs...)
+	}
+
+	ps, err := h.ps.List(c.Request().Context(), domain.ListOptions{
+		HideConflicts: req.Diet == "hide",
+		Langs:         langs,
+	})
 	return checkErrorThenResponse(c, err, ps)
 }
 
@@ -47,9 +88,10 @@ func (h *ProductHandler) Add(c echo.Context) error {
 		))
 	}
 
-	p, err := h.ps.Add(c.Request().Context(),
-		req.Name, req.Count, req.Price,
-	)
+	p, err := h.ps.Add(c.Request().Context(), domain.ProductInput{
+		Sku: req.Sku, Name: req.Name, Description: req.Description,
+		Category: req.Category, Count: req.Count, Price: req.Price,
+	})
 
 	return checkErrorThenResponse(c, err, p)
 }
@@ -69,9 +111,67 @@ func (h *ProductHandler) Update(c echo.Context) error {
 		))
 	}
 
-	p, err := h.ps.Update(c.Request().Context(),
-		uint(id), req.Name, req.Count, req.Price,
-	)
+	p, err := h.ps.Update(c.Request().Context(), uint(id), domain.ProductInput{
+		Sku: req.Sku, Name: req.Name, Description: req.Description,
+		Category: req.Category, Count: req.Count, Price: req.Price,
+	})
+
+	return checkErrorThenResponse(c, err, p)
+}
+
+func (h *ProductHandler) UpdateTranslations(c echo.Context) error {
+	req := new(requests.Translations)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	ts := make(map[string]domain.Translation, len(req.Translations))
+	for lang, t := range req.Translations {
+		ts[lang] = domain.Translation(t)
+	}
+
+	p, err := h.ps.UpdateTranslations(c.Request().Context(), uint(id), ts)
+
+	return checkErrorThenResponse(c, err, p)
+}
+
+func (h *ProductHandler) UpdateDietInfo(c echo.Context) error {
+	req := new(requests.DietInfo)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	info := domain.DietInfo{
+		Nutrition: domain.Nutrition(req.Nutrition),
+		Allergens: make([]domain.Allergen, len(req.Allergens)),
+		Labels:    make([]domain.DietLabel, len(req.Labels)),
+	}
+	for i, a := range req.Allergens {
+		info.Allergens[i] = domain.Allergen(a)
+	}
+	for i, l := range req.Labels {
+		info.Labels[i] = domain.DietLabel(l)
+	}
+
+	p, err := h.ps.UpdateDietInfo(c.Request().Context(), uint(id), info)
 
 	return checkErrorThenResponse(c, err, p)
 }
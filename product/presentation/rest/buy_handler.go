@@ -23,7 +23,10 @@ func (p *ProductHandler) Buy(c echo.Context) error {
 		))
 	}
 
-	bill, err := p.ps.Buy(c.Request().Context(), req.Cart)
+	bill, err := p.ps.Buy(c.Request().Context(), req.Cart, domain.BuyOptions{
+		StrictDiet: req.StrictDiet,
+		Partial:    req.Partial,
+	})
 
 	return checkErrorThenResponse(c, err, bill)
 }
@@ -47,7 +47,7 @@ func TestProductHandler_Buy(t *testing.T) {
 		{
 			name: "200 OK and bill",
 			prepare: func() {
-				ps.On("Buy", mock.Anything, mock.Anything).
+				ps.On("Buy", mock.Anything, mock.Anything, mock.Anything).
 					Return(mockBill, nil).Once()
 			},
 			args: args{map[uint]uint{1: 1, 2: 2}},
@@ -70,7 +70,7 @@ func TestProductHandler_Buy(t *testing.T) {
 		{
 			name: "500 InternalServerError",
 			prepare: func() {
-				ps.On("Buy", mock.Anything, mock.Anything).
+				ps.On("Buy", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, domain.ErrInternalServer).Once()
 			},
 			args: args{map[uint]uint{1: 2}},
@@ -82,7 +82,7 @@ func TestProductHandler_Buy(t *testing.T) {
 		{
 			name: "401 Unauthorized",
 			prepare: func() {
-				ps.On("Buy", mock.Anything, mock.Anything).
+				ps.On("Buy", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, domain.ErrUnauthorized).Once()
 			},
 			args: args{map[uint]uint{1: 2}},
@@ -94,7 +94,7 @@ func TestProductHandler_Buy(t *testing.T) {
 		{
 			name: "403 Forbidden",
 			prepare: func() {
-				ps.On("Buy", mock.Anything, mock.Anything).
+				ps.On("Buy", mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, domain.ErrPermissionDenied).Once()
 			},
 			args: args{map[uint]uint{1: 2}},
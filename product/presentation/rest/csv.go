@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+// columns of catalog files, import ignores the id and unknown columns
+var catalogHeader = []string{"id", "sku", "name", "description", "category", "count", "price"}
+
+// parseCatalog reads product rows of a catalog csv file.
+// Rows which can't be parsed are returned as failed results,
+// so they end up in the import report instead of aborting it.
+func parseCatalog(r io.Reader) ([]domain.ImportRow, []domain.ImportResult, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("csv header: %v", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"name", "count", "price"} {
+		if _, ok := cols[required]; !ok {
+			return nil, nil, fmt.Errorf("csv header: missing %q column", required)
+		}
+	}
+
+	rows := make([]domain.ImportRow, 0)
+	failed := make([]domain.ImportResult, 0)
+	// header is the first line
+	line := 1
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, nil, fmt.Errorf("csv line %d: %v", line, err)
+		}
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		count, err := strconv.ParseUint(field("count"), 10, 32)
+		if err != nil {
+			failed = append(failed, domain.ImportResult{
+				Line: line, Action: domain.ImportFailed, Error: "invalid count",
+			})
+			continue
+		}
+		price, err := strconv.ParseUint(field("price"), 10, 32)
+		if err != nil {
+			failed = append(failed, domain.ImportResult{
+				Line: line, Action: domain.ImportFailed, Error: "invalid price",
+			})
+			continue
+		}
+
+		rows = append(rows, domain.ImportRow{
+			Line: line,
+			ProductInput: domain.ProductInput{
+				Sku:         field("sku"),
+				Name:        field("name"),
+				Description: field("description"),
+				Category:    field("category"),
+				Count:       uint(count),
+				Price:       uint(price),
+			},
+		})
+	}
+
+	return rows, failed, nil
+}
+
+// encodeCatalog writes products in the same layout parseCatalog reads
+func encodeCatalog(ps []domain.Product) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+
+	if err := w.Write(catalogHeader); err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		err := w.Write([]string{
+			strconv.FormatUint(uint64(p.Id), 10),
+			p.Sku,
+			p.Name,
+			p.Description,
+			p.Category,
+			strconv.FormatUint(uint64(p.Count), 10),
+			strconv.FormatUint(uint64(p.Price), 10),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+// Import accepts catalog csv as "file" of a multipart form or as raw body
+func (h *ProductHandler) Import(c echo.Context) error {
+	dryRun := false
+	if q := c.QueryParam("dry_run"); q != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(q)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+				http.StatusBadRequest, "dry_run must be a boolean", nil,
+			))
+		}
+	}
+
+	var body io.Reader = c.Request().Body
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+				http.StatusBadRequest, err.Error(), nil,
+			))
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+				http.StatusBadRequest, err.Error(), nil,
+			))
+		}
+		defer f.Close()
+		body = f
+	}
+
+	rows, failed, err := parseCatalog(body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	report, err := h.ps.Import(c.Request().Context(), rows, dryRun)
+	if err == nil {
+		for _, f := range failed {
+			report.Add(f)
+		}
+		sort.SliceStable(report.Rows, func(i, j int) bool {
+			return report.Rows[i].Line < report.Rows[j].Line
+		})
+	}
+
+	return checkErrorThenResponse(c, err, report)
+}
+
+func (h *ProductHandler) Export(c echo.Context) error {
+	ps, err := h.ps.Export(c.Request().Context())
+	if err != nil {
+		return checkErrorThenResponse(c, err, nil)
+	}
+
+	data, err := encodeCatalog(ps)
+	if err != nil {
+		return checkErrorThenResponse(c, err, nil)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="products.csv"`)
+	return c.Blob(http.StatusOK, "text/csv", data)
+}
@@ -0,0 +1,50 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+func (h *ProductHandler) PriceHistory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	cs, err := h.ps.PriceHistory(c.Request().Context(), uint(id))
+
+	return checkErrorThenResponse(c, err, cs)
+}
+
+// PriceAt returns price in effect at RFC3339 "at" query param, now by default
+func (h *ProductHandler) PriceAt(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	at := time.Now()
+	if q := c.QueryParam("at"); q != "" {
+		at, err = time.Parse(time.RFC3339, q)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+				http.StatusBadRequest, "at must be an RFC3339 time", nil,
+			))
+		}
+	}
+
+	price, err := h.ps.PriceAt(c.Request().Context(), uint(id), at)
+
+	return checkErrorThenResponse(c, err, map[string]interface{}{
+		"product_id": id,
+		"price":      price,
+		"at":         at,
+	})
+}
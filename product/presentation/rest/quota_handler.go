@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/product/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (h *ProductHandler) AddQuota(c echo.Context) error {
+	req := new(requests.QuotaRule)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	r, err := h.ps.AddQuota(c.Request().Context(), domain.QuotaRule{
+		Name:      req.Name,
+		ProductId: req.ProductId,
+		Category:  req.Category,
+		Limit:     req.Limit,
+		Period:    req.Period,
+		Rolling:   req.Rolling,
+		BuyerIds:  req.BuyerIds,
+	})
+
+	return checkErrorThenResponse(c, err, r)
+}
+
+func (h *ProductHandler) ListQuotas(c echo.Context) error {
+	rules, err := h.ps.ListQuotas(c.Request().Context())
+	return checkErrorThenResponse(c, err, rules)
+}
+
+func (h *ProductHandler) DeleteQuota(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	err = h.ps.DeleteQuota(c.Request().Context(), uint(id))
+
+	return checkErrorThenResponse(c, err, nil)
+}
+
+func (h *ProductHandler) QuotaStatus(c echo.Context) error {
+	status, err := h.ps.QuotaStatus(c.Request().Context())
+	return checkErrorThenResponse(c, err, status)
+}
@@ -2,12 +2,73 @@ package requests
 
 // we use it for update product too
 type AddProduct struct {
-	Name  string `json:"name" validate:"required,alphanum"`
-	Count uint   `json:"count" validate:"required,gt=0"`
-	Price uint   `json:"price" validate:"required,gte=5"`
+	// optional external identifier, unique per seller
+	Sku         string `json:"sku" validate:"omitempty,max=64"`
+	Name        string `json:"name" validate:"required,productname"`
+	Description string `json:"description" validate:"max=1024"`
+	// groups products for quota rules
+	Category string `json:"category" validate:"max=32"`
+	Count    uint   `json:"count" validate:"required,gt=0"`
+	Price    uint   `json:"price" validate:"required,gte=5"`
+}
+
+type ListProducts struct {
+	// flag(default) marks products conflicting with dietary profile
+	// of the caller, hide removes them from the list
+	Diet string `query:"diet" validate:"omitempty,oneof=flag hide"`
+	// language of names and descriptions, overrides Accept-Language
+	Lang string `query:"lang" validate:"omitempty,max=16"`
+}
+
+type Translation struct {
+	Name        string `json:"name" validate:"required,productname"`
+	Description string `json:"description" validate:"max=1024"`
+}
+
+type Translations struct {
+	// map of language => translation
+	Translations map[string]Translation `json:"translations" validate:"required,dive"`
+}
+
+type DietInfo struct {
+	Nutrition struct {
+		ServingSize  uint    `json:"serving_size_g"`
+		Energy       uint    `json:"energy_kcal"`
+		Fat          float64 `json:"fat_g" validate:"gte=0"`
+		SaturatedFat float64 `json:"saturated_fat_g" validate:"gte=0"`
+		Carbohydrate float64 `json:"carbohydrate_g" validate:"gte=0"`
+		Sugars       float64 `json:"sugars_g" validate:"gte=0"`
+		Protein      float64 `json:"protein_g" validate:"gte=0"`
+		Salt         float64 `json:"salt_g" validate:"gte=0"`
+	} `json:"nutrition"`
+	Allergens []string `json:"allergens"`
+	Labels    []string `json:"labels"`
 }
 
 type Buy struct {
 	// map of product id => count
 	Cart map[uint]uint `json:"cart" validate:"required"`
+	// refuse products conflicting with dietary profile
+	StrictDiet bool `json:"strict_diet"`
+	// buy whatever can be fulfilled
+	Partial bool `json:"partial"`
+}
+
+type Reserve struct {
+	// map of product id => count
+	Cart map[uint]uint `json:"cart" validate:"required"`
+	// seconds to hold the stock, zero means the maximum
+	TTL uint `json:"ttl"`
+}
+
+type QuotaRule struct {
+	Name string `json:"name" validate:"required,max=64"`
+	// one of product id and category is required
+	ProductId uint   `json:"product_id" validate:"required_without=Category"`
+	Category  string `json:"category" validate:"required_without=ProductId,max=32"`
+	Limit     uint   `json:"limit" validate:"required,gt=0"`
+	Period    string `json:"period" validate:"required,oneof=day week month"`
+	Rolling   bool   `json:"rolling"`
+	// empty applies the rule to all buyers
+	BuyerIds []uint `json:"buyer_ids"`
 }
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/product/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (p *ProductHandler) Reserve(c echo.Context) error {
+	req := new(requests.Reserve)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+	if len(req.Cart) == 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, domain.ErrInvalidParams.Error(), nil,
+		))
+	}
+
+	r, err := p.ps.Reserve(c.Request().Context(), req.Cart,
+		time.Duration(req.TTL)*time.Second,
+	)
+
+	return checkErrorThenResponse(c, err, r)
+}
+
+func (p *ProductHandler) GetReservation(c echo.Context) error {
+	r, err := p.ps.GetReservation(c.Request().Context())
+	return checkErrorThenResponse(c, err, r)
+}
+
+func (p *ProductHandler) ReleaseReservation(c echo.Context) error {
+	err := p.ps.ReleaseReservation(c.Request().Context())
+	return checkErrorThenResponse(c, err, nil)
+}
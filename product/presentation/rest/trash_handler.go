@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+func (h *ProductHandler) ListDeleted(c echo.Context) error {
+	ps, err := h.ps.ListDeleted(c.Request().Context())
+	return checkErrorThenResponse(c, err, ps)
+}
+
+func (h *ProductHandler) Restore(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	p, err := h.ps.Restore(c.Request().Context(), uint(id))
+
+	return checkErrorThenResponse(c, err, p)
+}
+
+func (h *ProductHandler) Purge(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	err = h.ps.Purge(c.Request().Context(), uint(id))
+
+	return checkErrorThenResponse(c, err, nil)
+}
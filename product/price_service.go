@@ -0,0 +1,60 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func (s *Service) PriceHistory(ctx context.Context, id uint) ([]domain.PriceChange, error) {
+	const op string = "product.service.PriceHistory"
+
+	if _, err := s.pr.FindById(ctx, id); err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrProductNotFound
+	}
+
+	cs, err := s.pr.ListPriceChanges(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return cs, nil
+}
+
+func (s *Service) PriceAt(ctx context.Context, id uint, at time.Time) (uint, error) {
+	const op string = "product.service.PriceAt"
+
+	p, err := s.pr.FindById(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return 0, domain.ErrProductNotFound
+	}
+
+	cs, err := s.pr.ListPriceChanges(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return 0, domain.ErrInternalServer
+	}
+
+	return domain.PriceAt(cs, p.Price, at), nil
+}
+
+// recordPrice appends price change of the product to its history,
+// nothing is recorded when price stays the same
+func recordPrice(ctx context.Context, pr domain.ProductRepository, p *domain.Product, old, by uint) error {
+	if old == p.Price {
+		return nil
+	}
+	return pr.InsertPriceChange(ctx, domain.PriceChange{
+		ProductId: p.Id,
+		Old:       old,
+		New:       p.Price,
+		ChangedBy: by,
+		ChangedAt: time.Now(),
+	})
+}
@@ -0,0 +1,51 @@
+package product_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_PriceAt(t *testing.T) {
+	type testCase struct {
+		name  string
+		at    time.Time
+		price uint
+	}
+
+	pr := new(mocks.ProductRepository)
+
+	day := func(d int) time.Time {
+		return time.Date(2021, time.November, d, 12, 0, 0, 0, time.UTC)
+	}
+	pr.On("FindById", mock.Anything, uint(1)).
+		Return(&domain.Product{Id: 1, Name: "Cake", Price: 20}, nil)
+	pr.On("ListPriceChanges", mock.Anything, uint(1)).Return([]domain.PriceChange{
+		{ProductId: 1, Old: 0, New: 10, ChangedAt: day(1)},
+		{ProductId: 1, Old: 10, New: 15, ChangedAt: day(5)},
+		{ProductId: 1, Old: 15, New: 20, ChangedAt: day(9)},
+	}, nil)
+
+	testCases := []testCase{
+		{name: "should use first price before product was added", at: day(1).Add(-time.Hour), price: 10},
+		{name: "should use price set at the exact time", at: day(5), price: 15},
+		{name: "should use price in effect between changes", at: day(7), price: 15},
+		{name: "should use latest price after last change", at: day(20), price: 20},
+	}
+
+	svc := product.InitService(pr, nil, nil, nil, nil, nil, time.Minute, "en")
+
+	for _, tc := range testCases {
+		// action
+		price, err := svc.PriceAt(context.Background(), 1, tc.at)
+		// assert
+		assert.NoError(t, err, tc.name)
+		assert.EqualValues(t, tc.price, price, tc.name)
+	}
+}
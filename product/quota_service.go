@@ -0,0 +1,204 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+func (s *Service) AddQuota(ctx context.Context, r domain.QuotaRule) (*domain.QuotaRule, error) {
+	const op string = "product.service.AddQuota"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if r.ProductId != 0 {
+		if _, err := s.pr.FindById(ctx, r.ProductId); err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrProductNotFound
+		}
+	}
+
+	var err error
+	r.Id, err = s.qr.Insert(ctx, r)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return &r, nil
+}
+
+func (s *Service) ListQuotas(ctx context.Context) ([]domain.QuotaRule, error) {
+	const op string = "product.service.ListQuotas"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.qr.List(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return rules, nil
+}
+
+func (s *Service) DeleteQuota(ctx context.Context, id uint) error {
+	const op string = "product.service.DeleteQuota"
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	err := s.qr.Delete(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidParams) {
+			return domain.ErrInvalidParams
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+func (s *Service) QuotaStatus(ctx context.Context) ([]domain.QuotaStatus, error) {
+	const op string = "product.service.QuotaStatus"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if u.Role != domain.BUYER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	q, err := s.quotasOf(ctx, u.Id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]domain.QuotaStatus, 0, len(q.rules))
+	for _, r := range q.rules {
+		used, err := q.used(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		status := domain.QuotaStatus{
+			Rule:     r,
+			Used:     used,
+			Since:    r.WindowStart(q.now),
+			ResetsAt: r.ResetsAt(q.now),
+		}
+		if used < r.Limit {
+			status.Remaining = r.Limit - used
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// quotas tracks quota rules of a buyer during a purchase
+type quotas struct {
+	s       *Service
+	buyerId uint
+	now     time.Time
+	rules   []domain.QuotaRule
+	// rule id => units counted against it, loaded lazily
+	usage map[uint]uint
+}
+
+// quotasOf returns rules which may apply to the buyer
+func (s *Service) quotasOf(ctx context.Context, buyerId uint, now time.Time) (*quotas, error) {
+	const op string = "product.service.quotasOf"
+
+	rules, err := s.qr.List(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	q := &quotas{s: s, buyerId: buyerId, now: now, usage: make(map[uint]uint)}
+	for _, r := range rules {
+		if r.AppliesToBuyer(buyerId) {
+			q.rules = append(q.rules, r)
+		}
+	}
+
+	return q, nil
+}
+
+// used returns units of the rule bought in its window
+func (q *quotas) used(ctx context.Context, r domain.QuotaRule) (uint, error) {
+	const op string = "product.service.quotas.used"
+
+	if n, ok := q.usage[r.Id]; ok {
+		return n, nil
+	}
+
+	ids := []uint{r.ProductId}
+	if r.ProductId == 0 {
+		var err error
+		ids, err = q.s.pr.IdsByCategory(ctx, r.Category)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return 0, domain.ErrInternalServer
+		}
+	}
+
+	n, err := q.s.or.UnitsBought(ctx, q.buyerId, ids, r.WindowStart(q.now))
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return 0, domain.ErrInternalServer
+	}
+	q.usage[r.Id] = n
+
+	return n, nil
+}
+
+// allow returns how many of count units of the product quotas allow.
+// When it's less, the error wraps ErrQuotaExceeded naming the most
+// restrictive rule, other errors are internal failures.
+func (q *quotas) allow(ctx context.Context, p *domain.Product, count uint) (uint, error) {
+	allowed := count
+	var violation error
+	for _, r := range q.rules {
+		if !r.AppliesTo(q.buyerId, p) {
+			continue
+		}
+		used, err := q.used(ctx, r)
+		if err != nil {
+			return 0, err
+		}
+		var left uint
+		if used < r.Limit {
+			left = r.Limit - used
+		}
+		if left < allowed {
+			allowed = left
+			violation = errors.Wrapf(domain.ErrQuotaExceeded,
+				"rule %d %q allows %d per %s, %d left", r.Id, r.Name, r.Limit, r.Period, left)
+		}
+	}
+	return allowed, violation
+}
+
+// use counts planned units of the product against its rules,
+// allow must be called for the product first to load the usage
+func (q *quotas) use(p *domain.Product, count uint) {
+	for _, r := range q.rules {
+		if r.AppliesTo(q.buyerId, p) {
+			q.usage[r.Id] += count
+		}
+	}
+}
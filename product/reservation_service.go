@@ -0,0 +1,156 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Reserve holds stock of the cart for the buyer until ttl passes,
+// it replaces previous reservation of the buyer
+func (s *Service) Reserve(ctx context.Context, cart map[uint]uint, ttl time.Duration) (*domain.Reservation, error) {
+	const op string = "product.service.Reserve"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if u.Role != domain.BUYER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	if ttl <= 0 || ttl > s.rttl {
+		ttl = s.rttl
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	// expired reservations don't hold anything, just cleaning them up
+	err = s.rr.DeleteExpired(ctx)
+	if err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+	}
+
+	held, err := s.rr.HeldCounts(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	for pid, count := range cart {
+		p, err := s.pr.FindById(ctx, pid)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrProductNotFound
+		}
+		if available(p, held) < count {
+			return nil, domain.ErrInsufficientProductsAmount
+		}
+	}
+
+	r := &domain.Reservation{
+		UserId:    u.Id,
+		Items:     cart,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	ctx, rr := s.rr.BeginTransaction(ctx)
+
+	err = rr.DeleteByUser(ctx, u.Id)
+	if err != nil {
+		rr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	r.Id, err = rr.Insert(ctx, *r)
+	if err != nil {
+		rr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	rr.Commit()
+	return r, nil
+}
+
+func (s *Service) GetReservation(ctx context.Context) (*domain.Reservation, error) {
+	const op string = "product.service.GetReservation"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	r, err := s.rr.FindActiveByUser(ctx, u.Id)
+	if err != nil {
+		if errors.Is(err, domain.ErrReservationNotFound) {
+			return nil, domain.ErrReservationNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return r, nil
+}
+
+func (s *Service) ReleaseReservation(ctx context.Context) error {
+	const op string = "product.service.ReleaseReservation"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	err = s.rr.DeleteByUser(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+// consumeReservation removes bought products from
+// active reservation of the buyer, if there is any
+func consumeReservation(ctx context.Context, rr domain.ReservationRepository, userId uint, bought map[uint]uint) error {
+	r, err := rr.FindActiveByUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, domain.ErrReservationNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	for pid, count := range bought {
+		if r.Items[pid] <= count {
+			delete(r.Items, pid)
+		} else {
+			r.Items[pid] -= count
+		}
+	}
+
+	if len(r.Items) == 0 {
+		return rr.DeleteByUser(ctx, userId)
+	}
+	return rr.Update(ctx, r)
+}
+
+// available returns amount of product which is not held by others
+func available(p *domain.Product, held map[uint]uint) uint {
+	if held[p.Id] >= p.Count {
+		return 0
+	}
+	return p.Count - held[p.Id]
+}
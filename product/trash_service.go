@@ -0,0 +1,129 @@
+package product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// ListDeleted returns soft-deleted products (ADMIN only)
+func (s *Service) ListDeleted(ctx context.Context) ([]domain.Product, error) {
+	const op string = "product.service.ListDeleted"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	ps, err := s.pr.ListDeleted(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return ps, nil
+}
+
+// Restore brings back soft-deleted product if its seller is still
+// active and has no other product with the same name (ADMIN only)
+func (s *Service) Restore(ctx context.Context, id uint) (*domain.Product, error) {
+	const op string = "product.service.Restore"
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	p, err := s.pr.FindDeletedById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, domain.ErrProductNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	// product can't come back without an owner
+	seller, err := s.ur.FindById(ctx, p.SellerId)
+	if err != nil || seller.Role != domain.SELLER {
+		return nil, errors.Wrapf(domain.ErrRestoreConflict, "seller %d is not active", p.SellerId)
+	}
+
+	// seller may have added a replacement meanwhile
+	ps, err := s.pr.ListBySeller(ctx, p.SellerId)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	for _, sp := range ps {
+		if sp.Name == p.Name {
+			return nil, errors.Wrapf(domain.ErrRestoreConflict, "product %d has the same name", sp.Id)
+		}
+	}
+	// sku may have been reused meanwhile too
+	if err := s.checkSku(ctx, p.SellerId, p.Id, p.Sku); err != nil {
+		return nil, err
+	}
+
+	err = s.pr.Restore(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	p.DeletedAt = nil
+	logger.Log(logger.INFO, fmt.Sprintf("product %d restored", id))
+
+	return p, nil
+}
+
+// Purge permanently deletes soft-deleted product (ADMIN only)
+func (s *Service) Purge(ctx context.Context, id uint) error {
+	const op string = "product.service.Purge"
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	s.pl.Lock()
+	defer s.pl.Unlock()
+
+	// only products in trash can be purged
+	_, err := s.pr.FindDeletedById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return domain.ErrProductNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	err = s.pr.Purge(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("product %d purged", id))
+
+	return nil
+}
+
+func requireAdmin(ctx context.Context) error {
+	const op string = "product.service.requireAdmin"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	if u.Role != domain.ADMIN {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package product_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/product"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Restore(t *testing.T) {
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wants   wants
+	}
+
+	pr := new(mocks.ProductRepository)
+	ur := new(mocks.UserRepository)
+
+	adminContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 1, Role: domain.ADMIN,
+	})
+	deletedAt := time.Now()
+	newDeleted := func() *domain.Product {
+		return &domain.Product{Id: 3, Sku: "CK-1", Name: "Cake", SellerId: 2, DeletedAt: &deletedAt}
+	}
+	seller := &domain.User{Id: 2, Role: domain.SELLER}
+
+	testCases := []testCase{
+		{
+			name: "should restore product of an active seller",
+			prepare: func() {
+				pr.On("FindDeletedById", mock.Anything, uint(3)).Return(newDeleted(), nil).Once()
+				ur.On("FindById", mock.Anything, uint(2)).Return(seller, nil).Once()
+				pr.On("ListBySeller", mock.Anything, uint(2)).
+					Return([]domain.Product{{Id: 4, Name: "Soda", SellerId: 2}}, nil).Once()
+				pr.On("FindBySellerAndSku", mock.Anything, uint(2), "CK-1").
+					Return(nil, domain.ErrProductNotFound).Once()
+				pr.On("Restore", mock.Anything, uint(3)).Return(nil).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: nil},
+		},
+		{
+			name: "should fail when sku was reused meanwhile",
+			prepare: func() {
+				pr.On("FindDeletedById", mock.Anything, uint(3)).Return(newDeleted(), nil).Once()
+				ur.On("FindById", mock.Anything, uint(2)).Return(seller, nil).Once()
+				pr.On("ListBySeller", mock.Anything, uint(2)).
+					Return([]domain.Product{{Id: 4, Name: "Soda", SellerId: 2}}, nil).Once()
+				pr.On("FindBySellerAndSku", mock.Anything, uint(2), "CK-1").
+					Return(&domain.Product{Id: 5, Sku: "CK-1", SellerId: 2}, nil).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: domain.ErrDuplicateSku},
+		},
+		{
+			name: "should fail when seller has an active product with the same name",
+			prepare: func() {
+				pr.On("FindDeletedById", mock.Anything, uint(3)).Return(newDeleted(), nil).Once()
+				ur.On("FindById", mock.Anything, uint(2)).Return(seller, nil).Once()
+				pr.On("ListBySeller", mock.Anything, uint(2)).
+					Return([]domain.Product{{Id: 4, Name: "Cake", SellerId: 2}}, nil).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: domain.ErrRestoreConflict},
+		},
+		{
+			name: "should fail when seller of the product is deleted",
+			prepare: func() {
+				pr.On("FindDeletedById", mock.Anything, uint(3)).Return(newDeleted(), nil).Once()
+				ur.On("FindById", mock.Anything, uint(2)).
+					Return(nil, errors.New("record not found")).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: domain.ErrRestoreConflict},
+		},
+		{
+			name:    "should fail when non admin restores",
+			prepare: func() {},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 2, Role: domain.SELLER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := product.InitService(pr, ur, nil, nil, nil, nil, time.Minute, "en")
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		p, err := svc.Restore(tc.ctx, 3)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, p, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+			assert.Nil(t, p.DeletedAt, tc.name)
+		}
+	}
+	pr.AssertExpectations(t)
+	ur.AssertExpectations(t)
+}
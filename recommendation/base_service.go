@@ -0,0 +1,133 @@
+package recommendation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLimit = 5
+	maxLimit     = 20
+)
+
+type Service struct {
+	or domain.OrderRepository
+	pr domain.ProductRepository
+	// model is nil until the first build
+	m  *model
+	ml sync.RWMutex
+}
+
+func InitService(
+	or domain.OrderRepository,
+	pr domain.ProductRepository,
+) domain.RecommendationService {
+	return &Service{or: or, pr: pr}
+}
+
+func (s *Service) For(ctx context.Context, opts domain.RecommendationOptions) (*domain.Recommendations, error) {
+	const op string = "recommendation.service.For"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if u.Role != domain.BUYER {
+		return nil, domain.ErrPermissionDenied
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = defaultLimit
+	}
+	if opts.Limit > maxLimit {
+		opts.Limit = maxLimit
+	}
+
+	s.ml.RLock()
+	m := s.m
+	s.ml.RUnlock()
+	// first request builds the model when no rebuild happened yet
+	if m == nil {
+		if err = s.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		s.ml.RLock()
+		m = s.m
+		s.ml.RUnlock()
+	}
+
+	ps, err := s.pr.List(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	// out of stock and deleted products are never suggested
+	inStock := make(map[uint]domain.Product, len(ps))
+	for _, p := range ps {
+		if p.Count > 0 {
+			inStock[p.Id] = p
+		}
+	}
+	pick := func(ids []uint) []domain.Product {
+		result := make([]domain.Product, 0, opts.Limit)
+		for _, id := range ids {
+			if len(result) == opts.Limit {
+				break
+			}
+			if p, ok := inStock[id]; ok {
+				result = append(result, p)
+			}
+		}
+		return result
+	}
+
+	favourites := m.favourites(u.Id)
+	base := favourites
+	if opts.ProductId != 0 {
+		base = []uint{opts.ProductId}
+	}
+
+	return &domain.Recommendations{
+		Favourites:     pick(favourites),
+		BoughtTogether: pick(m.boughtWith(base)),
+		BuiltAt:        m.builtAt,
+	}, nil
+}
+
+func (s *Service) Rebuild(ctx context.Context) error {
+	const op string = "recommendation.service.Rebuild"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	if u.Role != domain.ADMIN {
+		return domain.ErrPermissionDenied
+	}
+
+	return s.Refresh(ctx)
+}
+
+func (s *Service) Refresh(ctx context.Context) error {
+	const op string = "recommendation.service.Refresh"
+
+	orders, err := s.or.List(ctx, domain.OrderFilter{})
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	m := buildModel(orders, time.Now())
+
+	s.ml.Lock()
+	s.m = m
+	s.ml.Unlock()
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package recommendation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/recommendation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_For(t *testing.T) {
+	type wants struct {
+		err            error
+		favourites     []uint
+		boughtTogether []uint
+	}
+	type testCase struct {
+		name  string
+		ctx   context.Context
+		opts  domain.RecommendationOptions
+		wants wants
+	}
+
+	or := new(mocks.OrderRepository)
+	pr := new(mocks.ProductRepository)
+
+	buyerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 5, Role: domain.BUYER,
+	})
+
+	item := func(pid, count, refunded uint) domain.OrderItem {
+		return domain.OrderItem{ProductId: pid, Count: count, Refunded: refunded}
+	}
+	or.On("List", mock.Anything, domain.OrderFilter{}).Return([]domain.Order{
+		{BuyerId: 5, Items: []domain.OrderItem{item(1, 3, 0), item(2, 1, 0)}},
+		{BuyerId: 5, Items: []domain.OrderItem{item(2, 1, 0), item(4, 2, 2)}},
+		{BuyerId: 6, Items: []domain.OrderItem{item(1, 1, 0), item(3, 1, 0)}},
+		{BuyerId: 7, Items: []domain.OrderItem{item(1, 1, 0), item(3, 1, 0), item(5, 1, 0)}},
+		{BuyerId: 8, Items: []domain.OrderItem{item(2, 1, 0), item(5, 1, 0)}},
+	}, nil).Once()
+	pr.On("List", mock.Anything).Return([]domain.Product{
+		{Id: 1, Name: "Cake", Count: 4},
+		{Id: 2, Name: "Soda", Count: 0},
+		{Id: 3, Name: "Tea", Count: 2},
+		{Id: 4, Name: "Gum", Count: 9},
+		{Id: 5, Name: "Chips", Count: 1},
+	}, nil)
+
+	testCases := []testCase{
+		{
+			name: "should suggest in stock favourites and products bought with them",
+			ctx:  buyerContext,
+			wants: wants{
+				// soda is out of stock, refunded gum doesn't count
+				favourites:     []uint{1},
+				boughtTogether: []uint{3, 5},
+			},
+		},
+		{
+			name: "should suggest products bought with the requested one",
+			ctx:  buyerContext,
+			opts: domain.RecommendationOptions{ProductId: 3, Limit: 1},
+			wants: wants{
+				favourites:     []uint{1},
+				boughtTogether: []uint{1},
+			},
+		},
+		{
+			name: "should fail when seller asks for recommendations",
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 2, Role: domain.SELLER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	svc := recommendation.InitService(or, pr)
+
+	for _, tc := range testCases {
+		// action
+		r, err := svc.For(tc.ctx, tc.opts)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, r, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		ids := func(ps []domain.Product) []uint {
+			result := make([]uint, len(ps))
+			for i, p := range ps {
+				result[i] = p.Id
+			}
+			return result
+		}
+		assert.Equal(t, tc.wants.favourites, ids(r.Favourites), tc.name)
+		assert.Equal(t, tc.wants.boughtTogether, ids(r.BoughtTogether), tc.name)
+	}
+	// model is built once and reused
+	or.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package recommendation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+// model is an in-memory snapshot of purchase history
+type model struct {
+	// buyer id => product id => units bought and not refunded
+	bought map[uint]map[uint]uint
+	// product id => product id => number of orders containing both
+	together map[uint]map[uint]uint
+	builtAt  time.Time
+}
+
+func buildModel(orders []domain.Order, now time.Time) *model {
+	m := &model{
+		bought:   make(map[uint]map[uint]uint),
+		together: make(map[uint]map[uint]uint),
+		builtAt:  now,
+	}
+
+	for _, o := range orders {
+		// products kept by the buyer in this order
+		kept := make([]uint, 0, len(o.Items))
+		for _, item := range o.Items {
+			count := item.Refundable()
+			if count == 0 {
+				continue
+			}
+			if m.bought[o.BuyerId] == nil {
+				m.bought[o.BuyerId] = make(map[uint]uint)
+			}
+			if m.bought[o.BuyerId][item.ProductId] == 0 {
+				kept = append(kept, item.ProductId)
+			}
+			m.bought[o.BuyerId][item.ProductId] += count
+		}
+
+		for _, a := range kept {
+			for _, b := range kept {
+				if a == b {
+					continue
+				}
+				if m.together[a] == nil {
+					m.together[a] = make(map[uint]uint)
+				}
+				m.together[a][b]++
+			}
+		}
+	}
+
+	return m
+}
+
+// favourites returns products of the buyer by units bought
+func (m *model) favourites(buyerId uint) []uint {
+	return ranked(m.bought[buyerId])
+}
+
+// boughtWith returns products most often bought with any of the given
+// products, the given ones themselves are excluded
+func (m *model) boughtWith(productIds []uint) []uint {
+	scores := make(map[uint]uint)
+	for _, pid := range productIds {
+		for other, n := range m.together[pid] {
+			scores[other] += n
+		}
+	}
+	for _, pid := range productIds {
+		delete(scores, pid)
+	}
+	return ranked(scores)
+}
+
+// ranked returns keys by descending score, ties by ascending id
+func ranked(scores map[uint]uint) []uint {
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
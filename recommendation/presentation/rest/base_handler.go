@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/recommendation/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+type RecommendationHandler struct {
+	rs domain.RecommendationService
+}
+
+// InitRecommendationHandler
+// auth echo group which uses auth middleware
+func InitRecommendationHandler(auth *echo.Group, rs domain.RecommendationService) *RecommendationHandler {
+	h := &RecommendationHandler{rs: rs}
+
+	auth.GET("/recommendations", h.For)
+	auth.POST("/admin/recommendations/rebuild", h.Rebuild)
+
+	return h
+}
+
+func (h *RecommendationHandler) For(c echo.Context) error {
+	req := new(requests.Recommendations)
+	err := httputil.BindAndValidate(c, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	r, err := h.rs.For(c.Request().Context(), domain.RecommendationOptions{
+		ProductId: req.ProductId,
+		Limit:     req.Limit,
+	})
+
+	return checkErrorThenResponse(c, err, r)
+}
+
+func (h *RecommendationHandler) Rebuild(c echo.Context) error {
+	err := h.rs.Rebuild(c.Request().Context())
+	return checkErrorThenResponse(c, err, nil)
+}
+
+func checkErrorThenResponse(c echo.Context, err error, content interface{}) error {
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", content,
+	))
+}
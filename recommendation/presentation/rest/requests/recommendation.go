@@ -0,0 +1,8 @@
+package requests
+
+type Recommendations struct {
+	// suggest products bought together with this one
+	ProductId uint `query:"product_id"`
+	// maximum products of each list
+	Limit int `query:"limit" validate:"gte=0,lte=20"`
+}
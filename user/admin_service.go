@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// SetRole changes role of the user (ADMIN only)
+func (s *Service) SetRole(ctx context.Context, id uint, role domain.Role) (*domain.User, error) {
+	const op string = "user.service.SetRole"
+
+	if !role.Valid() {
+		return nil, errors.Wrapf(domain.ErrInvalidParams, "unknown role %q", role)
+	}
+	user, err := s.otherUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	err = s.ur.UpdateRole(ctx, user.Id, role)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	old := user.Role
+	user.Role = role
+
+	logger.Log(logger.INFO, fmt.Sprintf("role of %s changed from %s to %s", user.Username, old, role))
+	return user, nil
+}
+
+// SetDisabled disables or re-enables the user (ADMIN only),
+// sessions of disabled users are terminated
+func (s *Service) SetDisabled(ctx context.Context, id uint, disabled bool) (*domain.User, error) {
+	const op string = "user.service.SetDisabled"
+
+	user, err := s.otherUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() == disabled {
+		return user, nil
+	}
+
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	err = s.ur.SetDisabledAt(ctx, user.Id, disabledAt)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	user.DisabledAt = disabledAt
+
+	if disabled {
+		// Authorize rejects them anyway, but refresh tokens are useless from now
+		err = s.jr.DeleteTokensOfUserExcept(ctx, user.Id, "")
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrInternalServer
+		}
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("%s disabled: %t", user.Username, disabled))
+	return user, nil
+}
+
+// BootstrapAdmin creates the admin when there is no admin yet,
+// it's called on start so the first admin doesn't need database access
+func (s *Service) BootstrapAdmin(ctx context.Context, uname, pass string) error {
+	const op string = "user.service.BootstrapAdmin"
+
+	if uname == "" {
+		return errors.Errorf("%s: admin username is required", op)
+	}
+	if err := s.pp.Validate(uname, pass); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	counts, err := s.ur.CountByRole(ctx)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if counts[domain.ADMIN] > 0 {
+		return nil
+	}
+
+	user, err := domain.NewUser(uname, pass, domain.ADMIN)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	_, err = s.ur.Insert(ctx, *user)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("admin %s bootstrapped", uname))
+	return nil
+}
+
+// otherUser returns the user of id for the admin in context, admins can't
+// change role of or disable themselves so there is always an admin left
+func (s *Service) otherUser(ctx context.Context, id uint) (*domain.User, error) {
+	user, self, err := s.targetUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if self {
+		if user.Role != domain.ADMIN {
+			return nil, domain.ErrPermissionDenied
+		}
+		return nil, errors.Wrap(domain.ErrInvalidParams, "admins can't change their own account")
+	}
+	return user, nil
+}
@@ -0,0 +1,128 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_SetDisabled(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		id       uint
+		disabled bool
+	}
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+
+	admin := &domain.User{Id: 1, Role: domain.ADMIN}
+	adminContext := context.WithValue(context.Background(), domain.USER, admin)
+	buyerContext := context.WithValue(context.Background(), domain.USER, &domain.User{
+		Id: 2, Role: domain.BUYER,
+	})
+
+	testCases := []testCase{
+		{
+			name: "should disable user and terminate its sessions",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindById", mock.Anything, uint(2)).
+					Return(&domain.User{Id: 2, Role: domain.BUYER}, nil).Once()
+				ur.On("SetDisabledAt", mock.Anything, uint(2), mock.MatchedBy(func(at *time.Time) bool {
+					return at != nil
+				})).Return(nil).Once()
+				jr.On("DeleteTokensOfUserExcept", mock.Anything, uint(2), "").Return(nil).Once()
+			},
+			args: args{
+				ctx:      adminContext,
+				id:       2,
+				disabled: true,
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+		{
+			name: "should re-enable user",
+			prepare: func() {
+				now := time.Now()
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindById", mock.Anything, uint(3)).
+					Return(&domain.User{Id: 3, Role: domain.SELLER, DisabledAt: &now}, nil).Once()
+				ur.On("SetDisabledAt", mock.Anything, uint(3), (*time.Time)(nil)).Return(nil).Once()
+			},
+			args: args{
+				ctx: adminContext,
+				id:  3,
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+		{
+			name: "should fail when admin disables itself",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+			},
+			args: args{
+				ctx:      adminContext,
+				id:       1,
+				disabled: true,
+			},
+			wants: wants{
+				err: domain.ErrInvalidParams,
+			},
+		},
+		{
+			name: "should fail when buyer disables another user",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(2)).
+					Return(&domain.User{Id: 2, Role: domain.BUYER}, nil).Once()
+			},
+			args: args{
+				ctx:      buyerContext,
+				id:       3,
+				disabled: true,
+			},
+			wants: wants{
+				err: domain.ErrPermissionDenied,
+			},
+		},
+	}
+
+	user.UserService = nil
+	svc := user.InitService(ur, jr, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		u, err := svc.SetDisabled(tc.args.ctx, tc.args.id, tc.args.disabled)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, u, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.args.disabled, u.Disabled(), tc.name)
+	}
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+}
@@ -3,80 +3,128 @@ package user
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/logger"
 	"github.com/pkg/errors"
 )
 
-// Register creates new user and return jwt token or error
-func (s *Service) Register(ctx context.Context, uname, pass string, role domain.Role) (string, error) {
+// Register creates new user and return token pair or error,
+// users of roles which must use 2FA get an enrolment challenge instead
+func (s *Service) Register(ctx context.Context, uname, pass string, role domain.Role) (*domain.LoginResult, error) {
 	const op string = "user.service.Register"
+
+	if err := s.pp.Validate(uname, pass); err != nil {
+		return nil, err
+	}
 	// create domain user object
 	user, err := domain.NewUser(uname, pass, role)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
 	// persist user
 	user.Id, err = s.ur.Insert(ctx, *user)
 	if err != nil {
 		if errors.Is(err, domain.ErrUserAlreadyExists) {
-			return "", domain.ErrUserAlreadyExists
+			return nil, domain.ErrUserAlreadyExists
 		}
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
 
-	// generate jwt token with user claims
-	token, err := s.jwt.Generate(user)
+	logger.Log(logger.INFO, fmt.Sprintf("%s registered", uname))
+
+	challenge, err := s.secondFactor(ctx, user, time.Now())
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
+	}
+	if challenge != nil {
+		return &domain.LoginResult{Challenge: challenge}, nil
 	}
 
-	// persist jwt token
-	err = s.jr.Insert(ctx, user.Id, token, s.jwt.tokenDuration)
+	// generate and persist tokens of a new family
+	pair, err := s.startSession(ctx, user)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
 
-	logger.Log(logger.INFO, fmt.Sprintf("%s registered", uname))
-
-	return token, nil
+	return &domain.LoginResult{TokenPair: pair}, nil
 }
 
-// Login checks credentials, generate and return jwt token and a boolean
-// which says is there another active session using this account or not
-func (s *Service) Login(ctx context.Context, uname, pass string) (string, bool, error) {
+// Login checks credentials, generate and return token pair and whether
+// there is another active session using this account or not,
+// users with 2FA get a challenge instead of tokens
+func (s *Service) Login(ctx context.Context, uname, pass string) (*domain.LoginResult, error) {
 	const op string = "user.service.Login"
+
+	now := time.Now()
+	keys := s.loginKeys(ctx, uname)
+	counts, err := s.reserveLogin(ctx, keys, now)
+	if err != nil {
+		return nil, err
+	}
 	// fetch user from db
 	user, err := s.ur.FindByUsername(ctx, uname)
-	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			return "", false, domain.ErrUserNotFound
-		}
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		s.releaseLogin(ctx, keys)
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", false, domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
+	}
+	// check password, unknown users fail exactly like wrong passwords
+	// so the response doesn't reveal which accounts exist
+	if user == nil {
+		checkDummyPassword(pass)
+		logger.Log(logger.INFO, errors.Wrap(domain.ErrWrongCredentials, uname).Error())
+		s.loginFailed(ctx, keys, counts, now)
+		return nil, domain.ErrWrongCredentials
+	}
+	ok, rehashed := user.CheckPassword(pass)
+	if !ok {
+		logger.Log(logger.INFO, errors.Wrap(domain.ErrWrongCredentials, uname).Error())
+		s.loginFailed(ctx, keys, counts, now)
+		return nil, domain.ErrWrongCredentials
+	}
+	// hash of old algorithm or cost is upgraded, it's retried on next login
+	if rehashed {
+		if err := s.ur.UpdatePassword(ctx, user.Id, user.Password); err != nil {
+			logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		}
 	}
-	// check password
-	if !user.CheckPassword(pass) {
-		logger.Log(logger.INFO, errors.Wrap(domain.ErrWrongCredentials, user.Username).Error())
-		return "", false, domain.ErrWrongCredentials
+	if user.Disabled() {
+		s.loginSucceeded(ctx, keys)
+		return nil, domain.ErrUserDisabled
 	}
-	// generate jwt token with user claims
-	token, err := s.jwt.Generate(user)
+
+	// failures of the username are forgotten when the second step succeeds
+	challenge, err := s.secondFactor(ctx, user, now)
 	if err != nil {
+		s.releaseLogin(ctx, keys)
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", false, domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
+	if challenge != nil {
+		s.releaseLogin(ctx, keys)
+		logger.Log(logger.INFO, fmt.Sprintf("%s passed password, waiting for 2FA", user.Username))
+		return &domain.LoginResult{Challenge: challenge}, nil
+	}
+	s.loginSucceeded(ctx, keys)
 
-	// persist jwt token
-	err = s.jr.Insert(ctx, user.Id, token, s.jwt.tokenDuration)
+	return s.completeLogin(ctx, user)
+}
+
+// completeLogin issues tokens of a new session to the user who passed all factors
+func (s *Service) completeLogin(ctx context.Context, user *domain.User) (*domain.LoginResult, error) {
+	const op string = "user.service.completeLogin"
+
+	// generate and persist tokens of a new family
+	pair, err := s.startSession(ctx, user)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return "", false, domain.ErrInternalServer
+		return nil, domain.ErrInternalServer
 	}
 
 	// check is there any other active session or not
@@ -89,7 +137,7 @@ func (s *Service) Login(ctx context.Context, uname, pass string) (string, bool,
 		"%s logged-in", user.Username,
 	))
 
-	return token, count > 1, nil
+	return &domain.LoginResult{TokenPair: pair, OtherSessions: count > 1}, nil
 }
 
 // Authorize parses jwt token and return related user
@@ -115,14 +163,36 @@ func (s *Service) Authorize(ctx context.Context, token string) (*domain.User, er
 	if !exists {
 		return nil, domain.ErrInvalidToken
 	}
+	// last use of the session, failing to record it shouldn't block the request
+	if err := s.jr.Touch(ctx, token); err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+	}
 
 	user, err := s.ur.FindById(ctx, claims.Id)
 	if err != nil {
 		return nil, domain.ErrUserNotFound
 	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
 	return user, nil
 }
 
+// Subject verifies signature of jwt token and returns id of its user,
+// it doesn't touch db so revoked tokens aren't detected
+func (s *Service) Subject(ctx context.Context, token string) (uint, error) {
+	claims, err := s.jwt.Verify(token)
+	if err != nil {
+		return 0, domain.ErrInvalidToken
+	}
+	return claims.Id, nil
+}
+
+// PublicKeys returns keys which verify access tokens
+func (s *Service) PublicKeys(ctx context.Context) domain.JWKSet {
+	return s.jwt.JWKS()
+}
+
 // TerminateActiveSessions terminates all other active sessions
 func (s *Service) TerminateActiveSessions(ctx context.Context) error {
 	const op string = "user.service.TerminateActiveSessions"
@@ -2,7 +2,7 @@ package user
 
 import (
 	"context"
-	"sync"
+	"fmt"
 	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
@@ -14,11 +14,17 @@ import (
 type Service struct {
 	ur  domain.UserRepository
 	jr  domain.JwtRepository
+	la  domain.LoginAttemptRepository
+	tf  domain.TwoFactorRepository
 	jwt *JWTManager
+	// login throttling
+	lp domain.LoginPolicy
+	// password strength
+	pp domain.PasswordPolicy
+	// second factor of logins
+	tp domain.TwoFactorPolicy
 	// deposit timeout
 	dtout time.Duration
-	// deposit lock
-	dl sync.RWMutex
 }
 
 var UserService *Service
@@ -26,25 +32,33 @@ var UserService *Service
 func InitService(
 	ur domain.UserRepository,
 	jr domain.JwtRepository,
+	la domain.LoginAttemptRepository,
+	tf domain.TwoFactorRepository,
 	jwt *JWTManager,
+	lp domain.LoginPolicy,
+	pp domain.PasswordPolicy,
+	tp domain.TwoFactorPolicy,
 	dtout time.Duration,
 ) domain.UserService {
 	if UserService == nil {
 		UserService = &Service{
-			ur: ur, jr: jr, jwt: jwt,
-			dtout: dtout,
+			ur: ur, jr: jr, la: la, tf: tf, jwt: jwt,
+			lp: lp, pp: pp, tp: tp, dtout: dtout,
 		}
 	}
 	return UserService
 }
 
-func (s *Service) Update(ctx context.Context, passwd string) error {
+// Update changes password of the user, ADMINs reset password of any user
+func (s *Service) Update(ctx context.Context, id uint, passwd string) error {
 	const op string = "user.service.Update"
 
-	user, err := s.refetchContextUserFromDB(ctx)
+	user, self, err := s.targetUser(ctx, id)
 	if err != nil {
-		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return domain.ErrInternalServer
+		return err
+	}
+	if err := s.pp.Validate(user.Username, passwd); err != nil {
+		return err
 	}
 
 	err = user.SetPassword(passwd)
@@ -53,30 +67,41 @@ func (s *Service) Update(ctx context.Context, passwd string) error {
 		return domain.ErrInternalServer
 	}
 
-	err = s.ur.Update(ctx, user)
+	err = s.ur.UpdatePassword(ctx, user.Id, user.Password)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return domain.ErrInternalServer
 	}
+
+	// sessions opened with the old password end on reset
+	if !self {
+		err = s.jr.DeleteTokensOfUserExcept(ctx, user.Id, "")
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return domain.ErrInternalServer
+		}
+		logger.Log(logger.INFO, fmt.Sprintf("password of %s reset by admin", user.Username))
+	}
 	return nil
 }
 
-func (s *Service) Delete(ctx context.Context) ([]uint, error) {
+// Delete deletes the user and returns its deposit as coins,
+// ADMINs delete any user
+func (s *Service) Delete(ctx context.Context, id uint) ([]uint, error) {
 	const op string = "user.service.Delete"
 
-	user, err := s.refetchContextUserFromDB(ctx)
+	user, _, err := s.targetUser(ctx, id)
 	if err != nil {
-		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
-		return nil, domain.ErrInternalServer
+		return nil, err
 	}
 
-	err = s.ur.Delete(ctx, user.Id)
+	deposit, err := s.ur.Delete(ctx, user.Id)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return nil, domain.ErrInternalServer
 	}
 
-	refund := algo.MinimumNumberOfElementsWhoseSumIs(domain.Coins, user.Deposit)
+	refund := algo.MinimumNumberOfElementsWhoseSumIs(domain.Coins, deposit)
 	return refund, nil
 }
 
@@ -2,15 +2,62 @@ package pgsql
 
 import (
 	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
 )
 
+// JWT is an access token row, Token holds keyed hash of the token
+// so the table can't be used to impersonate users
 type JWT struct {
-	Token     string `gorm:"primaryKey;column:token"`
-	UserID    uint   `gorm:"column:user_id"`
-	User      User
-	ExpiredAt time.Time `gorm:"column:expired_at"`
+	Token  string `gorm:"primaryKey;column:token"`
+	UserID uint   `gorm:"column:user_id"`
+	User   User
+	// Family is the session id, shared with refresh tokens
+	Family     string    `gorm:"column:family;index"`
+	Name       string    `gorm:"column:name;size:64"`
+	UserAgent  string    `gorm:"column:user_agent;size:256"`
+	IP         string    `gorm:"column:ip;size:64"`
+	CreatedAt  time.Time `gorm:"column:created_at"`
+	LastUsedAt time.Time `gorm:"column:last_used_at"`
+	ExpiredAt  time.Time `gorm:"column:expired_at"`
 }
 
 func (j *JWT) TableName() string {
 	return "jwt_tokens"
 }
+
+func (j *JWT) ToSession() domain.Session {
+	return domain.Session{
+		Id:         j.Family,
+		UserId:     j.UserID,
+		Name:       j.Name,
+		UserAgent:  j.UserAgent,
+		IP:         j.IP,
+		CreatedAt:  j.CreatedAt,
+		LastUsedAt: j.LastUsedAt,
+	}
+}
+
+// RefreshToken is a refresh token row, Token holds keyed hash of the token
+type RefreshToken struct {
+	Token     string `gorm:"primaryKey;column:token"`
+	UserID    uint   `gorm:"column:user_id;index"`
+	User      User
+	Family    string     `gorm:"column:family;index"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	ExpiredAt time.Time  `gorm:"column:expired_at"`
+}
+
+func (t *RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) ToDomain() *domain.RefreshToken {
+	return &domain.RefreshToken{
+		Token:     t.Token,
+		UserId:    t.UserID,
+		Family:    t.Family,
+		UsedAt:    t.UsedAt,
+		ExpiredAt: t.ExpiredAt,
+	}
+}
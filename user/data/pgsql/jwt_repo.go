@@ -2,29 +2,70 @@ package pgsql
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/apm-dev/vending-machine/pkg/pgsqlhelper"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
 type JwtRepository struct {
 	db *gorm.DB
+	// key of token hashes
+	key []byte
 }
 
-func InitJwtRepository(db *gorm.DB) domain.JwtRepository {
-	return &JwtRepository{db}
+func InitJwtRepository(db *gorm.DB, hashKey string) domain.JwtRepository {
+	return &JwtRepository{db, []byte(hashKey)}
 }
 
-func (r *JwtRepository) Insert(ctx context.Context, userId uint, token string, ttl time.Duration) error {
+func (r *JwtRepository) BeginTransaction(ctx context.Context) (context.Context, domain.JwtRepository) {
+	if tx, ok := pgsqlhelper.TransactionFromContext(ctx); ok {
+		return ctx, &JwtRepository{tx, r.key}
+	}
+	tx := r.db.Begin()
+	ctx = pgsqlhelper.TransactionToContext(ctx, tx)
+	return ctx, &JwtRepository{tx, r.key}
+}
+
+func (r *JwtRepository) Commit() {
+	r.db.Commit()
+}
+
+func (r *JwtRepository) Rollback() {
+	r.db.Rollback()
+}
+
+// hash returns keyed hash of the token which is stored instead of it
+func (r *JwtRepository) hash(token string) string {
+	return hashToken(r.key, token)
+}
+
+func hashToken(key []byte, token string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (r *JwtRepository) Insert(ctx context.Context, token string, s domain.Session, ttl time.Duration) error {
 	const op string = "user.data.pgsql.jwt_repo.Insert"
 
+	now := time.Now()
 	jwt := &JWT{
-		Token:     token,
-		UserID:    userId,
-		ExpiredAt: time.Now().Add(ttl),
+		Token:      r.hash(token),
+		UserID:     s.UserId,
+		Family:     s.Id,
+		Name:       s.Name,
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiredAt:  now.Add(ttl),
 	}
 
 	err := r.db.WithContext(ctx).Create(jwt).Error
@@ -39,11 +80,122 @@ func (r *JwtRepository) Insert(ctx context.Context, userId uint, token string, t
 func (r *JwtRepository) Exists(ctx context.Context, token string) (bool, error) {
 	const op string = "user.data.pgsql.jwt_repo.Exists"
 
-	result := r.db.WithContext(ctx).First(&JWT{}, "token = ?", token)
-	if result.Error != nil || result.RowsAffected == 0 {
-		return false, errors.Wrap(result.Error, op)
+	var count int64
+	err := r.db.WithContext(ctx).Model(&JWT{}).
+		Where("token = ? AND expired_at > ?", r.hash(token), time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+	return count > 0, nil
+}
+
+// touchInterval limits last use writes to one per interval for each session
+const touchInterval = time.Minute
+
+// alive limits sessions to the ones which are still usable,
+// either by their access token or by refreshing it
+func (r *JwtRepository) alive(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("expired_at > ? OR family IN (?)", now,
+		r.db.Model(&RefreshToken{}).Select("family").
+			Where("used_at IS NULL AND expired_at > ?", now),
+	)
+}
+
+func (r *JwtRepository) Touch(ctx context.Context, token string) error {
+	const op string = "user.data.pgsql.jwt_repo.Touch"
+
+	now := time.Now()
+	err := r.db.WithContext(ctx).Model(&JWT{}).
+		Where("token = ? AND last_used_at < ?", r.hash(token), now.Add(-touchInterval)).
+		Update("last_used_at", now).Error
+	if err != nil {
+		return errors.Wrap(err, op)
 	}
-	return true, nil
+	return nil
+}
+
+func (r *JwtRepository) Rotate(ctx context.Context, sessionId, token string, ttl time.Duration, client domain.ClientInfo) error {
+	const op string = "user.data.pgsql.jwt_repo.Rotate"
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&JWT{}).
+		Where("family = ?", sessionId).
+		Updates(map[string]interface{}{
+			"token":        r.hash(token),
+			"user_agent":   client.UserAgent,
+			"ip":           client.IP,
+			"last_used_at": now,
+			"expired_at":   now.Add(ttl),
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrSessionNotFound, op)
+	}
+	return nil
+}
+
+func (r *JwtRepository) ListSessions(ctx context.Context, userId uint) ([]domain.Session, error) {
+	const op string = "user.data.pgsql.jwt_repo.ListSessions"
+
+	var jwts []JWT
+	err := r.alive(r.db.WithContext(ctx), time.Now()).
+		Where("user_id = ?", userId).
+		Order("last_used_at DESC").
+		Find(&jwts).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	sessions := make([]domain.Session, 0, len(jwts))
+	for _, j := range jwts {
+		sessions = append(sessions, j.ToSession())
+	}
+	return sessions, nil
+}
+
+func (r *JwtRepository) FindSession(ctx context.Context, id string) (*domain.Session, error) {
+	const op string = "user.data.pgsql.jwt_repo.FindSession"
+
+	jwt := new(JWT)
+	err := r.db.WithContext(ctx).First(jwt, "family = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	s := jwt.ToSession()
+	return &s, nil
+}
+
+func (r *JwtRepository) FindSessionByToken(ctx context.Context, token string) (*domain.Session, error) {
+	const op string = "user.data.pgsql.jwt_repo.FindSessionByToken"
+
+	jwt := new(JWT)
+	err := r.db.WithContext(ctx).First(jwt, "token = ?", r.hash(token)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	s := jwt.ToSession()
+	return &s, nil
+}
+
+func (r *JwtRepository) RenameSession(ctx context.Context, id, name string) error {
+	const op string = "user.data.pgsql.jwt_repo.RenameSession"
+
+	err := r.db.WithContext(ctx).Model(&JWT{}).
+		Where("family = ?", id).
+		Update("name", name).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
 }
 
 func (r *JwtRepository) UserTokensCount(ctx context.Context, uid uint) (uint, error) {
@@ -51,7 +203,9 @@ func (r *JwtRepository) UserTokensCount(ctx context.Context, uid uint) (uint, er
 
 	var count int64
 
-	err := r.db.WithContext(ctx).Model(&JWT{}).Where("user_id = ?", uid).Count(&count).Error
+	err := r.alive(r.db.WithContext(ctx).Model(&JWT{}), time.Now()).
+		Where("user_id = ?", uid).
+		Count(&count).Error
 	if err != nil {
 		return 0, errors.Wrap(err, op)
 	}
@@ -62,13 +216,133 @@ func (r *JwtRepository) UserTokensCount(ctx context.Context, uid uint) (uint, er
 func (r *JwtRepository) DeleteTokensOfUserExcept(ctx context.Context, userId uint, exceptionToken string) error {
 	const op string = "user.data.pgsql.jwt_repo.DeleteTokensOfUserExcept"
 
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND token != ?", userId, exceptionToken).
-		Delete(&JWT{}).Error
+	exception := r.hash(exceptionToken)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND token != ?", userId, exception).
+			Delete(&JWT{}).Error
+		if err != nil {
+			return err
+		}
+		// other sessions must not be able to refresh either
+		return tx.Where("user_id = ? AND family NOT IN (?)", userId,
+			tx.Model(&JWT{}).Select("family").Where("token = ?", exception),
+		).Delete(&RefreshToken{}).Error
+	})
+
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *JwtRepository) ActiveCount(ctx context.Context) (uint, error) {
+	const op string = "user.data.pgsql.jwt_repo.ActiveCount"
+
+	var count int64
+
+	// sessions are alive while they can be refreshed, like ListSessions shows them
+	err := r.alive(r.db.WithContext(ctx).Model(&JWT{}), time.Now()).
+		Distinct("family").
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return uint(count), nil
+}
+
+func (r *JwtRepository) InsertRefresh(ctx context.Context, rt domain.RefreshToken) error {
+	const op string = "user.data.pgsql.jwt_repo.InsertRefresh"
 
+	err := r.db.WithContext(ctx).Create(&RefreshToken{
+		Token:     r.hash(rt.Token),
+		UserID:    rt.UserId,
+		Family:    rt.Family,
+		UsedAt:    rt.UsedAt,
+		ExpiredAt: rt.ExpiredAt,
+	}).Error
 	if err != nil {
 		return errors.Wrap(err, op)
 	}
+	return nil
+}
+
+func (r *JwtRepository) FindRefresh(ctx context.Context, token string) (*domain.RefreshToken, error) {
+	const op string = "user.data.pgsql.jwt_repo.FindRefresh"
+
+	rt := new(RefreshToken)
+	err := r.db.WithContext(ctx).First(rt, "token = ?", r.hash(token)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	found := rt.ToDomain()
+	// callers know the token, the row only has its hash
+	found.Token = token
+	return found, nil
+}
+
+func (r *JwtRepository) MarkRefreshUsed(ctx context.Context, token string) (bool, error) {
+	const op string = "user.data.pgsql.jwt_repo.MarkRefreshUsed"
+
+	result := r.db.WithContext(ctx).Model(&RefreshToken{}).
+		Where("token = ? AND used_at IS NULL", r.hash(token)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, op)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *JwtRepository) RevokeFamily(ctx context.Context, family string) error {
+	const op string = "user.data.pgsql.jwt_repo.RevokeFamily"
 
+	// tokens without a family aren't one session
+	if family == "" {
+		return errors.Wrap(domain.ErrSessionNotFound, op)
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("family = ?", family).Delete(&JWT{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("family = ?", family).Delete(&RefreshToken{}).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
 	return nil
 }
+
+func (r *JwtRepository) DeleteExpired(ctx context.Context) (uint, error) {
+	const op string = "user.data.pgsql.jwt_repo.DeleteExpired"
+
+	var deleted int64
+	now := time.Now()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("expired_at <= ?", now).Delete(&RefreshToken{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+
+		// used refresh tokens are kept until expiry for reuse detection,
+		// so only unused ones keep the session alive
+		result = tx.Where("expired_at <= ? AND family NOT IN (?)", now,
+			tx.Model(&RefreshToken{}).Select("family").Where("used_at IS NULL"),
+		).Delete(&JWT{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return uint(deleted), nil
+}
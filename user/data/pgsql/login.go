@@ -0,0 +1,46 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+type LoginFailure struct {
+	Key         string     `gorm:"primaryKey;column:key;size:128"`
+	Count       uint       `gorm:"column:count"`
+	LastAt      time.Time  `gorm:"column:last_at"`
+	LockedUntil *time.Time `gorm:"column:locked_until"`
+}
+
+func (f *LoginFailure) TableName() string {
+	return "login_failures"
+}
+
+type Lockout struct {
+	ID          uint       `gorm:"primaryKey"`
+	Key         string     `gorm:"column:key;size:128;index"`
+	IP          string     `gorm:"column:ip;size:64"`
+	Failures    uint       `gorm:"column:failures"`
+	LockedAt    time.Time  `gorm:"column:locked_at"`
+	LockedUntil time.Time  `gorm:"column:locked_until"`
+	UnlockedBy  *uint      `gorm:"column:unlocked_by"`
+	UnlockedAt  *time.Time `gorm:"column:unlocked_at"`
+}
+
+func (l *Lockout) TableName() string {
+	return "lockouts"
+}
+
+func (l *Lockout) ToDomain() domain.Lockout {
+	return domain.Lockout{
+		Id:          l.ID,
+		Key:         l.Key,
+		IP:          l.IP,
+		Failures:    l.Failures,
+		LockedAt:    l.LockedAt,
+		LockedUntil: l.LockedUntil,
+		UnlockedBy:  l.UnlockedBy,
+		UnlockedAt:  l.UnlockedAt,
+	}
+}
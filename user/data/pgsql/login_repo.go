@@ -0,0 +1,155 @@
+package pgsql
+
+import (
+	"context"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func InitLoginAttemptRepository(db *gorm.DB) domain.LoginAttemptRepository {
+	return &LoginAttemptRepository{db}
+}
+
+func (r *LoginAttemptRepository) Failures(ctx context.Context, key string) (*domain.LoginFailures, error) {
+	const op string = "user.data.pgsql.login_repo.Failures"
+
+	var dbf LoginFailure
+	err := r.db.WithContext(ctx).Where("key = ?", key).Limit(1).Find(&dbf).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &domain.LoginFailures{
+		Key:         key,
+		Count:       dbf.Count,
+		LastAt:      dbf.LastAt,
+		LockedUntil: dbf.LockedUntil,
+	}, nil
+}
+
+func (r *LoginAttemptRepository) AddFailure(ctx context.Context, key string, at, since time.Time) (uint, error) {
+	const op string = "user.data.pgsql.login_repo.AddFailure"
+
+	var count uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"count": gorm.Expr(
+					"CASE WHEN login_failures.last_at < ? THEN 1 ELSE login_failures.count + 1 END", since,
+				),
+				"last_at": at,
+			}),
+		}).Create(&LoginFailure{Key: key, Count: 1, LastAt: at}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&LoginFailure{}).Where("key = ?", key).Pluck("count", &count).Error
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return count, nil
+}
+
+func (r *LoginAttemptRepository) RemoveFailure(ctx context.Context, key string) error {
+	const op string = "user.data.pgsql.login_repo.RemoveFailure"
+
+	err := r.db.WithContext(ctx).Model(&LoginFailure{}).
+		Where("key = ? AND count > 0", key).
+		Update("count", gorm.Expr("count - 1")).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	const op string = "user.data.pgsql.login_repo.Lock"
+
+	err := r.db.WithContext(ctx).Model(&LoginFailure{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) Reset(ctx context.Context, key string) error {
+	const op string = "user.data.pgsql.login_repo.Reset"
+
+	err := r.db.WithContext(ctx).Where("key = ?", key).Delete(&LoginFailure{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) InsertLockout(ctx context.Context, l domain.Lockout) error {
+	const op string = "user.data.pgsql.login_repo.InsertLockout"
+
+	err := r.db.WithContext(ctx).Create(&Lockout{
+		Key:         l.Key,
+		IP:          l.IP,
+		Failures:    l.Failures,
+		LockedAt:    l.LockedAt,
+		LockedUntil: l.LockedUntil,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) ListLockouts(ctx context.Context) ([]domain.Lockout, error) {
+	const op string = "user.data.pgsql.login_repo.ListLockouts"
+
+	var dbls []Lockout
+	err := r.db.WithContext(ctx).Order("locked_at DESC").Find(&dbls).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	lockouts := make([]domain.Lockout, 0, len(dbls))
+	for _, l := range dbls {
+		lockouts = append(lockouts, l.ToDomain())
+	}
+	return lockouts, nil
+}
+
+func (r *LoginAttemptRepository) MarkUnlocked(ctx context.Context, key string, by uint, at time.Time) error {
+	const op string = "user.data.pgsql.login_repo.MarkUnlocked"
+
+	err := r.db.WithContext(ctx).Model(&Lockout{}).
+		Where("key = ? AND unlocked_at IS NULL AND locked_until > ?", key, at).
+		Updates(map[string]interface{}{
+			"unlocked_by": by,
+			"unlocked_at": at,
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *LoginAttemptRepository) DeleteStale(ctx context.Context, before time.Time) (uint, error) {
+	const op string = "user.data.pgsql.login_repo.DeleteStale"
+
+	res := r.db.WithContext(ctx).
+		Where("last_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before).
+		Delete(&LoginFailure{})
+	if res.Error != nil {
+		return 0, errors.Wrap(res.Error, op)
+	}
+	return uint(res.RowsAffected), nil
+}
@@ -0,0 +1,181 @@
+package pgsql
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// HashStoredTokens replaces raw tokens persisted by older versions with
+// their keyed hash in place and gives tokens without a family (session)
+// their own one, so existing sessions stay logged in and can be revoked.
+// It's safe to run on every start, migrated rows are skipped.
+func HashStoredTokens(db *gorm.DB, hashKey string) error {
+	const op string = "user.data.pgsql.migrate.HashStoredTokens"
+
+	// an empty key makes hashes computable by anyone who reads the table
+	if hashKey == "" {
+		return errors.Errorf("%s: token hash key is empty", op)
+	}
+	key := []byte(hashKey)
+	migrated := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// raw access tokens are jwts which have dots, hex hashes never have
+		var tokens []string
+		err := tx.Model(&JWT{}).Where("token LIKE ?", "%.%").Pluck("token", &tokens).Error
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			err = tx.Model(&JWT{}).Where("token = ?", t).Update("token", hashToken(key, t)).Error
+			if err != nil {
+				return err
+			}
+		}
+		migrated += len(tokens)
+
+		// raw refresh tokens are 43 chars of base64, hashes are 64 hex chars
+		tokens = nil
+		err = tx.Model(&RefreshToken{}).Where("length(token) <> ?", sha256HexLen).Pluck("token", &tokens).Error
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			err = tx.Model(&RefreshToken{}).Where("token = ?", t).Update("token", hashToken(key, t)).Error
+			if err != nil {
+				return err
+			}
+		}
+		migrated += len(tokens)
+
+		backfilled, err := backfillFamilies(tx, key)
+		if err != nil {
+			return err
+		}
+		migrated += backfilled
+
+		// refresh tokens used to keep the raw access token of their pair
+		if tx.Migrator().HasColumn(&RefreshToken{}, "access_token") {
+			return tx.Migrator().DropColumn(&RefreshToken{}, "access_token")
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if migrated > 0 {
+		logger.Log(logger.INFO, fmt.Sprintf("%s: %d tokens migrated", op, migrated))
+	}
+	return nil
+}
+
+// backfillFamilies gives every access token of older versions its own family,
+// their refresh tokens join the family of their pair while the raw access
+// token is still kept, otherwise they get their own family too
+func backfillFamilies(tx *gorm.DB, key []byte) (int, error) {
+	var tokens []string
+	err := tx.Model(&JWT{}).Where("family = ? OR family IS NULL", "").Pluck("token", &tokens).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range tokens {
+		family, err := newFamily()
+		if err != nil {
+			return 0, err
+		}
+		err = tx.Model(&JWT{}).Where("token = ?", t).Update("family", family).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	backfilled := len(tokens)
+
+	var rts []struct {
+		Token       string
+		AccessToken string
+	}
+	q := tx.Model(&RefreshToken{}).Where("family = ? OR family IS NULL", "")
+	if tx.Migrator().HasColumn(&RefreshToken{}, "access_token") {
+		q = q.Select("token, access_token")
+	} else {
+		q = q.Select("token")
+	}
+	if err = q.Scan(&rts).Error; err != nil {
+		return 0, err
+	}
+	for _, rt := range rts {
+		var families []string
+		if rt.AccessToken != "" {
+			err = tx.Model(&JWT{}).Where("token = ?", hashToken(key, rt.AccessToken)).
+				Limit(1).Pluck("family", &families).Error
+			if err != nil {
+				return 0, err
+			}
+		}
+		family := ""
+		if len(families) > 0 {
+			family = families[0]
+		}
+		if family == "" {
+			if family, err = newFamily(); err != nil {
+				return 0, err
+			}
+		}
+		err = tx.Model(&RefreshToken{}).Where("token = ?", rt.Token).Update("family", family).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return backfilled + len(rts), nil
+}
+
+// newFamily returns a random id like the ones of new sessions
+func newFamily() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// length of hex encoded sha256 sum
+const sha256HexLen = 64
+
+// EncryptTwoFactorSecrets encrypts TOTP secrets stored raw by older versions.
+// It's safe to run on every start, encrypted rows are skipped.
+func EncryptTwoFactorSecrets(db *gorm.DB, hashKey string) error {
+	const op string = "user.data.pgsql.migrate.EncryptTwoFactorSecrets"
+
+	aead := secretCipher([]byte(hashKey))
+	var raw []TwoFactor
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("secret NOT LIKE ?", encryptedSecretPrefix+"%").Find(&raw).Error
+		if err != nil {
+			return err
+		}
+		for _, tf := range raw {
+			secret, err := encryptSecret(aead, tf.Secret)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&TwoFactor{}).Where("user_id = ?", tf.UserID).Update("secret", secret).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if len(raw) > 0 {
+		logger.Log(logger.INFO, fmt.Sprintf("%s: %d secrets encrypted", op, len(raw)))
+	}
+	return nil
+}
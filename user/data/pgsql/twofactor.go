@@ -0,0 +1,83 @@
+package pgsql
+
+import (
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
+
+// TwoFactor is the TOTP enrolment of a user, Secret is encrypted by a key
+// derived from the token hash key, LastStep is the time step of the last
+// accepted code so a code can't be used twice
+type TwoFactor struct {
+	UserID      uint   `gorm:"primaryKey;column:user_id;autoIncrement:false"`
+	User        User   `gorm:"constraint:OnDelete:CASCADE"`
+	Secret      string `gorm:"column:secret;size:128"`
+	LastStep    uint64 `gorm:"column:last_step"`
+	ConfirmedAt *time.Time
+	CreatedAt   time.Time
+}
+
+func (t *TwoFactor) TableName() string {
+	return "two_factors"
+}
+
+func (t *TwoFactor) FromDomain(tf domain.TwoFactor) {
+	t.UserID = tf.UserId
+	t.Secret = tf.Secret
+	t.ConfirmedAt = tf.ConfirmedAt
+	t.CreatedAt = tf.CreatedAt
+}
+
+func (t *TwoFactor) ToDomain() *domain.TwoFactor {
+	return &domain.TwoFactor{
+		UserId:      t.UserID,
+		Secret:      t.Secret,
+		ConfirmedAt: t.ConfirmedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// BackupCode is a single use code of a user, Code holds keyed hash of the code
+type BackupCode struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"column:user_id;index"`
+	User   User   `gorm:"constraint:OnDelete:CASCADE"`
+	Code   string `gorm:"column:code;size:64"`
+}
+
+func (c *BackupCode) TableName() string {
+	return "backup_codes"
+}
+
+// LoginChallenge is a pending second step of login, Token holds keyed hash of the token
+type LoginChallenge struct {
+	Token     string    `gorm:"primaryKey;column:token"`
+	UserID    uint      `gorm:"column:user_id;index"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE"`
+	Enroll    bool      `gorm:"column:enroll"`
+	Attempts  uint      `gorm:"column:attempts"`
+	ExpiredAt time.Time `gorm:"column:expired_at"`
+}
+
+func (c *LoginChallenge) TableName() string {
+	return "login_challenges"
+}
+
+func (c *LoginChallenge) ToDomain() *domain.LoginChallenge {
+	return &domain.LoginChallenge{
+		Token:     c.Token,
+		UserId:    c.UserID,
+		Enroll:    c.Enroll,
+		ExpiredAt: c.ExpiredAt,
+	}
+}
+
+// TwoFactorRole is a role which must use 2FA
+type TwoFactorRole struct {
+	Role string `gorm:"primaryKey;column:role;size:16"`
+}
+
+func (r *TwoFactorRole) TableName() string {
+	return "two_factor_roles"
+}
@@ -0,0 +1,308 @@
+package pgsql
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TwoFactorRepository struct {
+	db *gorm.DB
+	// key of challenge and backup code hashes
+	key []byte
+	// secrets are encrypted at rest
+	aead cipher.AEAD
+}
+
+func InitTwoFactorRepository(db *gorm.DB, hashKey string) domain.TwoFactorRepository {
+	return &TwoFactorRepository{db, []byte(hashKey), secretCipher([]byte(hashKey))}
+}
+
+func (r *TwoFactorRepository) hash(token string) string {
+	return hashToken(r.key, token)
+}
+
+// prefix of encrypted secrets, older versions stored them raw
+const encryptedSecretPrefix = "enc:"
+
+// secretCipher returns AES-256-GCM of a key derived from the hash key,
+// so the same key isn't used for hashing and encryption
+func secretCipher(hashKey []byte) cipher.AEAD {
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte("two_factors.secret"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		// sha256 sum is always a valid aes-256 key
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}
+
+func encryptSecret(aead cipher.AEAD, secret string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func decryptSecret(aead cipher.AEAD, stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedSecretPrefix) {
+		return stored, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSecretPrefix))
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	secret, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (r *TwoFactorRepository) Find(ctx context.Context, userId uint) (*domain.TwoFactor, error) {
+	const op string = "user.data.pgsql.twofactor_repo.Find"
+
+	tf := new(TwoFactor)
+	err := r.db.WithContext(ctx).First(tf, "user_id = ?", userId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTwoFactorNotFound
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	found := tf.ToDomain()
+	found.Secret, err = decryptSecret(r.aead, tf.Secret)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	return found, nil
+}
+
+func (r *TwoFactorRepository) Save(ctx context.Context, tf domain.TwoFactor) error {
+	const op string = "user.data.pgsql.twofactor_repo.Save"
+
+	dbtf := new(TwoFactor)
+	dbtf.FromDomain(tf)
+	var err error
+	dbtf.Secret, err = encryptSecret(r.aead, tf.Secret)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// ciphertexts of a secret differ, so the stored one is decrypted to
+		// know whether it's a new secret which starts counting steps again
+		var old TwoFactor
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", tf.UserId).Limit(1).Find(&old).Error
+		if err != nil {
+			return err
+		}
+		if old.UserID != 0 {
+			if secret, err := decryptSecret(r.aead, old.Secret); err == nil && secret == tf.Secret {
+				dbtf.LastStep = old.LastStep
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_step"}),
+		}).Create(dbtf).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) Delete(ctx context.Context, userId uint) error {
+	const op string = "user.data.pgsql.twofactor_repo.Delete"
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&BackupCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&TwoFactor{}).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userId uint, step uint64) (bool, error) {
+	const op string = "user.data.pgsql.twofactor_repo.UseStep"
+
+	result := r.db.WithContext(ctx).Model(&TwoFactor{}).
+		Where("user_id = ? AND last_step < ?", userId, step).
+		Update("last_step", step)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, op)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *TwoFactorRepository) ReplaceBackupCodes(ctx context.Context, userId uint, codes []string) error {
+	const op string = "user.data.pgsql.twofactor_repo.ReplaceBackupCodes"
+
+	dbcs := make([]BackupCode, len(codes))
+	for i, c := range codes {
+		dbcs[i] = BackupCode{UserID: userId, Code: r.hash(c)}
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ?", userId).Delete(&BackupCode{}).Error
+		if err != nil || len(dbcs) == 0 {
+			return err
+		}
+		return tx.Create(&dbcs).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) UseBackupCode(ctx context.Context, userId uint, code string) (bool, error) {
+	const op string = "user.data.pgsql.twofactor_repo.UseBackupCode"
+
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND code = ?", userId, r.hash(code)).
+		Delete(&BackupCode{})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, op)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *TwoFactorRepository) InsertChallenge(ctx context.Context, c domain.LoginChallenge) error {
+	const op string = "user.data.pgsql.twofactor_repo.InsertChallenge"
+
+	err := r.db.WithContext(ctx).Create(&LoginChallenge{
+		Token:     r.hash(c.Token),
+		UserID:    c.UserId,
+		Enroll:    c.Enroll,
+		ExpiredAt: c.ExpiredAt,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) FindChallenge(ctx context.Context, token string) (*domain.LoginChallenge, error) {
+	const op string = "user.data.pgsql.twofactor_repo.FindChallenge"
+
+	c := new(LoginChallenge)
+	err := r.db.WithContext(ctx).
+		First(c, "token = ? AND expired_at > ?", r.hash(token), time.Now()).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, errors.Wrap(err, op)
+	}
+	found := c.ToDomain()
+	// callers know the token, the row only has its hash
+	found.Token = token
+	return found, nil
+}
+
+func (r *TwoFactorRepository) AddChallengeAttempt(ctx context.Context, token string) (uint, error) {
+	const op string = "user.data.pgsql.twofactor_repo.AddChallengeAttempt"
+
+	var attempts []uint
+	hash := r.hash(token)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&LoginChallenge{}).
+			Where("token = ?", hash).
+			Update("attempts", gorm.Expr("attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&LoginChallenge{}).Where("token = ?", hash).Pluck("attempts", &attempts).Error
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	if len(attempts) == 0 {
+		return 0, errors.Wrap(domain.ErrInvalidToken, op)
+	}
+	return attempts[0], nil
+}
+
+func (r *TwoFactorRepository) DeleteChallenge(ctx context.Context, token string) error {
+	const op string = "user.data.pgsql.twofactor_repo.DeleteChallenge"
+
+	err := r.db.WithContext(ctx).Where("token = ?", r.hash(token)).Delete(&LoginChallenge{}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) DeleteExpiredChallenges(ctx context.Context) (uint, error) {
+	const op string = "user.data.pgsql.twofactor_repo.DeleteExpiredChallenges"
+
+	result := r.db.WithContext(ctx).Where("expired_at <= ?", time.Now()).Delete(&LoginChallenge{})
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, op)
+	}
+	return uint(result.RowsAffected), nil
+}
+
+func (r *TwoFactorRepository) RequiredRoles(ctx context.Context) ([]domain.Role, error) {
+	const op string = "user.data.pgsql.twofactor_repo.RequiredRoles"
+
+	var dbrs []TwoFactorRole
+	err := r.db.WithContext(ctx).Order("role").Find(&dbrs).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	roles := make([]domain.Role, len(dbrs))
+	for i, dbr := range dbrs {
+		roles[i] = domain.Role(dbr.Role)
+	}
+	return roles, nil
+}
+
+func (r *TwoFactorRepository) SetRequiredRoles(ctx context.Context, roles []domain.Role) error {
+	const op string = "user.data.pgsql.twofactor_repo.SetRequiredRoles"
+
+	dbrs := make([]TwoFactorRole, len(roles))
+	for i, role := range roles {
+		dbrs[i] = TwoFactorRole{Role: string(role)}
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("1 = 1").Delete(&TwoFactorRole{}).Error
+		if err != nil || len(dbrs) == 0 {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&dbrs).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	return nil
+}
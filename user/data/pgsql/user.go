@@ -1,6 +1,9 @@
 package pgsql
 
 import (
+	"strings"
+	"time"
+
 	"github.com/apm-dev/vending-machine/domain"
 	"gorm.io/gorm"
 )
@@ -10,6 +13,12 @@ type User struct {
 	Password string `gorm:"size:256;column:password"`
 	Role     string `gorm:"size:32;column:role"`
 	Deposit  uint   `gorm:"column:deposit"`
+	// DeletedDeposit is the deposit refunded on soft delete, restore credits it back
+	DeletedDeposit uint `gorm:"column:deleted_deposit"`
+	// comma separated allergens and diet labels of dietary profile
+	DietAvoid   string     `gorm:"column:diet_avoid"`
+	DietRequire string     `gorm:"column:diet_require"`
+	DisabledAt  *time.Time `gorm:"column:disabled_at"`
 	gorm.Model
 }
 
@@ -23,17 +32,50 @@ func (u *User) FromDomain(user *domain.User) {
 	u.Password = user.Password
 	u.Role = string(user.Role)
 	u.Deposit = user.Deposit
+	u.DisabledAt = user.DisabledAt
+
+	avoid := make([]string, len(user.Diet.Avoid))
+	for i, a := range user.Diet.Avoid {
+		avoid[i] = string(a)
+	}
+	u.DietAvoid = strings.Join(avoid, ",")
+
+	require := make([]string, len(user.Diet.Require))
+	for i, l := range user.Diet.Require {
+		require[i] = string(l)
+	}
+	u.DietRequire = strings.Join(require, ",")
 }
 
 func (u *User) ToDomain() *domain.User {
-	return &domain.User{
-		Id:        u.ID,
-		Username:  u.Username,
-		Password:  u.Password,
-		Role:      domain.Role(u.Role),
-		Deposit:   u.Deposit,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
-		DeletedAt: u.DeletedAt.Time,
+	user := &domain.User{
+		Id:         u.ID,
+		Username:   u.Username,
+		Password:   u.Password,
+		Role:       domain.Role(u.Role),
+		Deposit:    u.Deposit,
+		CreatedAt:  u.CreatedAt,
+		UpdatedAt:  u.UpdatedAt,
+		DisabledAt: u.DisabledAt,
+	}
+
+	if u.DeletedAt.Valid {
+		user.DeletedAt = &u.DeletedAt.Time
+	}
+
+	user.Diet.Avoid = make([]domain.Allergen, 0)
+	if u.DietAvoid != "" {
+		for _, a := range strings.Split(u.DietAvoid, ",") {
+			user.Diet.Avoid = append(user.Diet.Avoid, domain.Allergen(a))
+		}
 	}
+
+	user.Diet.Require = make([]domain.DietLabel, 0)
+	if u.DietRequire != "" {
+		for _, l := range strings.Split(u.DietRequire, ",") {
+			user.Diet.Require = append(user.Diet.Require, domain.DietLabel(l))
+		}
+	}
+
+	return user
 }
@@ -3,6 +3,7 @@ package pgsql
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/pgsqlhelper"
@@ -59,6 +60,9 @@ func (r *UserRepository) FindById(ctx context.Context, id uint) (*domain.User, e
 
 	err := r.db.WithContext(ctx).First(&dbUser, "id = ?", id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrUserNotFound, op)
+		}
 		return nil, errors.Wrap(err, op)
 	}
 
@@ -72,6 +76,9 @@ func (r *UserRepository) FindByUsername(ctx context.Context, un string) (*domain
 
 	err := r.db.WithContext(ctx).First(&dbUser, "username = ?", un).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrUserNotFound, op)
+		}
 		return nil, errors.Wrap(err, op)
 	}
 
@@ -109,13 +116,256 @@ func (r *UserRepository) Update(ctx context.Context, u *domain.User) error {
 	return nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	const op string = "user.data.pgsql.user_repo.UpdatePassword"
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Update("password", hash)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, id uint, role domain.Role) error {
+	const op string = "user.data.pgsql.user_repo.UpdateRole"
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Update("role", string(role))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetDisabledAt(ctx context.Context, id uint, at *time.Time) error {
+	const op string = "user.data.pgsql.user_repo.SetDisabledAt"
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Update("disabled_at", at)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateDiet(ctx context.Context, id uint, diet domain.DietaryProfile) error {
+	const op string = "user.data.pgsql.user_repo.UpdateDiet"
+
+	dbUser := new(User)
+	dbUser.FromDomain(&domain.User{Diet: diet})
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"diet_avoid":   dbUser.DietAvoid,
+			"diet_require": dbUser.DietRequire,
+		})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) AddDeposit(ctx context.Context, id, amount uint) error {
+	const op string = "user.data.pgsql.user_repo.AddDeposit"
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Update("deposit", gorm.Expr("deposit + ?", amount))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ResetDeposit(ctx context.Context, id uint) (uint, error) {
+	const op string = "user.data.pgsql.user_repo.ResetDeposit"
+
+	// the locked read waits for concurrent changes, so the returned
+	// deposit is exactly what was zeroed
+	var deposits []uint
+	err := r.db.WithContext(ctx).Raw(`
+		WITH old AS (
+			SELECT id, deposit FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE
+		)
+		UPDATE users SET deposit = 0 FROM old WHERE users.id = old.id
+		RETURNING old.deposit`, id,
+	).Scan(&deposits).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	if len(deposits) == 0 {
+		return 0, errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return deposits[0], nil
+}
+
+func (r *UserRepository) SpendDeposit(ctx context.Context, id, amount uint) error {
+	const op string = "user.data.pgsql.user_repo.SpendDeposit"
+
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND deposit >= ?", id, amount).
+		Update("deposit", gorm.Expr("deposit - ?", amount))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, op)
+	}
+	if result.RowsAffected == 0 {
+		return errors.Wrap(domain.ErrInsufficientBalance, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uint) (uint, error) {
 	const op string = "user.data.pgsql.user_repo.Delete"
 
-	err := r.db.WithContext(ctx).Delete(&User{}, id).Error
+	// deposit is refunded on delete, it's kept aside so restore credits it back
+	var deposits []uint
+	err := r.db.WithContext(ctx).Raw(`
+		WITH old AS (
+			SELECT id, deposit FROM users WHERE id = ? AND deleted_at IS NULL FOR UPDATE
+		)
+		UPDATE users SET deposit = 0, deleted_deposit = old.deposit, deleted_at = ?
+		FROM old WHERE users.id = old.id
+		RETURNING old.deposit`, id, time.Now(),
+	).Scan(&deposits).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+	if len(deposits) == 0 {
+		return 0, errors.Wrap(domain.ErrUserNotFound, op)
+	}
+
+	return deposits[0], nil
+}
+
+func (r *UserRepository) ListDeleted(ctx context.Context) ([]domain.User, error) {
+	const op string = "user.data.pgsql.user_repo.ListDeleted"
+
+	dbUsers := make([]User, 0)
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&dbUsers).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	users := make([]domain.User, 0, len(dbUsers))
+	for _, u := range dbUsers {
+		users = append(users, *u.ToDomain())
+	}
+	return users, nil
+}
+
+func (r *UserRepository) FindDeletedById(ctx context.Context, id uint) (*domain.User, error) {
+	const op string = "user.data.pgsql.user_repo.FindDeletedById"
+
+	dbUser := new(User)
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&dbUser, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrap(domain.ErrUserNotFound, op)
+		}
+		return nil, errors.Wrap(err, op)
+	}
+
+	return dbUser.ToDomain(), nil
+}
+
+func (r *UserRepository) Restore(ctx context.Context, id uint) error {
+	const op string = "user.data.pgsql.user_repo.Restore"
+
+	err := r.db.WithContext(ctx).Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at":      nil,
+			"deposit":         gorm.Expr("deposit + deleted_deposit"),
+			"deleted_deposit": 0,
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) Purge(ctx context.Context, id uint) error {
+	const op string = "user.data.pgsql.user_repo.Purge"
+
+	err := r.db.WithContext(ctx).Unscoped().Delete(&User{}, id).Error
 	if err != nil {
 		return errors.Wrap(err, op)
 	}
 
 	return nil
 }
+
+func (r *UserRepository) CountByRole(ctx context.Context) (map[domain.Role]uint, error) {
+	const op string = "user.data.pgsql.user_repo.CountByRole"
+
+	var rows []struct {
+		Role  string
+		Count uint
+	}
+
+	err := r.db.WithContext(ctx).Model(&User{}).
+		Select("role, COUNT(*) AS count").
+		Group("role").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	counts := make(map[domain.Role]uint, len(rows))
+	for _, row := range rows {
+		counts[domain.Role(row.Role)] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *UserRepository) SumDeposits(ctx context.Context, role domain.Role) (uint, error) {
+	const op string = "user.data.pgsql.user_repo.SumDeposits"
+
+	var sum uint
+
+	err := r.db.WithContext(ctx).Model(&User{}).
+		Select("COALESCE(SUM(deposit), 0)").
+		Where("role = ?", string(role)).
+		Scan(&sum).Error
+	if err != nil {
+		return 0, errors.Wrap(err, op)
+	}
+
+	return sum, nil
+}
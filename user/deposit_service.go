@@ -30,20 +30,23 @@ func (s *Service) Deposit(ctx context.Context, coin domain.Coin) (uint, error) {
 		return 0, domain.ErrPermissionDenied
 	}
 
-	// use locks because of concurrent requests
-	// we make sure to add user deposits without conflict
-	// there are better ways to handle this kind of issue
-	// but it's just for POC
-	s.dl.Lock()
-	defer s.dl.Unlock()
+	// deposit is changed in sql, concurrent buys and refunds change it too
+	ctx, ur := s.ur.BeginTransaction(ctx)
 
-	user.AddDeposit(coin)
-
-	err = s.ur.Update(ctx, user)
+	err = ur.AddDeposit(ctx, user.Id, uint(coin))
 	if err != nil {
+		ur.Rollback()
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return 0, domain.ErrInternalServer
 	}
+	// the row is locked by the update until commit
+	user, err = ur.FindById(ctx, user.Id)
+	if err != nil {
+		ur.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return 0, domain.ErrInternalServer
+	}
+	ur.Commit()
 
 	return user.Deposit, nil
 }
@@ -65,13 +68,7 @@ func (s *Service) ResetDeposit(ctx context.Context) ([]uint, error) {
 		return nil, domain.ErrPermissionDenied
 	}
 
-	s.dl.Lock()
-	defer s.dl.Unlock()
-
-	deposit := user.Deposit
-	user.ResetDeposit()
-
-	err = s.ur.Update(ctx, user)
+	deposit, err := s.ur.ResetDeposit(ctx, user.Id)
 	if err != nil {
 		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
 		return nil, domain.ErrInternalServer
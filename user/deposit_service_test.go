@@ -46,10 +46,13 @@ func Test_Service_Deposit(t *testing.T) {
 				ur.On("FindById",
 					mock.AnythingOfType(timerCtx), uint(1),
 				).Return(u, nil).Once()
-				ur.On("Update",
-					mock.AnythingOfType(timerCtx),
-					mock.AnythingOfType("*domain.User"),
-				).Return(nil).Once()
+				ur.On("BeginTransaction", mock.AnythingOfType(timerCtx)).
+					Return(context.Background(), ur).Once()
+				ur.On("AddDeposit", mock.Anything, uint(1), uint(50)).Return(nil).Once()
+				// deposit is read back after the atomic update
+				ur.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.User{Id: 1, Role: domain.BUYER, Deposit: 50}, nil).Once()
+				ur.On("Commit").Once()
 			},
 			args: args{
 				ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
@@ -138,7 +141,8 @@ func Test_Service_Deposit(t *testing.T) {
 	for _, tc := range testCases {
 		// arrange
 		tc.prepare()
-		svc := user.InitService(ur, nil, nil, tc.timeout)
+		user.UserService = nil
+		svc := user.InitService(ur, nil, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, tc.timeout)
 		// action
 		balance, err := svc.Deposit(tc.args.ctx, tc.args.coin)
 		// assert
@@ -152,3 +156,37 @@ func Test_Service_Deposit(t *testing.T) {
 	}
 	ur.AssertExpectations(t)
 }
+
+func Test_Service_ResetDeposit(t *testing.T) {
+	ur := new(mocks.UserRepository)
+
+	buyer := &domain.User{Id: 1, Role: domain.BUYER}
+	buyerContext := context.WithValue(context.Background(), domain.USER, buyer)
+
+	user.UserService = nil
+	svc := user.InitService(ur, nil, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	t.Run("should refund the deposit which was zeroed", func(t *testing.T) {
+		ur.On("FindById", mock.Anything, uint(1)).Return(buyer, nil).Once()
+		ur.On("ResetDeposit", mock.Anything, uint(1)).Return(uint(70), nil).Once()
+
+		refund, err := svc.ResetDeposit(buyerContext)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{50, 20}, refund)
+	})
+
+	t.Run("should fail when seller resets", func(t *testing.T) {
+		ur.On("FindById", mock.Anything, uint(2)).
+			Return(&domain.User{Id: 2, Role: domain.SELLER}, nil).Once()
+
+		refund, err := svc.ResetDeposit(context.WithValue(context.Background(), domain.USER, &domain.User{
+			Id: 2, Role: domain.SELLER,
+		}))
+
+		assert.ErrorIs(t, err, domain.ErrPermissionDenied)
+		assert.Nil(t, refund)
+	})
+
+	ur.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// UpdateDietaryProfile saves dietary profile of buyer(user)
+func (s *Service) UpdateDietaryProfile(ctx context.Context, profile domain.DietaryProfile) (*domain.User, error) {
+	const op string = "user.service.UpdateDietaryProfile"
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+
+	user, err := s.refetchContextUserFromDB(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if user.Role != domain.BUYER {
+		return nil, domain.ErrPermissionDenied
+	}
+
+	err = s.ur.UpdateDiet(ctx, user.Id, profile)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	user.Diet = profile
+
+	return user, nil
+}
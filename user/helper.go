@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
 	"github.com/pkg/errors"
 )
 
@@ -21,3 +22,46 @@ func (s *Service) refetchContextUserFromDB(ctx context.Context) (*domain.User, e
 	}
 	return user, nil
 }
+
+// targetUser returns the user of id when it's the context user itself or
+// the context user is ADMIN, self reports whether it's the context user
+func (s *Service) targetUser(ctx context.Context, id uint) (user *domain.User, self bool, err error) {
+	const op string = "user.helper.targetUser"
+
+	cu, err := s.refetchContextUserFromDB(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, false, domain.ErrInternalServer
+	}
+	if cu.Id == id {
+		return cu, true, nil
+	}
+	if cu.Role != domain.ADMIN {
+		return nil, false, domain.ErrPermissionDenied
+	}
+
+	user, err = s.ur.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, false, domain.ErrUserNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, false, domain.ErrInternalServer
+	}
+	return user, false, nil
+}
+
+func (s *Service) requireAdmin(ctx context.Context) error {
+	const op string = "user.helper.requireAdmin"
+
+	user, err := s.refetchContextUserFromDB(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	if user.Role != domain.ADMIN {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
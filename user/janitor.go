@@ -0,0 +1,89 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Janitor periodically deletes expired tokens, login challenges and login
+// failures, otherwise their tables grow with every login and refresh
+type Janitor struct {
+	jr       domain.JwtRepository
+	tf       domain.TwoFactorRepository
+	la       domain.LoginAttemptRepository
+	lp       domain.LoginPolicy
+	interval time.Duration
+}
+
+func InitJanitor(
+	jr domain.JwtRepository,
+	tf domain.TwoFactorRepository,
+	la domain.LoginAttemptRepository,
+	lp domain.LoginPolicy,
+	interval time.Duration,
+) *Janitor {
+	return &Janitor{jr: jr, tf: tf, la: la, lp: lp, interval: interval}
+}
+
+// Run purges expired tokens on every interval, it blocks until ctx is done
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.Purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes expired tokens, login challenges and failures once
+func (j *Janitor) Purge(ctx context.Context) {
+	const op string = "user.janitor.Purge"
+
+	deleted, err := j.jr.DeleteExpired(ctx)
+	if err != nil {
+		// canceled on shutdown
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return
+	}
+	if deleted > 0 {
+		logger.Log(logger.DEBUG, fmt.Sprintf("%s: %d expired tokens deleted", op, deleted))
+	}
+
+	deleted, err = j.tf.DeleteExpiredChallenges(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return
+	}
+	if deleted > 0 {
+		logger.Log(logger.DEBUG, fmt.Sprintf("%s: %d expired login challenges deleted", op, deleted))
+	}
+
+	// failures before the window are forgotten on the next attempt anyway
+	deleted, err = j.la.DeleteStale(ctx, time.Now().Add(-j.lp.Window))
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return
+	}
+	if deleted > 0 {
+		logger.Log(logger.DEBUG, fmt.Sprintf("%s: %d stale login failures deleted", op, deleted))
+	}
+}
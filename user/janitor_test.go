@@ -0,0 +1,55 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Janitor_Run(t *testing.T) {
+	jr := new(mocks.JwtRepository)
+	tf := new(mocks.TwoFactorRepository)
+	la := new(mocks.LoginAttemptRepository)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	purged := make(chan struct{}, 1)
+	// a failing purge doesn't stop the janitor
+	jr.On("DeleteExpired", mock.Anything).Return(uint(0), errors.New("db is down")).Once()
+	jr.On("DeleteExpired", mock.Anything).Return(uint(2), nil)
+	tf.On("DeleteExpiredChallenges", mock.Anything).Return(uint(1), nil)
+	// failures are purged last, so it's a complete purge
+	la.On("DeleteStale", mock.Anything, mock.Anything).Return(uint(1), nil).
+		Run(func(mock.Arguments) {
+			select {
+			case purged <- struct{}{}:
+			default:
+			}
+		})
+
+	done := make(chan struct{})
+	go func() {
+		user.InitJanitor(jr, tf, la, domain.LoginPolicy{Window: time.Minute}, time.Millisecond).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("janitor didn't purge after failure")
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor didn't stop on cancel")
+	}
+	assert.GreaterOrEqual(t, len(jr.Calls), 2)
+}
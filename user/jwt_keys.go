@@ -0,0 +1,120 @@
+package user
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// JWTKey is a key of JWTManager, keys with private part sign tokens,
+// public only keys verify tokens of rotated keys
+type JWTKey struct {
+	// Id is set as kid header of tokens
+	Id      string
+	Method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// NewHMACKey returns symmetric HS256 key, it's never published on JWKS
+func NewHMACKey(kid, secret string) *JWTKey {
+	return &JWTKey{
+		Id:      kid,
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+}
+
+// LoadJWTKey reads RSA or Ed25519 key from PEM file, private keys
+// (PKCS#8 or PKCS#1) sign and verify, public keys (PKIX) only verify
+func LoadJWTKey(kid, path string) (*JWTKey, error) {
+	const op string = "user.jwt_keys.LoadJWTKey"
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("%s: no PEM block in %s", op, path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errors.Errorf("%s: unsupported PEM block %q in %s", op, block.Type, path)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	key := &JWTKey{Id: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.private, key.public = SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.public = SigningMethodEdDSA, k
+	default:
+		return nil, errors.Errorf("%s: unsupported key type %T in %s", op, parsed, path)
+	}
+	return key, nil
+}
+
+// CanSign reports whether the key has its private part
+func (k *JWTKey) CanSign() bool {
+	return k.private != nil
+}
+
+// SigningMethodEdDSA signs tokens with Ed25519 keys, jwt-go v3 doesn't support it
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	k, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(k, []byte(signingString))), nil
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	k, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(k, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package user
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
 	"time"
 
 	"github.com/apm-dev/vending-machine/domain"
@@ -9,8 +15,14 @@ import (
 )
 
 type JWTManager struct {
-	secretKey     string
+	// signer signs new tokens, it's one of keys
+	signer *JWTKey
+	// keys verify tokens by their kid header, keys of a rotation
+	// stay here until tokens signed by them are expired
+	keys          map[string]*JWTKey
 	tokenDuration time.Duration
+	// lifetime of refresh tokens, longer than access tokens
+	refreshDuration time.Duration
 }
 
 type UserClaims struct {
@@ -18,8 +30,33 @@ type UserClaims struct {
 	Id uint `json:"id"`
 }
 
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
-	return &JWTManager{secretKey, tokenDuration}
+// NewJWTManager signs tokens by the key of signingKid and verifies them by
+// any of keys, tokens without kid header are verified by the key with empty id
+func NewJWTManager(keys []*JWTKey, signingKid string, tokenDuration, refreshDuration time.Duration) (*JWTManager, error) {
+	const op string = "user.jwt_manager.NewJWTManager"
+
+	m := &JWTManager{
+		keys:            make(map[string]*JWTKey, len(keys)),
+		tokenDuration:   tokenDuration,
+		refreshDuration: refreshDuration,
+	}
+	for _, k := range keys {
+		if _, ok := m.keys[k.Id]; ok {
+			return nil, errors.Errorf("%s: duplicate key id %q", op, k.Id)
+		}
+		m.keys[k.Id] = k
+	}
+
+	signer, ok := m.keys[signingKid]
+	if !ok {
+		return nil, errors.Errorf("%s: signing key %q is not loaded", op, signingKid)
+	}
+	if !signer.CanSign() {
+		return nil, errors.Errorf("%s: signing key %q has no private key", op, signingKid)
+	}
+	m.signer = signer
+
+	return m, nil
 }
 
 func (m *JWTManager) Generate(u *domain.User) (string, error) {
@@ -31,8 +68,11 @@ func (m *JWTManager) Generate(u *domain.User) (string, error) {
 		Id: u.Id,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.secretKey))
+	token := jwt.NewWithClaims(m.signer.Method, claims)
+	if m.signer.Id != "" {
+		token.Header["kid"] = m.signer.Id
+	}
+	return token.SignedString(m.signer.private)
 }
 
 func (m *JWTManager) Verify(accessToken string) (*UserClaims, error) {
@@ -40,11 +80,16 @@ func (m *JWTManager) Verify(accessToken string) (*UserClaims, error) {
 		accessToken,
 		&UserClaims{},
 		func(t *jwt.Token) (interface{}, error) {
-			_, ok := t.Method.(*jwt.SigningMethodHMAC)
+			kid, _ := t.Header["kid"].(string)
+			key, ok := m.keys[kid]
 			if !ok {
-				return nil, errors.Wrapf(domain.ErrInvalidToken, "unexpected token signing method: %v", t.Method)
+				return nil, errors.Wrapf(domain.ErrInvalidToken, "unknown token key: %q", kid)
+			}
+			// the algorithm is bound to the key, never chosen by the token
+			if t.Method.Alg() != key.Method.Alg() {
+				return nil, errors.Wrapf(domain.ErrInvalidToken, "unexpected token signing method: %v", t.Method.Alg())
 			}
-			return []byte(m.secretKey), nil
+			return key.public, nil
 		},
 	)
 
@@ -59,3 +104,38 @@ func (m *JWTManager) Verify(accessToken string) (*UserClaims, error) {
 
 	return claims, nil
 }
+
+// GenerateRefresh returns random opaque token, used for refresh tokens and families
+func (m *JWTManager) GenerateRefresh() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// JWKS returns public keys of asymmetric keys, sorted by kid
+func (m *JWTManager) JWKS() domain.JWKSet {
+	set := domain.JWKSet{Keys: make([]domain.JWK, 0, len(m.keys))}
+	for _, k := range m.keys {
+		jwk := domain.JWK{Kid: k.Id, Use: "sig", Alg: k.Method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			// symmetric keys are secret
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
@@ -0,0 +1,131 @@
+package user_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, name, typ string, der []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	return path
+}
+
+func Test_JWTManager_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPath := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPub, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	rsaPubPath := writePEM(t, "rsa.pub.pem", "PUBLIC KEY", rsaPub)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDer, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edPath := writePEM(t, "ed.pem", "PRIVATE KEY", edDer)
+
+	load := func(kid, path string) *user.JWTKey {
+		k, err := user.LoadJWTKey(kid, path)
+		require.NoError(t, err)
+		return k
+	}
+	manager := func(signingKid string, keys ...*user.JWTKey) *user.JWTManager {
+		m, err := user.NewJWTManager(keys, signingKid, time.Minute, time.Hour)
+		require.NoError(t, err)
+		return m
+	}
+	u := &domain.User{Id: 7}
+
+	type testCase struct {
+		name     string
+		signer   *user.JWTManager
+		verifier *user.JWTManager
+		wantsErr bool
+	}
+	testCases := []testCase{
+		{
+			name:     "should verify RS256 tokens",
+			signer:   manager("rsa", load("rsa", rsaPath)),
+			verifier: manager("rsa", load("rsa", rsaPath)),
+		},
+		{
+			name:     "should verify EdDSA tokens",
+			signer:   manager("ed", load("ed", edPath)),
+			verifier: manager("ed", load("ed", edPath)),
+		},
+		{
+			name:   "should verify tokens of rotated keys",
+			signer: manager("rsa", load("rsa", rsaPath)),
+			// new key signs, the old public key only verifies
+			verifier: manager("ed", load("ed", edPath), load("rsa", rsaPubPath)),
+		},
+		{
+			name:   "should verify tokens signed by the secret before rotation",
+			signer: manager("", user.NewHMACKey("", "secret")),
+			verifier: manager("ed", user.NewHMACKey("", "secret"),
+				load("ed", edPath)),
+		},
+		{
+			name:     "should reject tokens of unknown keys",
+			signer:   manager("rsa", load("rsa", rsaPath)),
+			verifier: manager("ed", load("ed", edPath)),
+			wantsErr: true,
+		},
+		{
+			name:     "should reject tokens of retired keys with reused kid",
+			signer:   manager("k1", load("k1", rsaPath)),
+			verifier: manager("k1", load("k1", edPath)),
+			wantsErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		token, err := tc.signer.Generate(u)
+		require.NoError(t, err, tc.name)
+
+		claims, err := tc.verifier.Verify(token)
+		if tc.wantsErr {
+			assert.ErrorIs(t, err, domain.ErrInvalidToken, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, u.Id, claims.Id, tc.name)
+	}
+
+	t.Run("should reject HMAC token signed by the public key", func(t *testing.T) {
+		// classic algorithm confusion, public key is known to everyone
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, user.UserClaims{Id: 1})
+		token.Header["kid"] = "rsa"
+		signed, err := token.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPub}))
+		require.NoError(t, err)
+
+		_, err = manager("rsa", load("rsa", rsaPath)).Verify(signed)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+	})
+
+	t.Run("should publish only asymmetric public keys", func(t *testing.T) {
+		set := manager("ed", user.NewHMACKey("", "secret"),
+			load("ed", edPath), load("rsa", rsaPubPath)).JWKS()
+
+		require.Len(t, set.Keys, 2)
+		assert.Equal(t, "OKP", set.Keys[0].Kty)
+		assert.Equal(t, "EdDSA", set.Keys[0].Alg)
+		assert.Equal(t, "RSA", set.Keys[1].Kty)
+		assert.Equal(t, "AQAB", set.Keys[1].E)
+	})
+}
@@ -0,0 +1,190 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Unlock forgets failed logins of the user and ends its lockout (ADMIN only)
+func (s *Service) Unlock(ctx context.Context, id uint) error {
+	const op string = "user.service.Unlock"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return err
+	}
+	admin, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	user, err := s.ur.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrUserNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	key := domain.UserLoginKey(user.Username)
+	err = s.la.Reset(ctx, key)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	err = s.la.MarkUnlocked(ctx, key, admin.Id, time.Now())
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("%s unlocked by admin %d", user.Username, admin.Id))
+	return nil
+}
+
+// Lockouts lists recorded lockouts (ADMIN only)
+func (s *Service) Lockouts(ctx context.Context) ([]domain.Lockout, error) {
+	const op string = "user.service.Lockouts"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	lockouts, err := s.la.ListLockouts(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return lockouts, nil
+}
+
+// loginKeys returns keys which failed logins are counted by
+func (s *Service) loginKeys(ctx context.Context, uname string) []string {
+	keys := []string{domain.UserLoginKey(uname)}
+	if ip := domain.ClientFromContext(ctx).IP; ip != "" {
+		keys = append(keys, domain.IPLoginKey(ip))
+	}
+	return keys
+}
+
+// reserveLogin rejects login attempts which are earlier than the delay of
+// previous failures of any of keys, otherwise the attempt is counted as a
+// failure before checking the password, so parallel guesses can't pass the
+// maximum failures together. It returns counts of keys with this attempt
+func (s *Service) reserveLogin(ctx context.Context, keys []string, now time.Time) ([]uint, error) {
+	const op string = "user.service.reserveLogin"
+
+	var retryAt time.Time
+	for _, key := range keys {
+		f, err := s.la.Failures(ctx, key)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrInternalServer
+		}
+		if at := s.lp.RetryAt(f, now); at.After(retryAt) {
+			retryAt = at
+		}
+	}
+	if now.Before(retryAt) {
+		wait := math.Ceil(retryAt.Sub(now).Seconds())
+		return nil, errors.Wrapf(domain.ErrTooManyAttempts, "retry in %.0fs", wait)
+	}
+
+	counts := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		count, err := s.la.AddFailure(ctx, key, now, now.Add(-s.lp.Window))
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			s.releaseLogin(ctx, keys[:len(counts)])
+			return nil, domain.ErrInternalServer
+		}
+		counts = append(counts, count)
+		// other attempts took the remaining ones
+		if count > s.lp.MaxFailuresOf(key) {
+			s.releaseLogin(ctx, keys[:len(counts)])
+			return nil, errors.Wrap(domain.ErrTooManyAttempts, "other attempts are in progress")
+		}
+	}
+	return counts, nil
+}
+
+// releaseLogin takes back the attempt reserved for keys
+func (s *Service) releaseLogin(ctx context.Context, keys []string) {
+	const op string = "user.service.releaseLogin"
+
+	for _, key := range keys {
+		if err := s.la.RemoveFailure(ctx, key); err != nil {
+			logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		}
+	}
+}
+
+// loginFailed locks keys of a failed attempt which reached their
+// maximum failures with it, lockouts are recorded
+func (s *Service) loginFailed(ctx context.Context, keys []string, counts []uint, now time.Time) {
+	const op string = "user.service.loginFailed"
+
+	for i, key := range keys {
+		count := counts[i]
+		if count < s.lp.MaxFailuresOf(key) {
+			continue
+		}
+
+		until := now.Add(s.lp.Lockout)
+		if err := s.la.Lock(ctx, key, until); err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			continue
+		}
+		err := s.la.InsertLockout(ctx, domain.Lockout{
+			Key:         key,
+			IP:          domain.ClientFromContext(ctx).IP,
+			Failures:    count,
+			LockedAt:    now,
+			LockedUntil: until,
+		})
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		}
+		logger.Log(logger.WARN, fmt.Sprintf("%s locked until %s after %d failed logins",
+			key, until.Format(time.RFC3339), count,
+		))
+	}
+}
+
+// loginSucceeded forgets failures of the username (the first key), failures
+// of the ip are kept so logging into an own account doesn't reset them,
+// only the attempt reserved for it is taken back
+func (s *Service) loginSucceeded(ctx context.Context, keys []string) {
+	const op string = "user.service.loginSucceeded"
+
+	if err := s.la.Reset(ctx, keys[0]); err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+	}
+	s.releaseLogin(ctx, keys[1:])
+}
+
+var (
+	dummyUser     *domain.User
+	dummyUserOnce sync.Once
+)
+
+// checkDummyPassword spends the same time as checking a real password,
+// used for unknown users so timing doesn't reveal which accounts exist
+func checkDummyPassword(pass string) {
+	dummyUserOnce.Do(func() {
+		dummyUser, _ = domain.NewUser("", "dummy password", domain.BUYER)
+	})
+	if dummyUser != nil {
+		// a copy, so the shared hash is never rehashed
+		u := *dummyUser
+		_, _ = u.CheckPassword(pass)
+	}
+}
@@ -0,0 +1,216 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Service_Login(t *testing.T) {
+	type args struct {
+		uname string
+		pass  string
+	}
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+	la := new(mocks.LoginAttemptRepository)
+	tf := new(mocks.TwoFactorRepository)
+
+	policy := domain.LoginPolicy{
+		MaxFailures:   3,
+		MaxIPFailures: 10,
+		Delay:         time.Second,
+		Lockout:       time.Minute,
+		Window:        time.Minute,
+	}
+	ctx := context.WithValue(context.Background(), domain.CLIENT, domain.ClientInfo{IP: "10.0.0.1"})
+	bob, err := domain.NewUser("bob", "secret", domain.BUYER)
+	require.NoError(t, err)
+	bob.Id = 4
+
+	noFailures := func(keys ...string) {
+		for _, k := range keys {
+			la.On("Failures", mock.Anything, k).Return(&domain.LoginFailures{Key: k}, nil).Once()
+		}
+	}
+	// attempts are counted before checking the password
+	reserve := func(key string, count uint) {
+		la.On("AddFailure", mock.Anything, key, mock.Anything, mock.Anything).Return(count, nil).Once()
+	}
+
+	testCases := []testCase{
+		{
+			name: "should fail like wrong password when user is unknown",
+			prepare: func() {
+				noFailures("user:eve", "ip:10.0.0.1")
+				reserve("user:eve", 1)
+				reserve("ip:10.0.0.1", 1)
+				ur.On("FindByUsername", mock.Anything, "eve").Return(nil, domain.ErrUserNotFound).Once()
+			},
+			args: args{
+				uname: "eve",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: domain.ErrWrongCredentials,
+			},
+		},
+		{
+			name: "should lock username and record it when failures reach maximum",
+			prepare: func() {
+				noFailures("user:bob", "ip:10.0.0.1")
+				reserve("user:bob", 3)
+				reserve("ip:10.0.0.1", 3)
+				ur.On("FindByUsername", mock.Anything, "bob").Return(bob, nil).Once()
+				la.On("Lock", mock.Anything, "user:bob", mock.Anything).Return(nil).Once()
+				la.On("InsertLockout", mock.Anything, mock.MatchedBy(func(l domain.Lockout) bool {
+					return l.Key == "user:bob" && l.IP == "10.0.0.1" && l.Failures == 3
+				})).Return(nil).Once()
+			},
+			args: args{
+				uname: "bob",
+				pass:  "wrong",
+			},
+			wants: wants{
+				err: domain.ErrWrongCredentials,
+			},
+		},
+		{
+			name: "should reject parallel attempts beyond maximum without checking password",
+			prepare: func() {
+				noFailures("user:bob", "ip:10.0.0.1")
+				// other attempts reserved the remaining failures in the meantime
+				reserve("user:bob", 4)
+				la.On("RemoveFailure", mock.Anything, "user:bob").Return(nil).Once()
+			},
+			args: args{
+				uname: "bob",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: domain.ErrTooManyAttempts,
+			},
+		},
+		{
+			name: "should count usernames case-sensitively",
+			prepare: func() {
+				noFailures("user:Bob", "ip:10.0.0.1")
+				reserve("user:Bob", 1)
+				reserve("ip:10.0.0.1", 1)
+				ur.On("FindByUsername", mock.Anything, "Bob").Return(nil, domain.ErrUserNotFound).Once()
+			},
+			args: args{
+				uname: "Bob",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: domain.ErrWrongCredentials,
+			},
+		},
+		{
+			name: "should reject attempts during lockout without checking password",
+			prepare: func() {
+				until := time.Now().Add(time.Minute)
+				la.On("Failures", mock.Anything, "user:bob").Return(&domain.LoginFailures{
+					Key: "user:bob", Count: 3, LastAt: time.Now(), LockedUntil: &until,
+				}, nil).Once()
+				noFailures("ip:10.0.0.1")
+			},
+			args: args{
+				uname: "bob",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: domain.ErrTooManyAttempts,
+			},
+		},
+		{
+			name: "should delay the attempt after a recent failure",
+			prepare: func() {
+				noFailures("user:bob")
+				// second failure of the ip, the delay is doubled
+				la.On("Failures", mock.Anything, "ip:10.0.0.1").Return(&domain.LoginFailures{
+					Key: "ip:10.0.0.1", Count: 2, LastAt: time.Now().Add(-time.Second),
+				}, nil).Once()
+			},
+			args: args{
+				uname: "bob",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: domain.ErrTooManyAttempts,
+			},
+		},
+		{
+			name: "should login, forget failures of username and take back the attempt of ip",
+			prepare: func() {
+				la.On("Failures", mock.Anything, "user:bob").Return(&domain.LoginFailures{
+					Key: "user:bob", Count: 1, LastAt: time.Now().Add(-2 * time.Second),
+				}, nil).Once()
+				noFailures("ip:10.0.0.1")
+				reserve("user:bob", 2)
+				reserve("ip:10.0.0.1", 1)
+				ur.On("FindByUsername", mock.Anything, "bob").Return(bob, nil).Once()
+				la.On("Reset", mock.Anything, "user:bob").Return(nil).Once()
+				la.On("RemoveFailure", mock.Anything, "ip:10.0.0.1").Return(nil).Once()
+				tf.On("Find", mock.Anything, uint(4)).Return(nil, domain.ErrTwoFactorNotFound).Once()
+				tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{}, nil).Once()
+				jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+				jr.On("Insert", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(nil).Once()
+				jr.On("InsertRefresh", mock.Anything, mock.Anything).Return(nil).Once()
+				jr.On("Commit").Once()
+				jr.On("UserTokensCount", mock.Anything, uint(4)).Return(uint(1), nil).Once()
+			},
+			args: args{
+				uname: "bob",
+				pass:  "secret",
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+	}
+
+	jwt, err := user.NewJWTManager(
+		[]*user.JWTKey{user.NewHMACKey("", "secret")}, "", time.Minute, time.Hour,
+	)
+	require.NoError(t, err)
+	user.UserService = nil
+	svc := user.InitService(ur, jr, la, tf, jwt, policy, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		result, err := svc.Login(ctx, tc.args.uname, tc.args.pass)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, result, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.NotEmpty(t, result.AccessToken, tc.name)
+	}
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+	la.AssertExpectations(t)
+	tf.AssertExpectations(t)
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/user/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (h *UserHandler) SetRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+	req := new(requests.SetRole)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	user, err := h.us.SetRole(c.Request().Context(), uint(id), domain.Role(req.Role))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Role updated.", user,
+	))
+}
+
+func (h *UserHandler) Unlock(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	err = h.us.Unlock(c.Request().Context(), uint(id))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "User unlocked.", nil,
+	))
+}
+
+func (h *UserHandler) Lockouts(c echo.Context) error {
+	lockouts, err := h.us.Lockouts(c.Request().Context())
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", lockouts,
+	))
+}
+
+func (h *UserHandler) Disable(c echo.Context) error {
+	return h.setDisabled(c, true)
+}
+
+func (h *UserHandler) Enable(c echo.Context) error {
+	return h.setDisabled(c, false)
+}
+
+func (h *UserHandler) setDisabled(c echo.Context, disabled bool) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	user, err := h.us.SetDisabled(c.Request().Context(), uint(id), disabled)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	msg := "User enabled."
+	if disabled {
+		msg = "User disabled."
+	}
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, msg, user,
+	))
+}
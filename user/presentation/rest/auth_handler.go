@@ -2,6 +2,7 @@ package rest
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/apm-dev/vending-machine/domain"
 	"github.com/apm-dev/vending-machine/pkg/httputil"
@@ -17,7 +18,7 @@ func (h *UserHandler) Register(c echo.Context) error {
 		))
 	}
 
-	token, err := h.us.Register(
+	result, err := h.us.Register(
 		c.Request().Context(),
 		req.Username, req.Password,
 		domain.Role(req.Role),
@@ -28,10 +29,7 @@ func (h *UserHandler) Register(c echo.Context) error {
 			status, err.Error(), nil,
 		))
 	}
-
-	return c.JSON(http.StatusOK, httputil.MakeResponse(
-		http.StatusOK, "welcome "+req.Username, echo.Map{"token": token},
-	))
+	return h.loginResponse(c, req.Username, result)
 }
 
 func (h *UserHandler) Login(c echo.Context) error {
@@ -42,7 +40,7 @@ func (h *UserHandler) Login(c echo.Context) error {
 		))
 	}
 
-	token, activeSessions, err := h.us.Login(
+	result, err := h.us.Login(
 		c.Request().Context(),
 		req.Username, req.Password,
 	)
@@ -52,16 +50,77 @@ func (h *UserHandler) Login(c echo.Context) error {
 			status, err.Error(), nil,
 		))
 	}
+	return h.loginResponse(c, req.Username, result)
+}
+
+func (h *UserHandler) LoginTwoFactor(c echo.Context) error {
+	req := new(requests.LoginTwoFactor)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	result, err := h.us.VerifyLogin(c.Request().Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+	return h.loginResponse(c, "", result)
+}
+
+func (h *UserHandler) loginResponse(c echo.Context, uname string, result *domain.LoginResult) error {
+	if result.Challenge != nil {
+		msg := "Enter the code of your authenticator app."
+		if result.Challenge.Enroll {
+			msg = "Two-factor authentication is required, scan the enrollment uri and enter its code."
+		}
+		return c.JSON(http.StatusOK, httputil.MakeResponse(
+			http.StatusOK, msg, result,
+		))
+	}
 	// notify the user if there was another active session
-	msg := "welcome " + req.Username
-	if activeSessions {
+	msg := "welcome"
+	if uname != "" {
+		msg += " " + uname
+	}
+	if result.OtherSessions {
 		msg += ", there is another active session."
 	}
 	return c.JSON(http.StatusOK, httputil.MakeResponse(
-		http.StatusOK, msg, echo.Map{"token": token},
+		http.StatusOK, msg, result,
+	))
+}
+
+func (h *UserHandler) Refresh(c echo.Context) error {
+	req := new(requests.Refresh)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	pair, err := h.us.Refresh(c.Request().Context(), req.RefreshToken)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", pair,
 	))
 }
 
+// JWKS serves public keys in the standard format for other services,
+// so it isn't wrapped in the usual response
+func (h *UserHandler) JWKS(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.us.PublicKeys(c.Request().Context()))
+}
+
 func (h *UserHandler) LogoutAll(c echo.Context) error {
 	err := h.us.TerminateActiveSessions(c.Request().Context())
 	if err != nil {
@@ -79,6 +138,12 @@ func (h *UserHandler) LogoutAll(c echo.Context) error {
 }
 
 func (h *UserHandler) UpdatePassword(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
 	req := new(requests.UpdatePassword)
 	if err := httputil.BindAndValidate(c, req); err != nil {
 		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
@@ -86,7 +151,7 @@ func (h *UserHandler) UpdatePassword(c echo.Context) error {
 		))
 	}
 
-	err := h.us.Update(c.Request().Context(), req.Password)
+	err = h.us.Update(c.Request().Context(), uint(id), req.Password)
 	if err != nil {
 		status := httputil.StatusCode(err)
 		return c.JSON(status, httputil.MakeResponse(
@@ -100,7 +165,14 @@ func (h *UserHandler) UpdatePassword(c echo.Context) error {
 }
 
 func (h *UserHandler) DeleteAccount(c echo.Context) error {
-	refund, err := h.us.Delete(c.Request().Context())
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	refund, err := h.us.Delete(c.Request().Context(), uint(id))
 	if err != nil {
 		status := httputil.StatusCode(err)
 		return c.JSON(status, httputil.MakeResponse(
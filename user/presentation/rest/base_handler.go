@@ -21,10 +21,25 @@ func InitUserHandler(e *echo.Echo, auth *echo.Group, us domain.UserService) *Use
 	// public routes
 	e.POST("/register", h.Register)
 	e.POST("/login", h.Login)
+	// second step of logins with 2FA
+	e.POST("/login/2fa", h.LoginTwoFactor)
+	e.POST("/refresh", h.Refresh)
+	e.GET("/.well-known/jwks.json", h.JWKS)
 	// auth required routes
+	auth.POST("/logout", h.Logout)
 	auth.POST("/logout/all", h.LogoutAll)
+	// sessions of the user, id of session is not the token
+	auth.GET("/sessions", h.Sessions)
+	auth.PATCH("/sessions/:id", h.RenameSession)
+	auth.DELETE("/sessions/:id", h.RevokeSession)
 	auth.POST("/deposit", h.Deposit)
 	auth.POST("/reset", h.ResetDeposit)
+	auth.PUT("/diet", h.UpdateDietaryProfile)
+	// TOTP two-factor authentication of the user
+	auth.POST("/2fa/enroll", h.EnrollTwoFactor)
+	auth.POST("/2fa/confirm", h.ConfirmTwoFactor)
+	auth.POST("/2fa/backup-codes", h.RegenerateBackupCodes)
+	auth.POST("/2fa/disable", h.DisableTwoFactor)
 	// user CRUD
 	// Restful standard
 	// GET 			/users, /users/:id
@@ -34,8 +49,27 @@ func InitUserHandler(e *echo.Echo, auth *echo.Group, us domain.UserService) *Use
 	u := auth.Group("/users")
 	u.GET("/", h.List)
 	u.GET("/:id", h.Profile)
+	// users act on themselves, ADMINs on any user (password reset)
 	u.PATCH("/:id", h.UpdatePassword)
 	u.DELETE("/:id", h.DeleteAccount)
+	// admin session control of any user
+	u.GET("/:id/sessions", h.UserSessions)
+	u.DELETE("/:id/sessions", h.RevokeUserSessions)
+	// admin management of accounts
+	a := auth.Group("/admin/users")
+	a.PUT("/:id/role", h.SetRole)
+	a.POST("/:id/disable", h.Disable)
+	a.POST("/:id/enable", h.Enable)
+	a.POST("/:id/unlock", h.Unlock)
+	a.DELETE("/:id/2fa", h.ResetTwoFactor)
+	auth.GET("/admin/lockouts", h.Lockouts)
+	auth.GET("/admin/2fa/roles", h.TwoFactorRoles)
+	auth.PUT("/admin/2fa/roles", h.SetTwoFactorRoles)
+	// admin trash of soft-deleted users
+	t := auth.Group("/admin/trash/users")
+	t.GET("/", h.ListDeleted)
+	t.POST("/:id/restore", h.Restore)
+	t.DELETE("/:id", h.Purge)
 
 	return h
 }
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/user/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (h *UserHandler) UpdateDietaryProfile(c echo.Context) error {
+	req := new(requests.DietaryProfile)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	profile := domain.DietaryProfile{
+		Avoid:   make([]domain.Allergen, len(req.Avoid)),
+		Require: make([]domain.DietLabel, len(req.Require)),
+	}
+	for i, a := range req.Avoid {
+		profile.Avoid[i] = domain.Allergen(a)
+	}
+	for i, l := range req.Require {
+		profile.Require[i] = domain.DietLabel(l)
+	}
+
+	user, err := h.us.UpdateDietaryProfile(c.Request().Context(), profile)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", user.Diet,
+	))
+}
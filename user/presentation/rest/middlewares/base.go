@@ -1,12 +1,17 @@
 package middlewares
 
-import "github.com/apm-dev/vending-machine/domain"
+import (
+	"net"
+
+	"github.com/apm-dev/vending-machine/domain"
+)
 
 type UserMiddleware struct {
 	us domain.UserService
+	// proxies which client ips are taken from forwarded headers of
+	tp []*net.IPNet
 }
 
-func InitUserMiddleware(us domain.UserService) *UserMiddleware {
-	return &UserMiddleware{us}
+func InitUserMiddleware(us domain.UserService, trustedProxies []*net.IPNet) *UserMiddleware {
+	return &UserMiddleware{us, trustedProxies}
 }
-
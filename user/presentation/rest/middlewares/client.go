@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"context"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/labstack/echo"
+)
+
+// ClientInfo sets user agent and ip of the caller on context, they are
+// recorded on sessions, forwarded ips are taken only from trusted proxies
+func (m *UserMiddleware) ClientInfo(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := context.WithValue(c.Request().Context(), domain.CLIENT, domain.ClientInfo{
+			UserAgent: c.Request().UserAgent(),
+			IP:        httputil.ClientIP(c.Request(), m.tp),
+		})
+		c.SetRequest(c.Request().Clone(ctx))
+
+		return next(c)
+	}
+}
@@ -12,6 +12,11 @@ import (
 
 func (m *UserMiddleware) JwtAuth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		// already authorized by JwtIdentify
+		if _, err := domain.UserFromContext(c.Request().Context()); err == nil {
+			return next(c)
+		}
+
 		token := c.Request().Header.Get("Authorization")
 		if token == "" {
 			return c.JSON(http.StatusUnauthorized, httputil.MakeResponse(
@@ -39,3 +44,50 @@ func (m *UserMiddleware) JwtAuth(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// JwtIdentify sets user and token on context when a valid token is sent,
+// but unlike JwtAuth it lets anonymous requests pass, used by public routes
+// which behave differently for known users
+func (m *UserMiddleware) JwtIdentify(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.Request().Header.Get("Authorization")
+		if token == "" {
+			return next(c)
+		}
+
+		token = strings.TrimSpace(strings.Replace(token, "Bearer", "", 1))
+		user, err := m.us.Authorize(c.Request().Context(), token)
+		if err != nil {
+			return next(c)
+		}
+
+		ctx := c.Request().Context()
+		ctx = context.WithValue(ctx, domain.TOKEN, token)
+		ctx = context.WithValue(ctx, domain.USER, user)
+		c.SetRequest(c.Request().Clone(ctx))
+
+		return next(c)
+	}
+}
+
+// JwtSubject sets id of the user of a validly signed token on context,
+// it doesn't hit db so it's cheap enough to run before rate limiting
+func (m *UserMiddleware) JwtSubject(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.Request().Header.Get("Authorization")
+		if token == "" {
+			return next(c)
+		}
+
+		token = strings.TrimSpace(strings.Replace(token, "Bearer", "", 1))
+		id, err := m.us.Subject(c.Request().Context(), token)
+		if err != nil {
+			return next(c)
+		}
+
+		ctx := context.WithValue(c.Request().Context(), domain.SUBJECT, id)
+		c.SetRequest(c.Request().Clone(ctx))
+
+		return next(c)
+	}
+}
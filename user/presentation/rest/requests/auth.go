@@ -2,15 +2,28 @@ package requests
 
 type Register struct {
 	Username string `json:"username" validate:"required,alphanum"`
-	Password string `json:"password" validate:"required,alphanum,min=3"`
+	Password string `json:"password" validate:"required,max=128"`
 	Role     string `json:"role" validate:"required,oneof=seller buyer"`
 }
 
 type Login struct {
 	Username string `json:"username" validate:"required,alphanum"`
-	Password string `json:"password" validate:"required,min=3"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type SetRole struct {
+	Role string `json:"role" validate:"required,oneof=admin seller buyer"`
 }
 
 type UpdatePassword struct {
-	Password string `json:"password" validate:"required,alphanum,min=3"`
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+type DietaryProfile struct {
+	Avoid   []string `json:"avoid"`
+	Require []string `json:"require"`
 }
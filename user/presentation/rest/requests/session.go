@@ -0,0 +1,5 @@
+package requests
+
+type RenameSession struct {
+	Name string `json:"name" validate:"max=64"`
+}
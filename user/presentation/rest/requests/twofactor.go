@@ -0,0 +1,14 @@
+package requests
+
+type LoginTwoFactor struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorRoles struct {
+	Roles []string `json:"roles" validate:"dive,oneof=admin seller buyer"`
+}
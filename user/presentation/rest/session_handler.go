@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/user/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (h *UserHandler) Logout(c echo.Context) error {
+	err := h.us.Logout(c.Request().Context())
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Logged out.", nil,
+	))
+}
+
+func (h *UserHandler) Sessions(c echo.Context) error {
+	sessions, err := h.us.Sessions(c.Request().Context())
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", sessions,
+	))
+}
+
+func (h *UserHandler) RenameSession(c echo.Context) error {
+	req := new(requests.RenameSession)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	err := h.us.RenameSession(c.Request().Context(), c.Param("id"), req.Name)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Session renamed.", nil,
+	))
+}
+
+func (h *UserHandler) RevokeSession(c echo.Context) error {
+	err := h.us.RevokeSession(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Session revoked.", nil,
+	))
+}
+
+func (h *UserHandler) UserSessions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	sessions, err := h.us.UserSessions(c.Request().Context(), uint(id))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", sessions,
+	))
+}
+
+func (h *UserHandler) RevokeUserSessions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	err = h.us.RevokeUserSessions(c.Request().Context(), uint(id))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "All sessions of the user have been revoked.", nil,
+	))
+}
@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/httputil"
+	"github.com/apm-dev/vending-machine/user/presentation/rest/requests"
+	"github.com/labstack/echo"
+)
+
+func (h *UserHandler) EnrollTwoFactor(c echo.Context) error {
+	enrollment, err := h.us.EnrollTwoFactor(c.Request().Context())
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Scan the uri with your authenticator app and confirm its code.", enrollment,
+	))
+}
+
+func (h *UserHandler) ConfirmTwoFactor(c echo.Context) error {
+	req := new(requests.TwoFactorCode)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	codes, err := h.us.ConfirmTwoFactor(c.Request().Context(), req.Code)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Two-factor authentication enabled, keep the backup codes safe.", codes,
+	))
+}
+
+func (h *UserHandler) RegenerateBackupCodes(c echo.Context) error {
+	req := new(requests.TwoFactorCode)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	codes, err := h.us.RegenerateBackupCodes(c.Request().Context(), req.Code)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Backup codes replaced.", codes,
+	))
+}
+
+func (h *UserHandler) DisableTwoFactor(c echo.Context) error {
+	req := new(requests.TwoFactorCode)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	err := h.us.DisableTwoFactor(c.Request().Context(), req.Code)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Two-factor authentication disabled.", nil,
+	))
+}
+
+func (h *UserHandler) ResetTwoFactor(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, ":id must be positive number", nil,
+		))
+	}
+
+	err = h.us.ResetTwoFactor(c.Request().Context(), uint(id))
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Two-factor authentication reset.", nil,
+	))
+}
+
+func (h *UserHandler) TwoFactorRoles(c echo.Context) error {
+	roles, err := h.us.TwoFactorRoles(c.Request().Context())
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "", roles,
+	))
+}
+
+func (h *UserHandler) SetTwoFactorRoles(c echo.Context) error {
+	req := new(requests.TwoFactorRoles)
+	if err := httputil.BindAndValidate(c, req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.MakeResponse(
+			http.StatusBadRequest, err.Error(), nil,
+		))
+	}
+
+	roles := make([]domain.Role, len(req.Roles))
+	for i, r := range req.Roles {
+		roles[i] = domain.Role(r)
+	}
+	err := h.us.SetTwoFactorRoles(c.Request().Context(), roles)
+	if err != nil {
+		status := httputil.StatusCode(err)
+		return c.JSON(status, httputil.MakeResponse(
+			status, err.Error(), nil,
+		))
+	}
+
+	return c.JSON(http.StatusOK, httputil.MakeResponse(
+		http.StatusOK, "Roles which require two-factor authentication updated.", roles,
+	))
+}
@@ -0,0 +1,155 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Refresh exchanges refresh token for a new token pair,
+// reusing an exchanged token revokes all tokens of its family
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	const op string = "user.service.Refresh"
+
+	rt, err := s.jr.FindRefresh(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	if rt.Used() {
+		s.revokeFamily(ctx, rt)
+		return nil, domain.ErrInvalidToken
+	}
+	if rt.Expired(time.Now()) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.ur.FindById(ctx, rt.UserId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if user.Disabled() {
+		return nil, domain.ErrUserDisabled
+	}
+	// sessions opened before 2FA was required for the role end here,
+	// the user enrols on next login
+	if err := s.checkTwoFactorRequirement(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// the token is used only when the new pair is persisted,
+	// concurrent refreshes with the same token wait for it and are reuse
+	txCtx, jr := s.jr.BeginTransaction(ctx)
+	marked, err := jr.MarkRefreshUsed(txCtx, rt.Token)
+	if err != nil {
+		jr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if !marked {
+		jr.Rollback()
+		s.revokeFamily(ctx, rt)
+		return nil, domain.ErrInvalidToken
+	}
+
+	pair, err := s.issueTokens(txCtx, jr, user, rt.Family)
+	if err != nil {
+		jr.Rollback()
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	jr.Commit()
+
+	return pair, nil
+}
+
+// startSession issues tokens of a new session of the user in one transaction
+func (s *Service) startSession(ctx context.Context, user *domain.User) (*domain.TokenPair, error) {
+	ctx, jr := s.jr.BeginTransaction(ctx)
+	pair, err := s.issueTokens(ctx, jr, user, "")
+	if err != nil {
+		jr.Rollback()
+		return nil, err
+	}
+	jr.Commit()
+	return pair, nil
+}
+
+// issueTokens generates and persists access and refresh tokens of the user by
+// jr of the caller's transaction, empty family starts a new session (login),
+// otherwise the session is rotated
+func (s *Service) issueTokens(ctx context.Context, jr domain.JwtRepository, user *domain.User, family string) (
+	*domain.TokenPair, error,
+) {
+	const op string = "user.service.issueTokens"
+
+	access, err := s.jwt.Generate(user)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	client := domain.ClientFromContext(ctx)
+	if family == "" {
+		// new session
+		family, err = s.jwt.GenerateRefresh()
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		err = jr.Insert(ctx, access, domain.Session{
+			Id:        family,
+			UserId:    user.Id,
+			UserAgent: client.UserAgent,
+			IP:        client.IP,
+		}, s.jwt.tokenDuration)
+	} else {
+		// access token of the exchanged pair is replaced by the new one
+		err = jr.Rotate(ctx, family, access, s.jwt.tokenDuration, client)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	refresh, err := s.jwt.GenerateRefresh()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	err = jr.InsertRefresh(ctx, domain.RefreshToken{
+		Token:     refresh,
+		UserId:    user.Id,
+		Family:    family,
+		ExpiredAt: time.Now().Add(s.jwt.refreshDuration),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &domain.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    uint(s.jwt.tokenDuration / time.Second),
+	}, nil
+}
+
+// revokeFamily is called on refresh token reuse, the token may be stolen
+// so neither the attacker nor the owner keep a valid token of the chain
+func (s *Service) revokeFamily(ctx context.Context, rt *domain.RefreshToken) {
+	const op string = "user.service.revokeFamily"
+
+	logger.Log(logger.WARN, fmt.Sprintf(
+		"%s: reuse of refresh token of user %d, revoking its family", op, rt.UserId,
+	))
+	if err := s.jr.RevokeFamily(ctx, rt.Family); err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+	}
+}
@@ -0,0 +1,209 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Refresh(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		token string
+	}
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+	tf := new(mocks.TwoFactorRepository)
+
+	now := time.Now()
+	refresh := func(token string, used bool, expiredAt time.Time) *domain.RefreshToken {
+		rt := &domain.RefreshToken{
+			Token:     token,
+			UserId:    1,
+			Family:    "fam",
+			ExpiredAt: expiredAt,
+		}
+		if used {
+			rt.UsedAt = &now
+		}
+		return rt
+	}
+
+	testCases := []testCase{
+		{
+			name: "should rotate refresh token within its family",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-1").
+					Return(refresh("rt-1", false, now.Add(time.Hour)), nil).Once()
+				ur.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.User{Id: 1, Role: domain.BUYER}, nil).Once()
+				tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.ADMIN}, nil).Once()
+				// token is used in the transaction of the new pair
+				jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+				jr.On("MarkRefreshUsed", mock.Anything, "rt-1").Return(true, nil).Once()
+				// access token of the session is replaced
+				jr.On("Rotate", mock.Anything, "fam", mock.AnythingOfType("string"), time.Minute,
+					domain.ClientInfo{}).Return(nil).Once()
+				jr.On("InsertRefresh", mock.Anything, mock.MatchedBy(func(rt domain.RefreshToken) bool {
+					return rt.Family == "fam" && rt.UserId == 1 && rt.Token != "rt-1" && !rt.Used()
+				})).Return(nil).Once()
+				jr.On("Commit").Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-1",
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+		{
+			name: "should revoke family when used token is presented again",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-2").
+					Return(refresh("rt-2", true, now.Add(time.Hour)), nil).Once()
+				jr.On("RevokeFamily", mock.Anything, "fam").Return(nil).Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-2",
+			},
+			wants: wants{
+				err: domain.ErrInvalidToken,
+			},
+		},
+		{
+			name: "should revoke family when token is used concurrently",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-3").
+					Return(refresh("rt-3", false, now.Add(time.Hour)), nil).Once()
+				ur.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.User{Id: 1, Role: domain.BUYER}, nil).Once()
+				tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.ADMIN}, nil).Once()
+				jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+				jr.On("MarkRefreshUsed", mock.Anything, "rt-3").Return(false, nil).Once()
+				jr.On("Rollback").Once()
+				jr.On("RevokeFamily", mock.Anything, "fam").Return(nil).Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-3",
+			},
+			wants: wants{
+				err: domain.ErrInvalidToken,
+			},
+		},
+		{
+			name: "should keep token unused when the new pair can't be persisted",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-7").
+					Return(refresh("rt-7", false, now.Add(time.Hour)), nil).Once()
+				ur.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.User{Id: 1, Role: domain.BUYER}, nil).Once()
+				tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.ADMIN}, nil).Once()
+				jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+				jr.On("MarkRefreshUsed", mock.Anything, "rt-7").Return(true, nil).Once()
+				jr.On("Rotate", mock.Anything, "fam", mock.AnythingOfType("string"), time.Minute,
+					domain.ClientInfo{}).Return(errors.New("connection reset")).Once()
+				jr.On("Rollback").Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-7",
+			},
+			wants: wants{
+				err: domain.ErrInternalServer,
+			},
+		},
+		{
+			name: "should fail when refresh token is expired",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-4").
+					Return(refresh("rt-4", false, now.Add(-time.Minute)), nil).Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-4",
+			},
+			wants: wants{
+				err: domain.ErrInvalidToken,
+			},
+		},
+		{
+			name: "should fail when role of user requires 2FA which isn't enabled",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-6").
+					Return(refresh("rt-6", false, now.Add(time.Hour)), nil).Once()
+				ur.On("FindById", mock.Anything, uint(1)).
+					Return(&domain.User{Id: 1, Role: domain.SELLER}, nil).Once()
+				tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.SELLER}, nil).Once()
+				tf.On("Find", mock.Anything, uint(1)).Return(nil, domain.ErrTwoFactorNotFound).Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-6",
+			},
+			wants: wants{
+				err: domain.ErrTwoFactorRequired,
+			},
+		},
+		{
+			name: "should fail when refresh token is unknown",
+			prepare: func() {
+				jr.On("FindRefresh", mock.Anything, "rt-5").
+					Return(nil, domain.ErrInvalidToken).Once()
+			},
+			args: args{
+				ctx:   context.Background(),
+				token: "rt-5",
+			},
+			wants: wants{
+				err: domain.ErrInvalidToken,
+			},
+		},
+	}
+
+	jwt, err := user.NewJWTManager(
+		[]*user.JWTKey{user.NewHMACKey("", "secret")}, "", time.Minute, time.Hour,
+	)
+	assert.NoError(t, err)
+	user.UserService = nil
+	svc := user.InitService(ur, jr, nil, tf, jwt, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		pair, err := svc.Refresh(tc.args.ctx, tc.args.token)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, pair, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.NotEmpty(t, pair.AccessToken, tc.name)
+		assert.NotEmpty(t, pair.RefreshToken, tc.name)
+		assert.EqualValues(t, 60, pair.ExpiresIn, tc.name)
+	}
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+	tf.AssertExpectations(t)
+}
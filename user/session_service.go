@@ -0,0 +1,173 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Logout terminates the current session
+func (s *Service) Logout(ctx context.Context) error {
+	const op string = "user.service.Logout"
+
+	token, err := domain.TokenFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	current, err := s.jr.FindSessionByToken(ctx, token)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	err = s.jr.RevokeFamily(ctx, current.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+// Sessions lists active sessions of the user
+func (s *Service) Sessions(ctx context.Context) ([]domain.Session, error) {
+	const op string = "user.service.Sessions"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	token, err := domain.TokenFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	current, err := s.jr.FindSessionByToken(ctx, token)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	sessions, err := s.jr.ListSessions(ctx, u.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == current.Id
+	}
+
+	return sessions, nil
+}
+
+// RenameSession names a session of the user
+func (s *Service) RenameSession(ctx context.Context, id, name string) error {
+	const op string = "user.service.RenameSession"
+
+	if _, err := s.ownSession(ctx, id); err != nil {
+		return err
+	}
+
+	err := s.jr.RenameSession(ctx, id, name)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	return nil
+}
+
+// RevokeSession terminates a session of the user, ADMIN can revoke any session
+func (s *Service) RevokeSession(ctx context.Context, id string) error {
+	const op string = "user.service.RevokeSession"
+
+	session, err := s.ownSession(ctx, id)
+	if errors.Is(err, domain.ErrSessionNotFound) && s.requireAdmin(ctx) == nil {
+		// sessions of other users are hidden behind not found, except for admins
+		session, err = s.jr.FindSession(ctx, id)
+		if err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return domain.ErrInternalServer
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.jr.RevokeFamily(ctx, session.Id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("session of user %d revoked", session.UserId))
+	return nil
+}
+
+// UserSessions lists active sessions of any user (ADMIN only)
+func (s *Service) UserSessions(ctx context.Context, userId uint) ([]domain.Session, error) {
+	const op string = "user.service.UserSessions"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.jr.ListSessions(ctx, userId)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSessions terminates all sessions of any user (ADMIN only)
+func (s *Service) RevokeUserSessions(ctx context.Context, userId uint) error {
+	const op string = "user.service.RevokeUserSessions"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	// no token is excepted
+	err := s.jr.DeleteTokensOfUserExcept(ctx, userId, "")
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("all sessions of user %d revoked", userId))
+	return nil
+}
+
+// ownSession returns the session when it belongs to the context user,
+// otherwise ErrSessionNotFound
+func (s *Service) ownSession(ctx context.Context, id string) (*domain.Session, error) {
+	const op string = "user.service.ownSession"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	session, err := s.jr.FindSession(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			return nil, domain.ErrSessionNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if session.UserId != u.Id {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	return session, nil
+}
@@ -0,0 +1,118 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_RevokeSession(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  string
+	}
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		args    args
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+
+	buyer := &domain.User{Id: 1, Role: domain.BUYER}
+	admin := &domain.User{Id: 9, Role: domain.ADMIN}
+	buyerContext := context.WithValue(context.Background(), domain.USER, buyer)
+	adminContext := context.WithValue(context.Background(), domain.USER, admin)
+	session := func(id string, userId uint) *domain.Session {
+		return &domain.Session{Id: id, UserId: userId}
+	}
+
+	testCases := []testCase{
+		{
+			name: "should revoke own session",
+			prepare: func() {
+				jr.On("FindSession", mock.Anything, "s-1").Return(session("s-1", 1), nil).Once()
+				jr.On("RevokeFamily", mock.Anything, "s-1").Return(nil).Once()
+			},
+			args: args{
+				ctx: buyerContext,
+				id:  "s-1",
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+		{
+			name: "should hide session of another user",
+			prepare: func() {
+				jr.On("FindSession", mock.Anything, "s-2").Return(session("s-2", 2), nil).Once()
+				ur.On("FindById", mock.Anything, uint(1)).Return(buyer, nil).Once()
+			},
+			args: args{
+				ctx: buyerContext,
+				id:  "s-2",
+			},
+			wants: wants{
+				err: domain.ErrSessionNotFound,
+			},
+		},
+		{
+			name: "should let admin revoke session of any user",
+			prepare: func() {
+				jr.On("FindSession", mock.Anything, "s-2").Return(session("s-2", 2), nil).Twice()
+				ur.On("FindById", mock.Anything, uint(9)).Return(admin, nil).Once()
+				jr.On("RevokeFamily", mock.Anything, "s-2").Return(nil).Once()
+			},
+			args: args{
+				ctx: adminContext,
+				id:  "s-2",
+			},
+			wants: wants{
+				err: nil,
+			},
+		},
+		{
+			name: "should fail when session doesn't exist",
+			prepare: func() {
+				jr.On("FindSession", mock.Anything, "s-3").Return(nil, domain.ErrSessionNotFound).Twice()
+				ur.On("FindById", mock.Anything, uint(9)).Return(admin, nil).Once()
+			},
+			args: args{
+				ctx: adminContext,
+				id:  "s-3",
+			},
+			wants: wants{
+				err: domain.ErrSessionNotFound,
+			},
+		},
+	}
+
+	user.UserService = nil
+	svc := user.InitService(ur, jr, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		err := svc.RevokeSession(tc.args.ctx, tc.args.id)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+		} else {
+			assert.NoError(t, err, tc.name)
+		}
+	}
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+}
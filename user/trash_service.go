@@ -0,0 +1,98 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// ListDeleted returns soft-deleted users (ADMIN only)
+func (s *Service) ListDeleted(ctx context.Context) ([]domain.User, error) {
+	const op string = "user.service.ListDeleted"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	users, err := s.ur.ListDeleted(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	return users, nil
+}
+
+// Restore brings back soft-deleted user with its deposit (ADMIN only),
+// the deposit which was refunded on delete is credited back
+func (s *Service) Restore(ctx context.Context, id uint) (*domain.User, error) {
+	const op string = "user.service.Restore"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := s.ur.FindDeletedById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	err = s.ur.Restore(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+
+	// deposit of the row in trash was zeroed, the restored one has it back
+	user, err = s.ur.FindById(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	logger.Log(logger.INFO, fmt.Sprintf("%s restored with %d deposit", user.Username, user.Deposit))
+
+	return user, nil
+}
+
+// Purge permanently deletes soft-deleted user (ADMIN only)
+func (s *Service) Purge(ctx context.Context, id uint) error {
+	const op string = "user.service.Purge"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	// only users in trash can be purged
+	user, err := s.ur.FindDeletedById(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return domain.ErrUserNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	// tokens reference the user row
+	err = s.jr.DeleteTokensOfUserExcept(ctx, id, "")
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	err = s.ur.Purge(ctx, id)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("%s purged", user.Username))
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Service_Restore(t *testing.T) {
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+
+	admin := &domain.User{Id: 1, Role: domain.ADMIN}
+	adminContext := context.WithValue(context.Background(), domain.USER, admin)
+	deletedAt := time.Now()
+	newDeleted := func() *domain.User {
+		// deposit was refunded and zeroed on delete
+		return &domain.User{Id: 2, Username: "bob", Role: domain.BUYER, DeletedAt: &deletedAt}
+	}
+
+	testCases := []testCase{
+		{
+			name: "should restore deleted user with its deposit",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindDeletedById", mock.Anything, uint(2)).Return(newDeleted(), nil).Once()
+				ur.On("Restore", mock.Anything, uint(2)).Return(nil).Once()
+				// restore credits back the refunded deposit
+				ur.On("FindById", mock.Anything, uint(2)).
+					Return(&domain.User{Id: 2, Username: "bob", Role: domain.BUYER, Deposit: 30}, nil).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: nil},
+		},
+		{
+			name: "should fail when user isn't in trash",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindDeletedById", mock.Anything, uint(2)).
+					Return(nil, domain.ErrUserNotFound).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: domain.ErrUserNotFound},
+		},
+		{
+			name: "should fail when non admin restores",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(3)).
+					Return(&domain.User{Id: 3, Role: domain.SELLER}, nil).Once()
+			},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 3, Role: domain.SELLER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	user.UserService = nil
+	svc := user.InitService(ur, nil, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		u, err := svc.Restore(tc.ctx, 2)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			assert.Nil(t, u, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Nil(t, u.DeletedAt, tc.name)
+		assert.EqualValues(t, 30, u.Deposit, tc.name)
+	}
+	ur.AssertExpectations(t)
+}
+
+func Test_Service_Purge(t *testing.T) {
+	type wants struct {
+		err error
+	}
+	type testCase struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wants   wants
+	}
+
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+
+	admin := &domain.User{Id: 1, Role: domain.ADMIN}
+	adminContext := context.WithValue(context.Background(), domain.USER, admin)
+	deletedAt := time.Now()
+
+	testCases := []testCase{
+		{
+			name: "should delete tokens and purge deleted user",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindDeletedById", mock.Anything, uint(2)).
+					Return(&domain.User{Id: 2, Username: "bob", DeletedAt: &deletedAt}, nil).Once()
+				jr.On("DeleteTokensOfUserExcept", mock.Anything, uint(2), "").Return(nil).Once()
+				ur.On("Purge", mock.Anything, uint(2)).Return(nil).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: nil},
+		},
+		{
+			name: "should fail when user isn't in trash",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(1)).Return(admin, nil).Once()
+				ur.On("FindDeletedById", mock.Anything, uint(2)).
+					Return(nil, domain.ErrUserNotFound).Once()
+			},
+			ctx:   adminContext,
+			wants: wants{err: domain.ErrUserNotFound},
+		},
+		{
+			name: "should fail when non admin purges",
+			prepare: func() {
+				ur.On("FindById", mock.Anything, uint(3)).
+					Return(&domain.User{Id: 3, Role: domain.BUYER}, nil).Once()
+			},
+			ctx: context.WithValue(context.Background(), domain.USER, &domain.User{
+				Id: 3, Role: domain.BUYER,
+			}),
+			wants: wants{err: domain.ErrPermissionDenied},
+		},
+	}
+
+	user.UserService = nil
+	svc := user.InitService(ur, jr, nil, nil, nil, domain.LoginPolicy{}, domain.PasswordPolicy{}, domain.TwoFactorPolicy{}, time.Second)
+
+	for _, tc := range testCases {
+		// arrange
+		tc.prepare()
+		// action
+		err := svc.Purge(tc.ctx, 2)
+		// assert
+		if tc.wants.err != nil {
+			assert.ErrorIs(t, err, tc.wants.err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+	}
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+}
@@ -0,0 +1,488 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/pkg/logger"
+	"github.com/apm-dev/vending-machine/pkg/totp"
+	"github.com/pkg/errors"
+)
+
+// VerifyLogin completes login of the challenge with a TOTP or backup code,
+// wrong codes count as failed logins and revoke the challenge after max attempts
+func (s *Service) VerifyLogin(ctx context.Context, challenge, code string) (*domain.LoginResult, error) {
+	const op string = "user.service.VerifyLogin"
+
+	now := time.Now()
+	c, err := s.tf.FindChallenge(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidToken) {
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	user, err := s.ur.FindById(ctx, c.UserId)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	keys := s.loginKeys(ctx, user.Username)
+	counts, err := s.reserveLogin(ctx, keys, now)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled() {
+		s.releaseLogin(ctx, keys)
+		return nil, domain.ErrUserDisabled
+	}
+
+	tf, err := s.tf.Find(ctx, user.Id)
+	if err != nil {
+		s.releaseLogin(ctx, keys)
+		if errors.Is(err, domain.ErrTwoFactorNotFound) {
+			// reset by admin in the meantime
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	// the attempt is counted before checking the code,
+	// so parallel guesses can't pass max attempts together
+	attempts, err := s.tf.AddChallengeAttempt(ctx, c.Token)
+	if err != nil {
+		s.releaseLogin(ctx, keys)
+		if errors.Is(err, domain.ErrInvalidToken) {
+			return nil, domain.ErrInvalidToken
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if attempts > s.tp.MaxAttempts {
+		s.releaseLogin(ctx, keys)
+		s.revokeChallenge(ctx, c)
+		return nil, domain.ErrInvalidToken
+	}
+	// backup codes don't exist before enrolment is confirmed
+	ok, err := s.checkCode(ctx, tf, code, !c.Enroll, now)
+	if err != nil {
+		s.releaseLogin(ctx, keys)
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if !ok {
+		logger.Log(logger.INFO, errors.Wrap(domain.ErrWrongCredentials, user.Username).Error())
+		if attempts == s.tp.MaxAttempts {
+			s.revokeChallenge(ctx, c)
+		}
+		s.loginFailed(ctx, keys, counts, now)
+		return nil, domain.ErrWrongCredentials
+	}
+	if err := s.tf.DeleteChallenge(ctx, c.Token); err != nil {
+		s.releaseLogin(ctx, keys)
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	s.loginSucceeded(ctx, keys)
+
+	var backupCodes []string
+	if c.Enroll {
+		backupCodes, err = s.confirmTwoFactor(ctx, tf, now)
+		if err != nil {
+			logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+			return nil, domain.ErrInternalServer
+		}
+	}
+
+	result, err := s.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	result.BackupCodes = backupCodes
+	return result, nil
+}
+
+// EnrollTwoFactor starts TOTP enrolment of the user, pending enrolment
+// is replaced by a new secret, it's enabled on confirm
+func (s *Service) EnrollTwoFactor(ctx context.Context) (*domain.TwoFactorEnrollment, error) {
+	const op string = "user.service.EnrollTwoFactor"
+
+	user, err := s.refetchContextUserFromDB(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	tf, err := s.tf.Find(ctx, user.Id)
+	if err != nil && !errors.Is(err, domain.ErrTwoFactorNotFound) {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if tf != nil && tf.Enabled() {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	enrollment, err := s.newEnrollment(ctx, user)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return enrollment, nil
+}
+
+// ConfirmTwoFactor enables 2FA with a code of the enrolment and returns backup codes
+func (s *Service) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
+	const op string = "user.service.ConfirmTwoFactor"
+
+	now := time.Now()
+	tf, err := s.contextTwoFactor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, domain.ErrTwoFactorEnabled
+	}
+
+	ok, err := s.checkCode(ctx, tf, code, false, now)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if !ok {
+		return nil, domain.ErrWrongCredentials
+	}
+
+	codes, err := s.confirmTwoFactor(ctx, tf, now)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return codes, nil
+}
+
+// RegenerateBackupCodes replaces backup codes of the user, e.g. when they're used up
+func (s *Service) RegenerateBackupCodes(ctx context.Context, code string) ([]string, error) {
+	const op string = "user.service.RegenerateBackupCodes"
+
+	tf, err := s.enabledTwoFactor(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.newBackupCodes(ctx, tf.UserId)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor disables 2FA of the user with a TOTP or backup code,
+// users of roles which must use 2FA can't disable it
+func (s *Service) DisableTwoFactor(ctx context.Context, code string) error {
+	const op string = "user.service.DisableTwoFactor"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	required, err := s.twoFactorRequired(ctx, u.Role)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	if required {
+		return domain.ErrTwoFactorRequired
+	}
+
+	tf, err := s.enabledTwoFactor(ctx, code)
+	if err != nil {
+		return err
+	}
+	if err := s.tf.Delete(ctx, tf.UserId); err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("%s disabled 2FA", u.Username))
+	return nil
+}
+
+// ResetTwoFactor removes 2FA of the user who lost the device (ADMIN only),
+// the user enrols again on next login when its role must use 2FA
+func (s *Service) ResetTwoFactor(ctx context.Context, id uint) error {
+	const op string = "user.service.ResetTwoFactor"
+
+	user, err := s.otherUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.tf.Delete(ctx, user.Id); err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("2FA of %s reset by admin", user.Username))
+	return nil
+}
+
+// TwoFactorRoles returns roles which must use 2FA (ADMIN only)
+func (s *Service) TwoFactorRoles(ctx context.Context) ([]domain.Role, error) {
+	const op string = "user.service.TwoFactorRoles"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	roles, err := s.tf.RequiredRoles(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return roles, nil
+}
+
+// SetTwoFactorRoles sets roles which must use 2FA (ADMIN only), users of the
+// roles without 2FA enrol on next login and can't refresh their tokens
+func (s *Service) SetTwoFactorRoles(ctx context.Context, roles []domain.Role) error {
+	const op string = "user.service.SetTwoFactorRoles"
+
+	if err := s.requireAdmin(ctx); err != nil {
+		return err
+	}
+	for _, r := range roles {
+		if !r.Valid() {
+			return errors.Wrapf(domain.ErrInvalidParams, "unknown role %q", r)
+		}
+	}
+	if err := s.tf.SetRequiredRoles(ctx, roles); err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+
+	logger.Log(logger.INFO, fmt.Sprintf("2FA is required for roles %v", roles))
+	return nil
+}
+
+// secondFactor returns a challenge when login of the user needs a code, users
+// of roles which must use 2FA get a new enrolment when they don't have it yet
+func (s *Service) secondFactor(ctx context.Context, user *domain.User, now time.Time) (*domain.LoginChallenge, error) {
+	const op string = "user.service.secondFactor"
+
+	tf, err := s.tf.Find(ctx, user.Id)
+	if err != nil && !errors.Is(err, domain.ErrTwoFactorNotFound) {
+		return nil, errors.Wrap(err, op)
+	}
+	c := &domain.LoginChallenge{
+		UserId:    user.Id,
+		ExpiresIn: int64(s.tp.ChallengeTTL / time.Second),
+		ExpiredAt: now.Add(s.tp.ChallengeTTL),
+	}
+	if tf == nil || !tf.Enabled() {
+		required, err := s.twoFactorRequired(ctx, user.Role)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+		if !required {
+			return nil, nil
+		}
+		c.Enroll = true
+		c.Enrollment, err = s.newEnrollment(ctx, user)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+	}
+
+	c.Token, err = s.jwt.GenerateRefresh()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	if err := s.tf.InsertChallenge(ctx, *c); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	return c, nil
+}
+
+// checkTwoFactorRequirement returns ErrTwoFactorRequired when role
+// of the user must use 2FA which the user hasn't enabled
+func (s *Service) checkTwoFactorRequirement(ctx context.Context, user *domain.User) error {
+	const op string = "user.service.checkTwoFactorRequirement"
+
+	required, err := s.twoFactorRequired(ctx, user.Role)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	if !required {
+		return nil
+	}
+	tf, err := s.tf.Find(ctx, user.Id)
+	if err != nil && !errors.Is(err, domain.ErrTwoFactorNotFound) {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return domain.ErrInternalServer
+	}
+	if tf == nil || !tf.Enabled() {
+		return domain.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+func (s *Service) twoFactorRequired(ctx context.Context, role domain.Role) (bool, error) {
+	roles, err := s.tf.RequiredRoles(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// revokeChallenge deletes the challenge after max attempts, so codes
+// can't be guessed with a single password check
+func (s *Service) revokeChallenge(ctx context.Context, c *domain.LoginChallenge) {
+	const op string = "user.service.revokeChallenge"
+
+	if err := s.tf.DeleteChallenge(ctx, c.Token); err != nil {
+		logger.Log(logger.WARN, errors.Wrap(err, op).Error())
+	}
+}
+
+// checkCode checks a TOTP code, or a backup code when they're allowed,
+// accepted codes are used up
+func (s *Service) checkCode(ctx context.Context, tf *domain.TwoFactor, code string, backup bool, now time.Time) (bool, error) {
+	code = normalizeCode(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(tf.Secret, code, now, s.tp.Skew)
+		if !ok {
+			return false, nil
+		}
+		return s.tf.UseStep(ctx, tf.UserId, step)
+	}
+	if !backup || code == "" {
+		return false, nil
+	}
+	return s.tf.UseBackupCode(ctx, tf.UserId, code)
+}
+
+// contextTwoFactor returns enrolment of the context user
+func (s *Service) contextTwoFactor(ctx context.Context) (*domain.TwoFactor, error) {
+	const op string = "user.service.contextTwoFactor"
+
+	u, err := domain.UserFromContext(ctx)
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	tf, err := s.tf.Find(ctx, u.Id)
+	if err != nil {
+		if errors.Is(err, domain.ErrTwoFactorNotFound) {
+			return nil, domain.ErrTwoFactorNotFound
+		}
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	return tf, nil
+}
+
+// enabledTwoFactor returns enabled 2FA of the context user when code is valid
+func (s *Service) enabledTwoFactor(ctx context.Context, code string) (*domain.TwoFactor, error) {
+	const op string = "user.service.enabledTwoFactor"
+
+	tf, err := s.contextTwoFactor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled() {
+		return nil, domain.ErrTwoFactorNotFound
+	}
+	ok, err := s.checkCode(ctx, tf, code, true, time.Now())
+	if err != nil {
+		logger.Log(logger.ERROR, errors.Wrap(err, op).Error())
+		return nil, domain.ErrInternalServer
+	}
+	if !ok {
+		return nil, domain.ErrWrongCredentials
+	}
+	return tf, nil
+}
+
+// newEnrollment saves a new unconfirmed secret of the user
+func (s *Service) newEnrollment(ctx context.Context, user *domain.User) (*domain.TwoFactorEnrollment, error) {
+	const op string = "user.service.newEnrollment"
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	err = s.tf.Save(ctx, domain.TwoFactor{
+		UserId:    user.Id,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	return &domain.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.tp.Issuer, user.Username, secret),
+	}, nil
+}
+
+// confirmTwoFactor enables the enrolment and returns its backup codes
+func (s *Service) confirmTwoFactor(ctx context.Context, tf *domain.TwoFactor, now time.Time) ([]string, error) {
+	const op string = "user.service.confirmTwoFactor"
+
+	tf.ConfirmedAt = &now
+	if err := s.tf.Save(ctx, *tf); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	codes, err := s.newBackupCodes(ctx, tf.UserId)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	logger.Log(logger.INFO, fmt.Sprintf("2FA of user %d enabled", tf.UserId))
+	return codes, nil
+}
+
+// backupCodeAlphabet has no look-alike characters (0/o, 1/l/i)
+const backupCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// newBackupCodes replaces backup codes of the user, codes look like "abcde-23456"
+func (s *Service) newBackupCodes(ctx context.Context, userId uint) ([]string, error) {
+	const op string = "user.service.newBackupCodes"
+
+	codes := make([]string, s.tp.BackupCodes)
+	normalized := make([]string, s.tp.BackupCodes)
+	max := big.NewInt(int64(len(backupCodeAlphabet)))
+	for i := range codes {
+		b := make([]byte, 10)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, errors.Wrap(err, op)
+			}
+			b[j] = backupCodeAlphabet[n.Int64()]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+		normalized[i] = string(b)
+	}
+	if err := s.tf.ReplaceBackupCodes(ctx, userId, normalized); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+	return codes, nil
+}
+
+// normalizeCode lets users type codes with spaces, dashes or upper case
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
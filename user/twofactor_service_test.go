@@ -0,0 +1,191 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apm-dev/vending-machine/domain"
+	"github.com/apm-dev/vending-machine/domain/mocks"
+	"github.com/apm-dev/vending-machine/pkg/totp"
+	"github.com/apm-dev/vending-machine/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Service_Login_TwoFactor(t *testing.T) {
+	ur := new(mocks.UserRepository)
+	jr := new(mocks.JwtRepository)
+	la := new(mocks.LoginAttemptRepository)
+	tf := new(mocks.TwoFactorRepository)
+
+	jwt, err := user.NewJWTManager(
+		[]*user.JWTKey{user.NewHMACKey("", "secret")}, "", time.Minute, time.Hour,
+	)
+	require.NoError(t, err)
+	lp := domain.LoginPolicy{MaxFailures: 5, MaxIPFailures: 20, Window: time.Minute}
+	tp := domain.TwoFactorPolicy{Issuer: "VM", ChallengeTTL: time.Minute, MaxAttempts: 3, Skew: 1, BackupCodes: 4}
+	user.UserService = nil
+	svc := user.InitService(ur, jr, la, tf, jwt, lp, domain.PasswordPolicy{}, tp, time.Second)
+
+	ctx := context.WithValue(context.Background(), domain.CLIENT, domain.ClientInfo{IP: "10.0.0.1"})
+	now := time.Now()
+	sam, err := domain.NewUser("sam", "secret", domain.SELLER)
+	require.NoError(t, err)
+	sam.Id = 7
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	code, err := totp.Code(secret, now)
+	require.NoError(t, err)
+
+	for _, k := range []string{"user:sam", "ip:10.0.0.1"} {
+		la.On("Failures", mock.Anything, k).Return(&domain.LoginFailures{Key: k}, nil)
+	}
+	// attempts are reserved before checking, passed ones are taken back
+	la.On("AddFailure", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(uint(1), nil)
+	la.On("RemoveFailure", mock.Anything, mock.Anything).Return(nil)
+
+	t.Run("should issue a challenge instead of tokens when 2FA is enabled", func(t *testing.T) {
+		ur.On("FindByUsername", mock.Anything, "sam").Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).
+			Return(&domain.TwoFactor{UserId: 7, Secret: secret, ConfirmedAt: &now}, nil).Once()
+		tf.On("InsertChallenge", mock.Anything, mock.MatchedBy(func(c domain.LoginChallenge) bool {
+			return c.UserId == 7 && !c.Enroll && c.Token != ""
+		})).Return(nil).Once()
+
+		result, err := svc.Login(ctx, "sam", "secret")
+		require.NoError(t, err)
+		assert.Nil(t, result.TokenPair)
+		require.NotNil(t, result.Challenge)
+		assert.EqualValues(t, 60, result.Challenge.ExpiresIn)
+	})
+
+	t.Run("should enrol on login when role requires 2FA", func(t *testing.T) {
+		ur.On("FindByUsername", mock.Anything, "sam").Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).Return(nil, domain.ErrTwoFactorNotFound).Once()
+		tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.SELLER}, nil).Once()
+		tf.On("Save", mock.Anything, mock.MatchedBy(func(f domain.TwoFactor) bool {
+			return f.UserId == 7 && f.Secret != "" && !f.Enabled()
+		})).Return(nil).Once()
+		tf.On("InsertChallenge", mock.Anything, mock.MatchedBy(func(c domain.LoginChallenge) bool {
+			return c.UserId == 7 && c.Enroll
+		})).Return(nil).Once()
+
+		result, err := svc.Login(ctx, "sam", "secret")
+		require.NoError(t, err)
+		require.NotNil(t, result.Challenge)
+		require.NotNil(t, result.Challenge.Enrollment)
+		assert.Contains(t, result.Challenge.Enrollment.URI, "otpauth://totp/VM:sam?")
+	})
+
+	t.Run("should issue tokens for a valid code", func(t *testing.T) {
+		tf.On("FindChallenge", mock.Anything, "ch-1").
+			Return(&domain.LoginChallenge{Token: "ch-1", UserId: 7}, nil).Once()
+		ur.On("FindById", mock.Anything, uint(7)).Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).
+			Return(&domain.TwoFactor{UserId: 7, Secret: secret, ConfirmedAt: &now}, nil).Once()
+		tf.On("AddChallengeAttempt", mock.Anything, "ch-1").Return(uint(1), nil).Once()
+		tf.On("UseStep", mock.Anything, uint(7), totp.Step(now)).Return(true, nil).Once()
+		tf.On("DeleteChallenge", mock.Anything, "ch-1").Return(nil).Once()
+		la.On("Reset", mock.Anything, "user:sam").Return(nil).Once()
+		jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+		jr.On("Insert", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(nil).Once()
+		jr.On("InsertRefresh", mock.Anything, mock.Anything).Return(nil).Once()
+		jr.On("Commit").Once()
+		jr.On("UserTokensCount", mock.Anything, uint(7)).Return(uint(1), nil).Once()
+
+		result, err := svc.VerifyLogin(ctx, "ch-1", code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		assert.Empty(t, result.BackupCodes)
+	})
+
+	t.Run("should reject a replayed code and revoke challenge after max attempts", func(t *testing.T) {
+		tf.On("FindChallenge", mock.Anything, "ch-2").
+			Return(&domain.LoginChallenge{Token: "ch-2", UserId: 7}, nil).Once()
+		ur.On("FindById", mock.Anything, uint(7)).Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).
+			Return(&domain.TwoFactor{UserId: 7, Secret: secret, ConfirmedAt: &now}, nil).Once()
+		// the last allowed attempt
+		tf.On("AddChallengeAttempt", mock.Anything, "ch-2").Return(uint(3), nil).Once()
+		tf.On("UseStep", mock.Anything, uint(7), totp.Step(now)).Return(false, nil).Once()
+		tf.On("DeleteChallenge", mock.Anything, "ch-2").Return(nil).Once()
+
+		result, err := svc.VerifyLogin(ctx, "ch-2", code)
+		assert.ErrorIs(t, err, domain.ErrWrongCredentials)
+		assert.Nil(t, result)
+	})
+
+	t.Run("should reject attempts beyond max without checking the code", func(t *testing.T) {
+		tf.On("FindChallenge", mock.Anything, "ch-4").
+			Return(&domain.LoginChallenge{Token: "ch-4", UserId: 7}, nil).Once()
+		ur.On("FindById", mock.Anything, uint(7)).Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).
+			Return(&domain.TwoFactor{UserId: 7, Secret: secret, ConfirmedAt: &now}, nil).Once()
+		// parallel attempts took the remaining ones
+		tf.On("AddChallengeAttempt", mock.Anything, "ch-4").Return(uint(4), nil).Once()
+		tf.On("DeleteChallenge", mock.Anything, "ch-4").Return(nil).Once()
+
+		result, err := svc.VerifyLogin(ctx, "ch-4", code)
+		assert.ErrorIs(t, err, domain.ErrInvalidToken)
+		assert.Nil(t, result)
+	})
+
+	t.Run("should issue an enrolment challenge instead of tokens on register when role requires 2FA", func(t *testing.T) {
+		ur.On("Insert", mock.Anything, mock.Anything).Return(uint(8), nil).Once()
+		tf.On("Find", mock.Anything, uint(8)).Return(nil, domain.ErrTwoFactorNotFound).Once()
+		tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.SELLER}, nil).Once()
+		tf.On("Save", mock.Anything, mock.MatchedBy(func(f domain.TwoFactor) bool {
+			return f.UserId == 8 && !f.Enabled()
+		})).Return(nil).Once()
+		tf.On("InsertChallenge", mock.Anything, mock.MatchedBy(func(c domain.LoginChallenge) bool {
+			return c.UserId == 8 && c.Enroll
+		})).Return(nil).Once()
+
+		result, err := svc.Register(ctx, "max", "secret", domain.SELLER)
+		require.NoError(t, err)
+		assert.Nil(t, result.TokenPair)
+		require.NotNil(t, result.Challenge)
+		assert.NotNil(t, result.Challenge.Enrollment)
+	})
+
+	t.Run("should confirm enrolment on login and return backup codes", func(t *testing.T) {
+		tf.On("FindChallenge", mock.Anything, "ch-3").
+			Return(&domain.LoginChallenge{Token: "ch-3", UserId: 7, Enroll: true}, nil).Once()
+		ur.On("FindById", mock.Anything, uint(7)).Return(sam, nil).Once()
+		tf.On("Find", mock.Anything, uint(7)).
+			Return(&domain.TwoFactor{UserId: 7, Secret: secret}, nil).Once()
+		tf.On("AddChallengeAttempt", mock.Anything, "ch-3").Return(uint(1), nil).Once()
+		tf.On("UseStep", mock.Anything, uint(7), totp.Step(now)).Return(true, nil).Once()
+		tf.On("DeleteChallenge", mock.Anything, "ch-3").Return(nil).Once()
+		la.On("Reset", mock.Anything, "user:sam").Return(nil).Once()
+		tf.On("Save", mock.Anything, mock.MatchedBy(func(f domain.TwoFactor) bool {
+			return f.UserId == 7 && f.Enabled()
+		})).Return(nil).Once()
+		tf.On("ReplaceBackupCodes", mock.Anything, uint(7), mock.MatchedBy(func(codes []string) bool {
+			return len(codes) == 4
+		})).Return(nil).Once()
+		jr.On("BeginTransaction", mock.Anything).Return(context.Background(), jr).Once()
+		jr.On("Insert", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(nil).Once()
+		jr.On("InsertRefresh", mock.Anything, mock.Anything).Return(nil).Once()
+		jr.On("Commit").Once()
+		jr.On("UserTokensCount", mock.Anything, uint(7)).Return(uint(1), nil).Once()
+
+		result, err := svc.VerifyLogin(ctx, "ch-3", code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		assert.Len(t, result.BackupCodes, 4)
+	})
+
+	t.Run("should not disable 2FA when role requires it", func(t *testing.T) {
+		tf.On("RequiredRoles", mock.Anything).Return([]domain.Role{domain.SELLER}, nil).Once()
+
+		err := svc.DisableTwoFactor(context.WithValue(ctx, domain.USER, sam), code)
+		assert.ErrorIs(t, err, domain.ErrTwoFactorRequired)
+	})
+
+	ur.AssertExpectations(t)
+	jr.AssertExpectations(t)
+	tf.AssertExpectations(t)
+}